@@ -0,0 +1,145 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"mittens/internal/pkg/accesslog"
+	"mittens/internal/pkg/http"
+	"time"
+)
+
+const (
+	accessLogShuffle = "shuffle"
+	accessLogReplay  = "replay"
+)
+
+// AccessLog stores flags related to the HTTP requests read from access logs.
+type AccessLog struct {
+	Files           stringArray
+	Format          string
+	Mode            string
+	ReplaySpeed     float64
+	SampleRate      float64
+	IncludePaths    stringArray
+	ExcludePaths    stringArray
+	DropQueryParams stringArray
+	Replacements    stringArray
+}
+
+func (a *AccessLog) String() string {
+	return fmt.Sprintf("%+v", *a)
+}
+
+func (a *AccessLog) initFlags(fs *flag.FlagSet) {
+	fs.Var(&a.Files, "access-log-files", "Access logs whose requests are sent to the target. Can be repeated.")
+	fs.StringVar(&a.Format, "access-log-format", accesslog.COMBINED, "Format of the access logs: `combined`, `common`, `json` (one object per line) or `csv` (with a header row).")
+	fs.StringVar(&a.Mode, "access-log-mode", accessLogShuffle, "How the requests of the access logs are sent: `shuffle` picks them at random along with `http-requests`, `replay` sends them in their original order and at their original pace.")
+	fs.Float64Var(&a.ReplaySpeed, "access-log-replay-speed", 1, "Speed at which the access logs are replayed, e.g. 2 sends the requests twice as fast as they were logged. Only applies if `access-log-mode` is `replay`.")
+	fs.Float64Var(&a.SampleRate, "access-log-sample-rate", 1, "Share of the requests of the access logs which are sent, between 0 (excluded) and 1.")
+	fs.Var(&a.IncludePaths, "access-log-include-paths", "Regular expression which the path of a request must match to be read from the access logs. Can be repeated. If not set, any path is read.")
+	fs.Var(&a.ExcludePaths, "access-log-exclude-paths", "Regular expression which the path of a request must not match to be read from the access logs, e.g. ^/health$. Can be repeated.")
+	fs.Var(&a.DropQueryParams, "access-log-drop-query-params", "Query parameter which is removed from the requests of the access logs, e.g. email. Can be repeated.")
+	fs.Var(&a.Replacements, "access-log-replace", "Rule in the '<regex>=<replacement>' format applied to the path, query and body of the requests of the access logs, e.g. '/users/\\d+=/users/{$range|min=1,max=1000}'. Can be repeated.")
+}
+
+func (a *AccessLog) options() (accesslog.Options, error) {
+	if a.Mode != accessLogShuffle && a.Mode != accessLogReplay {
+		return accesslog.Options{}, fmt.Errorf("access log mode %q not supported, please use %s or %s", a.Mode, accessLogShuffle, accessLogReplay)
+	}
+	if a.ReplaySpeed <= 0 {
+		return accesslog.Options{}, fmt.Errorf("access log replay speed must be greater than 0")
+	}
+	if a.SampleRate <= 0 || a.SampleRate > 1 {
+		return accesslog.Options{}, fmt.Errorf("access log sample rate must be greater than 0 and at most 1")
+	}
+	return accesslog.Options{
+		Format:          a.Format,
+		SampleRate:      a.SampleRate,
+		IncludePaths:    a.IncludePaths,
+		ExcludePaths:    a.ExcludePaths,
+		DropQueryParams: a.DropQueryParams,
+		Replacements:    a.Replacements,
+	}, nil
+}
+
+// getEntries reads the requests of the access logs, one log after the other. The times of each log are shifted so that
+// its first request comes right after the last request of the previous log, which keeps the pace of the replay.
+func (a *AccessLog) getEntries(compression http.CompressionType) ([]accesslog.Entry, error) {
+	if len(a.Files) == 0 {
+		return nil, nil
+	}
+	options, err := a.options()
+	if err != nil {
+		return nil, err
+	}
+	var entries []accesslog.Entry
+	for _, file := range a.Files {
+		fileEntries, skipped, err := accesslog.Read(file, options, compression)
+		if err != nil {
+			return nil, err
+		}
+		if skipped > 0 {
+			log.Printf("⚠️ Skipped %d line(s) of access log %s which could not be read or have an unsupported method", skipped, file)
+		}
+		if len(fileEntries) == 0 {
+			return nil, fmt.Errorf("no requests were read from access log %s", file)
+		}
+		if len(entries) > 0 {
+			shift(fileEntries, entries[len(entries)-1].Time)
+		}
+		entries = append(entries, fileEntries...)
+	}
+	return entries, nil
+}
+
+// shift moves the times of the entries so that the first one is at the given time. Entries without a time are left as they are.
+func shift(entries []accesslog.Entry, to time.Time) {
+	if to.IsZero() || entries[0].Time.IsZero() {
+		return
+	}
+	offset := to.Sub(entries[0].Time)
+	for i := range entries {
+		if !entries[i].Time.IsZero() {
+			entries[i].Time = entries[i].Time.Add(offset)
+		}
+	}
+}
+
+// getHTTPRequests returns the requests of the access logs which are picked at random, if they are not replayed.
+func (a *AccessLog) getHTTPRequests(compression http.CompressionType) ([]http.Request, error) {
+	if a.Mode == accessLogReplay {
+		return nil, nil
+	}
+	entries, err := a.getEntries(compression)
+	if err != nil {
+		return nil, err
+	}
+	var requests []http.Request
+	for _, entry := range entries {
+		requests = append(requests, entry.Request)
+	}
+	return requests, nil
+}
+
+// getReplay returns the requests of the access logs which are replayed, if any.
+func (a *AccessLog) getReplay(compression http.CompressionType) ([]accesslog.Entry, error) {
+	if a.Mode != accessLogReplay {
+		return nil, nil
+	}
+	return a.getEntries(compression)
+}
@@ -0,0 +1,72 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessLogFiles_Shuffle(t *testing.T) {
+	file := writeFile(t, "access.log", `127.0.0.1 - - [10/Oct/2024:13:55:36 +0000] "GET /hotels/42?email=a%40b.com HTTP/1.1" 200 10
+127.0.0.1 - - [10/Oct/2024:13:55:37 +0000] "GET /health HTTP/1.1" 200 2
+`)
+	r, _ := newTestRoot(t, "-http-requests=get:/ping", "-access-log-files="+file, "-access-log-exclude-paths=^/health", "-access-log-drop-query-params=email")
+
+	targets, err := r.GetWarmupTargets()
+	require.NoError(t, err)
+	requests := targets[0].HTTPRequests
+	require.Equal(t, 2, len(requests))
+	assert.Equal(t, "/hotels/42", requests[1].Path)
+	assert.Empty(t, targets[0].Replay)
+}
+
+func TestAccessLogFiles_Replay(t *testing.T) {
+	first := writeFile(t, "first.log", `{"time": "2024-10-10T13:55:36Z", "method": "GET", "path": "/a"}
+{"time": "2024-10-10T13:55:38Z", "method": "GET", "path": "/b"}
+`)
+	second := writeFile(t, "second.log", `{"time": "2020-01-01T00:00:00Z", "method": "GET", "path": "/c"}
+{"time": "2020-01-01T00:00:01Z", "method": "GET", "path": "/d"}
+`)
+	r, _ := newTestRoot(t, "-access-log-files="+first, "-access-log-files="+second, "-access-log-format=json", "-access-log-mode=replay")
+
+	targets, err := r.GetWarmupTargets()
+	require.NoError(t, err)
+	assert.Empty(t, targets[0].HTTPRequests, "replayed requests are not picked at random")
+	replay := targets[0].Replay
+	require.Equal(t, 4, len(replay))
+	assert.Equal(t, "/c", replay[2].Request.Path)
+	assert.Equal(t, 3*time.Second, replay[3].Time.Sub(replay[0].Time), "each log follows the previous one")
+}
+
+func TestAccessLogFiles_Invalid(t *testing.T) {
+	file := writeFile(t, "access.log", `127.0.0.1 - - [10/Oct/2024:13:55:36 +0000] "GET /hotels HTTP/1.1" 200 10`+"\n")
+	tests := map[string][]string{
+		"access log mode \"random\" not supported":             {"-access-log-files=" + file, "-access-log-mode=random"},
+		"access log replay speed must be greater than 0":       {"-access-log-files=" + file, "-access-log-replay-speed=0"},
+		"access log sample rate must be greater than 0":        {"-access-log-files=" + file, "-access-log-sample-rate=0"},
+		"no requests were read from access log":                {"-access-log-files=" + file, "-access-log-include-paths=^/search"},
+		"access-log-files cannot be used with targets":         {"-access-log-files=" + file, `-targets={"name": "app"}`},
+		"invalid replacement /hotels, expected format <regex>": {"-access-log-files=" + file, "-access-log-replace=/hotels"},
+	}
+	for expected, args := range tests {
+		r, _ := newTestRoot(t, args...)
+		_, err := r.GetWarmupTargets()
+		assert.ErrorContains(t, err, expected)
+	}
+}
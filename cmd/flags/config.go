@@ -0,0 +1,263 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"mittens/internal/pkg/accesslog"
+	"mittens/internal/pkg/grpc"
+	"mittens/internal/pkg/http"
+	"mittens/internal/pkg/rampup"
+	"mittens/internal/pkg/scenario"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// validators check values which the flag parser accepts but which would otherwise only be rejected once the warmup starts.
+// Running them while loading the config file or the environment allows us to report the key that holds the invalid value.
+var validators = map[string]func(value string) error{
+	"http-requests": func(value string) error {
+		_, err := http.ToHTTPRequest(value, http.COMPRESSION_NONE)
+		return err
+	},
+	"grpc-requests": func(value string) error {
+		_, err := grpc.ToGrpcRequest(value)
+		return err
+	},
+	"http-requests-compression": oneOf(string(http.COMPRESSION_NONE), string(http.COMPRESSION_GZIP), string(http.COMPRESSION_BROTLI), string(http.COMPRESSION_DEFLATE)),
+	"targets": func(value string) error {
+		_, err := parseTarget(value)
+		return err
+	},
+	"scenarios": func(value string) error {
+		_, err := scenario.ToScenario(value, http.COMPRESSION_NONE)
+		return err
+	},
+	"datasets": func(value string) error {
+		_, err := parseDataset(value)
+		return err
+	},
+	"access-log-format": oneOf(accesslog.COMBINED, accesslog.COMMON, accesslog.JSON, accesslog.CSV),
+	"access-log-mode":   oneOf(accessLogShuffle, accessLogReplay),
+	"access-log-replace": func(value string) error {
+		_, err := accesslog.ToRule(value)
+		return err
+	},
+	"target-http-protocol":      oneOf(string(http.HTTP1), string(http.HTTP2), string(http.H2C)),
+	"target-readiness-protocol": oneOf("http", "grpc"),
+	"ramp-up-profile":           oneOf(rampup.LINEAR, rampup.STEP, rampup.EXPONENTIAL, rampup.STAGES),
+	"ramp-up-stages": func(value string) error {
+		_, err := rampup.ParseStages(value)
+		return err
+	},
+}
+
+// structuredFlags are the flags that accept a JSON object. In the config file their values can be written as mappings.
+var structuredFlags = map[string]bool{
+	"http-requests": true,
+	"grpc-requests": true,
+	"targets":       true,
+	"scenarios":     true,
+	"datasets":      true,
+}
+
+// configFile applies the content of a YAML or JSON config file to a flag set.
+// Keys are flag names. Nested mappings are joined with a dash so that `target: {http-port: 8080}` sets `target-http-port`.
+type configFile struct {
+	fs   *flag.FlagSet
+	path string
+	// set holds the flags that were set on the command line or through environment variables. These take precedence over the values in the file.
+	set map[string]bool
+}
+
+// LoadConfigFile applies the options of the file set in `config` to every flag that was not set on the command line or through an environment variable.
+func (r *Root) LoadConfigFile() error {
+	if r.ConfigFile == "" {
+		return nil
+	}
+	return loadConfigFile(flag.CommandLine, r.ConfigFile)
+}
+
+func loadConfigFile(fs *flag.FlagSet, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read config file: %v", err)
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return fmt.Errorf("unable to parse config file %s: %v", path, err)
+	}
+	// an empty file has no content
+	if len(document.Content) == 0 {
+		return nil
+	}
+
+	c := configFile{fs: fs, path: path, set: setFlags(fs)}
+	return c.applyMapping(document.Content[0], "", "")
+}
+
+// applyMapping applies every key of a mapping. prefix is the flag name built so far and key is the dotted path used in errors.
+func (c configFile) applyMapping(node *yaml.Node, prefix string, key string) error {
+	node = resolveAlias(node)
+	if node.Kind != yaml.MappingNode {
+		return c.errorf(node, key, "expected a mapping")
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], resolveAlias(node.Content[i+1])
+		name := joinKey(prefix, keyNode.Value, "-")
+		childKey := joinKey(key, keyNode.Value, ".")
+
+		if f := c.fs.Lookup(name); f != nil {
+			if err := c.applyFlag(f, childKey, valueNode); err != nil {
+				return err
+			}
+			continue
+		}
+		if valueNode.Kind == yaml.MappingNode && c.hasFlagWithPrefix(name+"-") {
+			if err := c.applyMapping(valueNode, name, childKey); err != nil {
+				return err
+			}
+			continue
+		}
+		return c.errorf(keyNode, childKey, "unknown option")
+	}
+	return nil
+}
+
+// applyFlag sets a single flag. Lists are only accepted for flags that can be repeated on the command line.
+func (c configFile) applyFlag(f *flag.Flag, key string, node *yaml.Node) error {
+	if c.set[f.Name] {
+		return nil
+	}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return c.setFlag(f, key, node)
+	case yaml.SequenceNode:
+		if _, ok := f.Value.(*stringArray); !ok {
+			return c.errorf(node, key, "expected a single value but got a list")
+		}
+		for i, item := range node.Content {
+			item = resolveAlias(item)
+			itemKey := fmt.Sprintf("%s[%d]", key, i)
+			if item.Kind == yaml.MappingNode && structuredFlags[f.Name] {
+				if err := c.setFlagFromMapping(f, itemKey, item); err != nil {
+					return err
+				}
+				continue
+			}
+			if item.Kind != yaml.ScalarNode {
+				return c.errorf(item, itemKey, "expected a string")
+			}
+			if err := c.setFlag(f, itemKey, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return c.errorf(node, key, "expected a value or a list of values")
+	}
+}
+
+func (c configFile) setFlag(f *flag.Flag, key string, node *yaml.Node) error {
+	if err := setFlag(c.fs, f.Name, node.Value); err != nil {
+		return c.errorf(node, key, "%v", err)
+	}
+	return nil
+}
+
+// setFlagFromMapping sets a flag to the JSON representation of a mapping.
+func (c configFile) setFlagFromMapping(f *flag.Flag, key string, node *yaml.Node) error {
+	var value map[string]interface{}
+	if err := node.Decode(&value); err != nil {
+		return c.errorf(node, key, "%v", err)
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return c.errorf(node, key, "%v", err)
+	}
+	if err := setFlag(c.fs, f.Name, string(encoded)); err != nil {
+		return c.errorf(node, key, "%v", err)
+	}
+	return nil
+}
+
+func (c configFile) hasFlagWithPrefix(prefix string) bool {
+	found := false
+	c.fs.VisitAll(func(f *flag.Flag) {
+		if strings.HasPrefix(f.Name, prefix) {
+			found = true
+		}
+	})
+	return found
+}
+
+func (c configFile) errorf(node *yaml.Node, key string, format string, args ...interface{}) error {
+	return fmt.Errorf("%s:%d: %s: %s", c.path, node.Line, key, fmt.Sprintf(format, args...))
+}
+
+// setFlag validates and sets the value of a flag.
+func setFlag(fs *flag.FlagSet, name string, value string) error {
+	if validate, ok := validators[name]; ok {
+		if err := validate(value); err != nil {
+			return err
+		}
+	}
+	if err := fs.Set(name, value); err != nil {
+		return fmt.Errorf("invalid value %q: %v", value, err)
+	}
+	return nil
+}
+
+// setFlags returns the names of the flags that have been set so far.
+func setFlags(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+func joinKey(prefix string, key string, separator string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + separator + key
+}
+
+// oneOf returns a validator that only accepts the given values.
+func oneOf(allowed ...string) func(value string) error {
+	return func(value string) error {
+		for _, a := range allowed {
+			if value == a {
+				return nil
+			}
+		}
+		return fmt.Errorf("value %q is not supported, expected one of %q", value, allowed)
+	}
+}
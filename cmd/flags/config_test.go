@@ -0,0 +1,186 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRoot(t *testing.T, args ...string) (*Root, *flag.FlagSet) {
+	fs := flag.NewFlagSet("mittens", flag.ContinueOnError)
+	r := &Root{}
+	r.initFlags(fs)
+	require.NoError(t, fs.Parse(args))
+	return r, fs
+}
+
+// writeFile writes the content to a file with the given name, in a directory which is removed once the test finishes.
+func writeFile(t *testing.T, name string, content string) string {
+	file := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+	return file
+}
+
+func TestConfig_NestedAndFlatKeys(t *testing.T) {
+	file := writeFile(t, "mittens.yaml", `
+max-duration-seconds: 120
+concurrency: 4
+file-probe:
+  enabled: false
+target:
+  http-port: 9090
+  readiness-http-path: /health
+http:
+  requests:
+    - get:/ping
+    - post:/search:{"query":"mittens"}
+  requests-compression: gzip
+http-headers:
+  - "X-Foo: bar"
+grpc:
+  requests:
+    - health/ping
+`)
+	r, fs := newTestRoot(t)
+	require.NoError(t, loadConfigFile(fs, file))
+
+	assert.Equal(t, 120, r.MaxDurationSeconds)
+	assert.Equal(t, 4, r.Concurrency)
+	assert.False(t, r.FileProbe.Enabled)
+	assert.Equal(t, 9090, r.Target.HTTPPort)
+	assert.Equal(t, "/health", r.Target.ReadinessHTTPPath)
+	assert.Equal(t, stringArray{"get:/ping", `post:/search:{"query":"mittens"}`}, r.HTTP.Requests)
+	assert.Equal(t, "gzip", r.HTTP.Compression)
+	assert.Equal(t, stringArray{"X-Foo: bar"}, r.HTTPHeaders.Headers)
+	assert.Equal(t, stringArray{"health/ping"}, r.Grpc.Requests)
+}
+
+func TestConfig_JSONFile(t *testing.T) {
+	file := writeFile(t, "mittens.yaml", `{"target": {"grpc-port": 6565}, "http-requests": ["get:/ping"]}`)
+	r, fs := newTestRoot(t)
+	require.NoError(t, loadConfigFile(fs, file))
+
+	assert.Equal(t, 6565, r.Target.GrpcPort)
+	assert.Equal(t, stringArray{"get:/ping"}, r.HTTP.Requests)
+}
+
+func TestConfig_CommandLineTakesPrecedence(t *testing.T) {
+	file := writeFile(t, "mittens.yaml", `
+concurrency: 4
+http:
+  requests:
+    - get:/from-file
+`)
+	r, fs := newTestRoot(t, "-concurrency=8", "-http-requests=get:/from-flag")
+	require.NoError(t, loadConfigFile(fs, file))
+
+	assert.Equal(t, 8, r.Concurrency)
+	assert.Equal(t, stringArray{"get:/from-flag"}, r.HTTP.Requests)
+}
+
+func TestConfig_UnknownKey(t *testing.T) {
+	file := writeFile(t, "mittens.yaml", `
+target:
+  http-prot: 8080
+`)
+	_, fs := newTestRoot(t)
+	err := loadConfigFile(fs, file)
+
+	require.Error(t, err)
+	assert.Equal(t, file+":3: target.http-prot: unknown option", err.Error())
+}
+
+func TestConfig_InvalidValue(t *testing.T) {
+	file := writeFile(t, "mittens.yaml", `
+target:
+  http-port: abc
+`)
+	_, fs := newTestRoot(t)
+	err := loadConfigFile(fs, file)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), file+`:3: target.http-port: invalid value "abc"`)
+}
+
+func TestConfig_InvalidRequest(t *testing.T) {
+	file := writeFile(t, "mittens.yaml", `
+http:
+  requests:
+    - get:/ping
+    - get/health
+`)
+	_, fs := newTestRoot(t)
+	err := loadConfigFile(fs, file)
+
+	require.Error(t, err)
+	assert.Equal(t, file+":5: http.requests[1]: invalid request flag: get/health, expected format <http-method>:<path>[:body]", err.Error())
+}
+
+func TestConfig_ListForSingleValueFlag(t *testing.T) {
+	file := writeFile(t, "mittens.yaml", `
+concurrency: [1, 2]
+`)
+	_, fs := newTestRoot(t)
+	err := loadConfigFile(fs, file)
+
+	require.Error(t, err)
+	assert.Equal(t, file+":2: concurrency: expected a single value but got a list", err.Error())
+}
+
+func TestConfig_StructuredRequest(t *testing.T) {
+	file := writeFile(t, "mittens.yaml", `
+http:
+  requests:
+    - get:/ping
+    - name: search
+      method: post
+      path: /search
+      headers:
+        Content-Type: application/json
+      timeout-milliseconds: 200
+`)
+	r, fs := newTestRoot(t)
+	require.NoError(t, loadConfigFile(fs, file))
+
+	requests, err := r.GetWarmupHTTPRequests()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(requests))
+	assert.Equal(t, "search", requests[1].Name)
+	assert.Equal(t, "/search", requests[1].Path)
+	assert.Equal(t, map[string]string{"Content-Type": "application/json"}, requests[1].Headers)
+	assert.Equal(t, 200, requests[1].TimeoutMilliseconds)
+}
+
+func TestConfig_InvalidStructuredRequest(t *testing.T) {
+	file := writeFile(t, "mittens.yaml", `
+http:
+  requests:
+    - method: get
+      path: /ping
+      timeout: 10
+`)
+	_, fs := newTestRoot(t)
+	err := loadConfigFile(fs, file)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), file+`:4: http.requests[0]: invalid request`)
+	assert.Contains(t, err.Error(), `unknown field "timeout"`)
+}
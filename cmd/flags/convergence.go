@@ -0,0 +1,63 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"mittens/internal/pkg/convergence"
+	"time"
+)
+
+// Convergence stores flags related to stopping the warmup once latencies have converged.
+type Convergence struct {
+	Enabled          bool
+	TolerancePercent float64
+	WindowSeconds    int
+	MinSeconds       int
+	MinRequests      int
+	MaxErrorPercent  float64
+}
+
+func (c *Convergence) String() string {
+	return fmt.Sprintf("%+v", *c)
+}
+
+func (c *Convergence) initFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&c.Enabled, "convergence-enabled", false, "If set to true the warmup stops before `max-warmup-seconds` once the latency of every request has converged")
+	fs.Float64Var(&c.TolerancePercent, "convergence-tolerance-percent", 10, "Maximum deviation, in percent, of the average latency of each second from the average latency of the window")
+	fs.IntVar(&c.WindowSeconds, "convergence-window-seconds", 10, "How long latencies must stay within the tolerance for the warmup to stop")
+	fs.IntVar(&c.MinSeconds, "convergence-min-seconds", 10, "Minimum duration of the warmup, even if latencies converged earlier")
+	fs.IntVar(&c.MinRequests, "convergence-min-requests", 20, "Minimum number of successful responses for each request before the warmup can stop")
+	fs.Float64Var(&c.MaxErrorPercent, "convergence-max-error-percent", 0, "Maximum percentage of the warmup requests of each request which failed or got no response during the window. The warmup does not stop while it is exceeded")
+}
+
+// getDetector returns nil if convergence is not enabled.
+func (c *Convergence) getDetector() (*convergence.Detector, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+	options := convergence.Options{
+		Tolerance:    c.TolerancePercent / 100,
+		Window:       time.Duration(c.WindowSeconds) * time.Second,
+		MinDuration:  time.Duration(c.MinSeconds) * time.Second,
+		MinRequests:  c.MinRequests,
+		MaxErrorRate: c.MaxErrorPercent / 100,
+	}
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	return convergence.New(options), nil
+}
@@ -0,0 +1,53 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"mittens/internal/pkg/convergence"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvergence_Disabled(t *testing.T) {
+	r, _ := newTestRoot(t)
+
+	detector, err := r.GetConvergenceDetector()
+	require.NoError(t, err)
+	assert.Nil(t, detector)
+}
+
+func TestConvergence_Enabled(t *testing.T) {
+	r, _ := newTestRoot(t, "-convergence-enabled", "-convergence-tolerance-percent=5", "-convergence-window-seconds=20", "-convergence-max-error-percent=1")
+
+	detector, err := r.GetConvergenceDetector()
+	require.NoError(t, err)
+	require.NotNil(t, detector)
+	assert.Equal(t, convergence.Options{Tolerance: 0.05, Window: 20 * time.Second, MinDuration: 10 * time.Second, MinRequests: 20, MaxErrorRate: 0.01}, detector.Options)
+}
+
+func TestConvergence_Invalid(t *testing.T) {
+	r, _ := newTestRoot(t, "-convergence-enabled", "-convergence-window-seconds=1")
+
+	_, err := r.GetConvergenceDetector()
+	assert.EqualError(t, err, "window must be at least 2s, got 1s")
+
+	r, _ = newTestRoot(t, "-convergence-enabled", "-convergence-max-error-percent=101")
+
+	_, err = r.GetConvergenceDetector()
+	assert.EqualError(t, err, "max error rate must be between 0 and 100%, got 101%")
+}
@@ -0,0 +1,120 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"mittens/internal/pkg/dataset"
+	"mittens/internal/pkg/http"
+	"regexp"
+	"strings"
+)
+
+// Datasets stores the files whose rows are used by data placeholders, e.g. {$data|hotels.id}.
+type Datasets struct {
+	Datasets stringArray
+}
+
+// jsonDataset is the JSON representation of a dataset. Unlike the <name>=<file> format it allows setting how the rows are picked.
+type jsonDataset struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	Mode string `json:"mode"`
+}
+
+// datasetNameRegex matches the names which can be used in data placeholders.
+var datasetNameRegex = regexp.MustCompile(`^[\w-]+$`)
+
+func (d *Datasets) String() string {
+	return fmt.Sprintf("%+v", *d)
+}
+
+func (d *Datasets) initFlags(fs *flag.FlagSet) {
+	fs.Var(&d.Datasets, "datasets", `CSV or JSON lines file whose rows are used by {$data|<name>.<column>} placeholders, in the '<name>=<file>' format. Can be repeated. Alternatively a JSON object with the name, file and mode of the dataset, where the mode is sequential (the default), random or once. E.g. {"name": "hotels", "file": "hotels.csv", "mode": "random"}`)
+}
+
+// parseDataset reads a dataset flag, either in the <name>=<file> format or as a JSON object.
+func parseDataset(value string) (jsonDataset, error) {
+	var j jsonDataset
+	if strings.HasPrefix(strings.TrimSpace(value), "{") {
+		decoder := json.NewDecoder(strings.NewReader(value))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&j); err != nil {
+			return jsonDataset{}, fmt.Errorf("invalid dataset: %s, %v", value, err)
+		}
+	} else {
+		var ok bool
+		if j.Name, j.File, ok = strings.Cut(value, "="); !ok {
+			return jsonDataset{}, fmt.Errorf("invalid dataset: %s, expected format <name>=<file>", value)
+		}
+	}
+	if !datasetNameRegex.MatchString(j.Name) {
+		return jsonDataset{}, fmt.Errorf("invalid dataset: %s, name must only contain letters, digits, _ and -", value)
+	}
+	if j.File == "" {
+		return jsonDataset{}, fmt.Errorf("invalid dataset: %s, file is required", value)
+	}
+	return j, nil
+}
+
+// getDatasets loads the datasets by name.
+func (d *Datasets) getDatasets() (map[string]*dataset.Dataset, error) {
+	datasets := make(map[string]*dataset.Dataset)
+	for _, value := range d.Datasets {
+		j, err := parseDataset(value)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := datasets[j.Name]; ok {
+			return nil, fmt.Errorf("duplicate dataset %s", j.Name)
+		}
+		loaded, err := dataset.Load(j.Name, j.File, j.Mode)
+		if err != nil {
+			return nil, err
+		}
+		datasets[j.Name] = loaded
+	}
+	return datasets, nil
+}
+
+// bindDatasets gives each request which uses data placeholders a cursor over the rows of its datasets. Requests which use
+// a dataset whose mode is once are picked along with the other requests until the cursor has no rows left.
+func bindDatasets(requests []http.Request, datasets map[string]*dataset.Dataset) error {
+	for i := range requests {
+		references := requests[i].DataReferences()
+		if len(references) == 0 {
+			continue
+		}
+		var used []*dataset.Dataset
+		seen := make(map[string]bool)
+		for _, reference := range references {
+			d, ok := datasets[reference.Dataset]
+			if !ok {
+				return fmt.Errorf("invalid request %s: dataset %s is not set", requests[i].DisplayName(), reference.Dataset)
+			}
+			if !d.Columns[reference.Column] {
+				return fmt.Errorf("invalid request %s: dataset %s has no column %s", requests[i].DisplayName(), reference.Dataset, reference.Column)
+			}
+			if !seen[d.Name] {
+				seen[d.Name] = true
+				used = append(used, d)
+			}
+		}
+		requests[i].Data = dataset.NewCursor(used...)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatasets(t *testing.T) {
+	hotels := writeFile(t, "hotels.csv", "id,locale\n42,en_GB\n7,fr_FR\n")
+	r, _ := newTestRoot(t,
+		"-datasets=hotels="+hotels,
+		`-datasets={"name": "once", "file": "`+hotels+`", "mode": "once"}`,
+		"-http-requests=get:/hotels/{$data|hotels.id}?locale={$data|hotels.locale}",
+		"-http-requests=get:/reviews/{$data|once.id}",
+		"-http-requests=get:/ping",
+	)
+
+	targets, err := r.GetWarmupTargets()
+	require.NoError(t, err)
+	requests := targets[0].HTTPRequests
+	require.Equal(t, 3, len(requests))
+
+	first, err := requests[0].WithData()
+	require.NoError(t, err)
+	assert.Equal(t, "/hotels/42?locale=en_GB", first.Path, "the columns of the same row are used together")
+	second, err := requests[0].WithData()
+	require.NoError(t, err)
+	assert.Equal(t, "/hotels/7?locale=fr_FR", second.Path)
+
+	assert.Equal(t, 0, requests[1].Count, "requests using a once dataset are picked by weight until they have no rows left")
+	for i := 0; i < 2; i++ {
+		assert.False(t, requests[1].Data.Done())
+		_, err = requests[1].WithData()
+		require.NoError(t, err)
+	}
+	assert.True(t, requests[1].Data.Done())
+	assert.Nil(t, requests[2].Data)
+}
+
+func TestDatasets_ConfigFile(t *testing.T) {
+	hotels := writeFile(t, "hotels.jsonl", `{"id": 42}`+"\n")
+	file := writeFile(t, "mittens.yaml", `
+datasets:
+  - name: hotels
+    file: `+hotels+`
+    mode: random
+http-requests:
+  - get:/hotels/{$data|hotels.id}
+`)
+	r, fs := newTestRoot(t)
+	require.NoError(t, loadConfigFile(fs, file))
+
+	targets, err := r.GetWarmupTargets()
+	require.NoError(t, err)
+	request, err := targets[0].HTTPRequests[0].WithData()
+	require.NoError(t, err)
+	assert.Equal(t, "/hotels/42", request.Path)
+}
+
+func TestDatasets_Invalid(t *testing.T) {
+	hotels := writeFile(t, "hotels.csv", "id\n42\n")
+	tests := map[string][]string{
+		"expected format <name>=<file>":                 {"-datasets=" + hotels},
+		"name must only contain letters":                {"-datasets=hotel.ids=" + hotels},
+		"duplicate dataset hotels":                      {"-datasets=hotels=" + hotels, "-datasets=hotels=" + hotels},
+		"dataset cities is not set":                     {"-datasets=hotels=" + hotels, "-http-requests=get:/cities/{$data|cities.id}"},
+		"dataset hotels has no column name":             {"-datasets=hotels=" + hotels, "-http-requests=get:/hotels/{$data|hotels.name}"},
+		"data placeholders cannot be used in scenarios": {"-datasets=hotels=" + hotels, `-scenarios={"name": "a", "steps": [{"http": "get:/hotels/{$data|hotels.id}"}]}`},
+		"target app: invalid request GET /hotels":       {"-datasets=hotels=" + hotels, `-targets={"name": "app", "http-requests": ["get:/hotels/{$data|hotels.name}"]}`},
+	}
+	for expected, args := range tests {
+		r, _ := newTestRoot(t, args...)
+		_, err := r.GetWarmupTargets()
+		assert.ErrorContains(t, err, expected)
+	}
+}
@@ -0,0 +1,116 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const envPrefix = "MITTENS_"
+
+// LoadEnv applies the MITTENS_* environment variables to every flag that was not set on the command line.
+//
+// Options are resolved in the following order, from highest to lowest precedence:
+// command line, environment variables, config file and default values.
+func (r *Root) LoadEnv() error {
+	return loadEnv(flag.CommandLine, os.Environ())
+}
+
+// envName returns the environment variable bound to a flag, e.g. MITTENS_TARGET_HTTP_PORT for `target-http-port`.
+// Flags that can be repeated also accept indexed variables, e.g. MITTENS_HTTP_REQUESTS_0, MITTENS_HTTP_REQUESTS_1.
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+func loadEnv(fs *flag.FlagSet, environ []string) error {
+	variables := make(map[string]string)
+	for _, kv := range environ {
+		name, value, found := strings.Cut(kv, "=")
+		if found && strings.HasPrefix(name, envPrefix) {
+			variables[name] = value
+		}
+	}
+
+	set := setFlags(fs)
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		names := []string{envName(f.Name)}
+		if _, ok := f.Value.(*stringArray); ok {
+			names = append(names, indexedEnvNames(f.Name, variables)...)
+		}
+		for _, name := range names {
+			value, ok := variables[name]
+			if !ok {
+				continue
+			}
+			delete(variables, name)
+			// flags set on the command line take precedence
+			if set[f.Name] {
+				continue
+			}
+			if setErr := setFlag(fs, f.Name, value); setErr != nil {
+				err = fmt.Errorf("%s: %v", name, setErr)
+				return
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for name := range variables {
+		log.Printf("Ignoring environment variable %s as it does not match any option", name)
+	}
+	return nil
+}
+
+// indexedEnvNames returns the variables named after a repeatable flag and suffixed with an index, sorted by index.
+// Gaps between indexes are allowed.
+func indexedEnvNames(flagName string, variables map[string]string) []string {
+	prefix := envName(flagName) + "_"
+	indexes := make(map[int]string)
+	for name := range variables {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		index, err := strconv.Atoi(strings.TrimPrefix(name, prefix))
+		if err != nil || index < 0 {
+			// not an indexed variable of this flag, e.g. MITTENS_HTTP_REQUESTS_COMPRESSION
+			continue
+		}
+		indexes[index] = name
+	}
+
+	sorted := make([]int, 0, len(indexes))
+	for index := range indexes {
+		sorted = append(sorted, index)
+	}
+	sort.Ints(sorted)
+
+	names := make([]string, 0, len(sorted))
+	for _, index := range sorted {
+		names = append(names, indexes[index])
+	}
+	return names
+}
@@ -0,0 +1,85 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnv_EnvName(t *testing.T) {
+	assert.Equal(t, "MITTENS_TARGET_HTTP_PORT", envName("target-http-port"))
+	assert.Equal(t, "MITTENS_CONCURRENCY", envName("concurrency"))
+}
+
+func TestEnv_SetsFlags(t *testing.T) {
+	r, fs := newTestRoot(t)
+	environ := []string{
+		"PATH=/usr/bin",
+		"MITTENS_TARGET_HTTP_PORT=9090",
+		"MITTENS_FILE_PROBE_ENABLED=false",
+		"MITTENS_HTTP_REQUESTS_COMPRESSION=gzip",
+		"MITTENS_HTTP_REQUESTS_10=get:/third",
+		"MITTENS_HTTP_REQUESTS_2=get:/second",
+		"MITTENS_HTTP_REQUESTS_0=get:/first",
+		"MITTENS_GRPC_REQUESTS=health/ping",
+	}
+
+	require.NoError(t, loadEnv(fs, environ))
+
+	assert.Equal(t, 9090, r.Target.HTTPPort)
+	assert.False(t, r.FileProbe.Enabled)
+	assert.Equal(t, "gzip", r.HTTP.Compression)
+	assert.Equal(t, stringArray{"get:/first", "get:/second", "get:/third"}, r.HTTP.Requests)
+	assert.Equal(t, stringArray{"health/ping"}, r.Grpc.Requests)
+}
+
+func TestEnv_CommandLineTakesPrecedence(t *testing.T) {
+	r, fs := newTestRoot(t, "-concurrency=8", "-http-requests=get:/from-flag")
+	environ := []string{
+		"MITTENS_CONCURRENCY=4",
+		"MITTENS_HTTP_REQUESTS_0=get:/from-env",
+	}
+
+	require.NoError(t, loadEnv(fs, environ))
+
+	assert.Equal(t, 8, r.Concurrency)
+	assert.Equal(t, stringArray{"get:/from-flag"}, r.HTTP.Requests)
+}
+
+func TestEnv_TakesPrecedenceOverConfigFile(t *testing.T) {
+	file := writeFile(t, "mittens.yaml", `
+concurrency: 4
+max-duration-seconds: 120
+`)
+	r, fs := newTestRoot(t)
+
+	require.NoError(t, loadEnv(fs, []string{"MITTENS_CONCURRENCY=6", "MITTENS_CONFIG=" + file}))
+	require.NoError(t, loadConfigFile(fs, r.ConfigFile))
+
+	assert.Equal(t, 6, r.Concurrency)
+	assert.Equal(t, 120, r.MaxDurationSeconds)
+}
+
+func TestEnv_InvalidValue(t *testing.T) {
+	_, fs := newTestRoot(t)
+
+	err := loadEnv(fs, []string{"MITTENS_TARGET_HTTP_PORT=abc"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `MITTENS_TARGET_HTTP_PORT: invalid value "abc"`)
+}
@@ -23,22 +23,37 @@ import (
 
 // Grpc stores flags related to gRPC requests.
 type Grpc struct {
-	Requests stringArray
+	Requests          stringArray
+	RequestsPerSecond int
+	ProtoFiles        stringArray
+	ImportPaths       stringArray
+	ProtosetFiles     stringArray
 }
 
 func (g *Grpc) String() string {
 	return fmt.Sprintf("%+v", *g)
 }
 
-func (g *Grpc) initFlags() {
-	flag.Var(&g.Requests, "grpc-requests", `gRPC requests to be sent. Request is in '<service>/<method>[:message]' format. E.g. health/ping:{"key": "value"}`)
+func (g *Grpc) initFlags(fs *flag.FlagSet) {
+	fs.Var(&g.Requests, "grpc-requests", `gRPC requests to be sent. Request is in '<service>/<method>[:message]' format. E.g. health/ping:{"key": "value"}. Alternatively requests can be a JSON object with the name, service-method, message, messages, max-responses, weight, count and once options of the request. E.g. {"service-method": "health/ping", "message": {"key": "value"}, "weight": 2}`)
+	fs.IntVar(&g.RequestsPerSecond, "grpc-requests-per-second", 0, "Target number of gRPC requests per second. 0 means no limit. If set, `request-delay-milliseconds` is ignored for gRPC requests.")
+	fs.Var(&g.ProtoFiles, "grpc-proto-files", "Proto source files describing the gRPC services, used instead of server reflection. Can be repeated.")
+	fs.Var(&g.ImportPaths, "grpc-import-paths", "Paths where the imports of `grpc-proto-files` are looked up. Can be repeated.")
+	fs.Var(&g.ProtosetFiles, "grpc-protoset-files", "Compiled FileDescriptorSet files describing the gRPC services, used instead of server reflection. Can be repeated.")
 }
 
 func (g *Grpc) getWarmupGrpcRequests() ([]grpc.Request, error) {
 	log.Print(g.Requests)
+	if err := g.getDescriptors().Validate(); err != nil {
+		return nil, err
+	}
 	return toGrpcRequests(g.Requests)
 }
 
+func (g *Grpc) getDescriptors() grpc.Descriptors {
+	return grpc.Descriptors{ProtoFiles: g.ProtoFiles, ImportPaths: g.ImportPaths, ProtosetFiles: g.ProtosetFiles}
+}
+
 func toGrpcRequests(requestsFlag []string) ([]grpc.Request, error) {
 
 	var requests []grpc.Request
@@ -15,6 +15,7 @@
 package flags
 
 import (
+	"mittens/internal/pkg/grpc"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -35,3 +36,12 @@ func TestGrpc_ToGrpcRequests(t *testing.T) {
 	assert.Equal(t, "svc1/ping", requests[0].ServiceMethod)
 	assert.Equal(t, "svc2/ping", requests[1].ServiceMethod)
 }
+
+func TestGrpc_Descriptors(t *testing.T) {
+	r, _ := newTestRoot(t, "-grpc-protoset-files=a.protoset", "-grpc-protoset-files=b.protoset")
+	assert.Equal(t, grpc.Descriptors{ProtosetFiles: []string{"a.protoset", "b.protoset"}}, r.Grpc.getDescriptors())
+
+	r, _ = newTestRoot(t, "-grpc-import-paths=protos")
+	_, err := r.GetWarmupGrpcRequests()
+	assert.EqualError(t, err, "import paths require proto files")
+}
@@ -0,0 +1,57 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"mittens/internal/pkg/har"
+	"mittens/internal/pkg/http"
+)
+
+// HAR stores flags related to the HTTP requests imported from HAR files.
+type HAR struct {
+	Files          stringArray
+	Hosts          stringArray
+	IncludeHeaders stringArray
+	ExcludeHeaders stringArray
+}
+
+func (h *HAR) String() string {
+	return fmt.Sprintf("%+v", *h)
+}
+
+func (h *HAR) initFlags(fs *flag.FlagSet) {
+	fs.Var(&h.Files, "har-files", "HAR files whose requests are sent along with `http-requests`, with their method, path, query, headers and body. Can be repeated.")
+	fs.Var(&h.Hosts, "har-hosts", "Hosts whose requests are imported from the HAR files, e.g. api.example.com. Can be repeated. If not set, requests to any host are imported.")
+	fs.Var(&h.IncludeHeaders, "har-include-headers", "Only these headers are imported from the HAR files. Can be repeated. If not set, every header is imported unless it is excluded.")
+	fs.Var(&h.ExcludeHeaders, "har-exclude-headers", "Headers which are not imported from the HAR files, e.g. Cookie. Can be repeated. Host, Content-Length, Content-Encoding and other connection headers are never imported.")
+}
+
+func (h *HAR) getHTTPRequests(compression http.CompressionType) ([]http.Request, error) {
+	options := har.Options{Hosts: h.Hosts, IncludeHeaders: h.IncludeHeaders, ExcludeHeaders: h.ExcludeHeaders}
+	var requests []http.Request
+	for _, file := range h.Files {
+		fileRequests, err := har.ToHTTPRequests(file, options, compression)
+		if err != nil {
+			return nil, err
+		}
+		if len(fileRequests) == 0 {
+			return nil, fmt.Errorf("no requests were imported from HAR file %s", file)
+		}
+		requests = append(requests, fileRequests...)
+	}
+	return requests, nil
+}
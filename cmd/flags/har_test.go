@@ -0,0 +1,53 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const harContent = `{"log": {"entries": [
+		{"request": {"method": "GET", "url": "https://api.example.com/search?q=hotels", "headers": [{"name": "Cookie", "value": "a=b"}, {"name": "Accept", "value": "*/*"}]}},
+		{"request": {"method": "GET", "url": "https://cdn.example.com/app.js", "headers": []}}
+	]}}`
+
+func TestHARFiles(t *testing.T) {
+	file := writeFile(t, "traffic.har", harContent)
+	r, _ := newTestRoot(t, "-http-requests=get:/ping", "-har-files="+file, "-har-hosts=api.example.com", "-har-exclude-headers=cookie")
+
+	requests, err := r.GetWarmupHTTPRequests()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(requests))
+	assert.Equal(t, "/ping", requests[0].Path)
+	assert.Equal(t, "/search?q=hotels", requests[1].Path)
+	assert.Equal(t, map[string]string{"Accept": "*/*"}, requests[1].Headers)
+}
+
+func TestHARFiles_Invalid(t *testing.T) {
+	file := writeFile(t, "traffic.har", harContent)
+	tests := map[string][]string{
+		"no requests were imported from HAR file": {"-har-files=" + file, "-har-hosts=www.example.com"},
+		"unable to read HAR file":                 {"-har-files=/does-not-exist.har"},
+		"cannot be used with targets":             {"-har-files=" + file, `-targets={"name": "app"}`},
+	}
+	for expected, args := range tests {
+		r, _ := newTestRoot(t, args...)
+		_, err := r.GetWarmupTargets()
+		assert.ErrorContains(t, err, expected)
+	}
+}
@@ -34,17 +34,19 @@ var allowedHTTPMethods = map[string]interface{}{
 
 // HTTP stores flags related to HTTP requests.
 type HTTP struct {
-	Requests    stringArray
-	Compression string
+	Requests          stringArray
+	Compression       string
+	RequestsPerSecond int
 }
 
 func (h *HTTP) String() string {
 	return fmt.Sprintf("%+v", *h)
 }
 
-func (h *HTTP) initFlags() {
-	flag.Var(&h.Requests, "http-requests", `HTTP request to be sent. Request is in '<http-method>:<path>[:body]' format. E.g. post:/ping:{"key":"value"}`)
-	flag.StringVar(&h.Compression, "http-requests-compression", "", "Compression is disabled by default. Allows compression of Http body either with `gzip`, `deflate` or `brotli`. Using one of the compression algorithms also the according `Content-Encoding` header is added.")
+func (h *HTTP) initFlags(fs *flag.FlagSet) {
+	fs.Var(&h.Requests, "http-requests", `HTTP request to be sent. Request is in '<http-method>:<path>[:body]' format. E.g. post:/ping:{"key":"value"}. Alternatively requests can be a JSON object with the name, method, path, body, headers, timeout-milliseconds, weight, count and once options of the request. E.g. {"name": "ping", "method": "post", "path": "/ping", "headers": {"Content-Type": "application/json"}}`)
+	fs.StringVar(&h.Compression, "http-requests-compression", "", "Compression is disabled by default. Allows compression of Http body either with `gzip`, `deflate` or `brotli`. Using one of the compression algorithms also the according `Content-Encoding` header is added.")
+	fs.IntVar(&h.RequestsPerSecond, "http-requests-per-second", 0, "Target number of HTTP requests per second. 0 means no limit. If set, `request-delay-milliseconds` is ignored for HTTP requests.")
 }
 
 func (h *HTTP) getWarmupHTTPRequests() ([]http.Request, error) {
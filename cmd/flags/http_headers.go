@@ -28,8 +28,8 @@ func (h *HTTPHeaders) String() string {
 	return fmt.Sprintf("%+v", *h)
 }
 
-func (h *HTTPHeaders) initFlags() {
-	flag.Var(&h.Headers, "http-headers", "HTTP header to be sent with warm up requests.")
+func (h *HTTPHeaders) initFlags(fs *flag.FlagSet) {
+	fs.Var(&h.Headers, "http-headers", "HTTP header to be sent with warm up requests.")
 }
 
 func (h *HTTPHeaders) getWarmupHTTPHeaders() []string {
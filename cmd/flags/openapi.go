@@ -0,0 +1,53 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"mittens/internal/pkg/http"
+	"mittens/internal/pkg/openapi"
+)
+
+// OpenAPI stores flags related to the HTTP requests generated from OpenAPI documents.
+type OpenAPI struct {
+	Files stringArray
+	Tags  stringArray
+}
+
+func (o *OpenAPI) String() string {
+	return fmt.Sprintf("%+v", *o)
+}
+
+func (o *OpenAPI) initFlags(fs *flag.FlagSet) {
+	fs.Var(&o.Files, "openapi-files", "OpenAPI 3 documents, in JSON or YAML, with a request generated for each of their operations and sent along with `http-requests`. Can be repeated.")
+	fs.Var(&o.Tags, "openapi-tags", "Tags of the operations which are warmed up from the OpenAPI documents. Can be repeated. If not set, every operation is warmed up.")
+}
+
+func (o *OpenAPI) getHTTPRequests(compression http.CompressionType) ([]http.Request, error) {
+	options := openapi.Options{Tags: o.Tags}
+	var requests []http.Request
+	for _, file := range o.Files {
+		fileRequests, err := openapi.ToHTTPRequests(file, options, compression)
+		if err != nil {
+			return nil, err
+		}
+		if len(fileRequests) == 0 {
+			return nil, fmt.Errorf("no requests were generated from OpenAPI file %s", file)
+		}
+		requests = append(requests, fileRequests...)
+	}
+	return requests, nil
+}
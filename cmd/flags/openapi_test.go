@@ -0,0 +1,63 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const openAPIContent = `
+openapi: 3.0.0
+paths:
+  /hotels/{id}:
+    get:
+      tags: [hotels]
+      parameters:
+        - name: id
+          in: path
+          required: true
+          example: 42
+  /admin/reindex:
+    post:
+      tags: [admin]
+`
+
+func TestOpenAPIFiles(t *testing.T) {
+	file := writeFile(t, "openapi.yaml", openAPIContent)
+	r, _ := newTestRoot(t, "-http-requests=get:/ping", "-openapi-files="+file, "-openapi-tags=hotels")
+
+	requests, err := r.GetWarmupHTTPRequests()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(requests))
+	assert.Equal(t, "/ping", requests[0].Path)
+	assert.Equal(t, "/hotels/42", requests[1].Path)
+}
+
+func TestOpenAPIFiles_Invalid(t *testing.T) {
+	file := writeFile(t, "openapi.yaml", openAPIContent)
+	tests := map[string][]string{
+		"no requests were generated from OpenAPI file": {"-openapi-files=" + file, "-openapi-tags=search"},
+		"unable to read OpenAPI file":                  {"-openapi-files=/does-not-exist.yaml"},
+		"cannot be used with targets":                  {"-openapi-files=" + file, `-targets={"name": "app"}`},
+	}
+	for expected, args := range tests {
+		r, _ := newTestRoot(t, args...)
+		_, err := r.GetWarmupTargets()
+		assert.ErrorContains(t, err, expected)
+	}
+}
@@ -0,0 +1,56 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"mittens/internal/pkg/rampup"
+	"time"
+)
+
+// RampUp stores flags related to the ramp-up of the warmup traffic.
+type RampUp struct {
+	Profile string
+	Steps   int
+	Stages  string
+}
+
+func (r *RampUp) String() string {
+	return fmt.Sprintf("%+v", *r)
+}
+
+func (r *RampUp) initFlags(fs *flag.FlagSet) {
+	fs.StringVar(&r.Profile, "ramp-up-profile", rampup.LINEAR, "How concurrency and target rates grow during `concurrency-target-seconds`. One of linear, step, exponential or stages.")
+	fs.IntVar(&r.Steps, "ramp-up-steps", 4, "Number of equal increments used by the step ramp-up profile")
+	fs.StringVar(&r.Stages, "ramp-up-stages", "", "Stages used by the stages ramp-up profile, in the '<duration>:<concurrency>' format and separated by commas. E.g. 10s:2,20s:8,30s:16. The last stage is held until the warmup finishes.")
+}
+
+func (r *RampUp) getRampUpProfile(concurrency int, concurrencyTargetSeconds int) (rampup.Profile, error) {
+	profile := rampup.Profile{
+		Kind:        r.Profile,
+		Concurrency: concurrency,
+		Duration:    time.Duration(concurrencyTargetSeconds) * time.Second,
+		Steps:       r.Steps,
+	}
+	if r.Profile == rampup.STAGES {
+		stages, err := rampup.ParseStages(r.Stages)
+		if err != nil {
+			return profile, err
+		}
+		profile.Stages = stages
+	}
+	return profile, profile.Validate()
+}
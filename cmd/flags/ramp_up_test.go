@@ -0,0 +1,60 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"mittens/internal/pkg/rampup"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRampUp_DefaultProfile(t *testing.T) {
+	r, _ := newTestRoot(t, "-concurrency=4", "-concurrency-target-seconds=10")
+
+	profile, err := r.GetRampUpProfile()
+	require.NoError(t, err)
+	assert.Equal(t, rampup.Profile{Kind: rampup.LINEAR, Concurrency: 4, Duration: 10 * time.Second, Steps: 4}, profile)
+}
+
+func TestRampUp_StagesProfile(t *testing.T) {
+	r, _ := newTestRoot(t, "-ramp-up-profile=stages", "-ramp-up-stages=10s:2,20s:8")
+
+	profile, err := r.GetRampUpProfile()
+	require.NoError(t, err)
+	assert.Equal(t, []rampup.Stage{{Duration: 10 * time.Second, Concurrency: 2}, {Duration: 20 * time.Second, Concurrency: 8}}, profile.Stages)
+	assert.Equal(t, 8, profile.MaxWorkers())
+}
+
+func TestRampUp_StagesProfileWithoutStages(t *testing.T) {
+	r, _ := newTestRoot(t, "-ramp-up-profile=stages")
+
+	_, err := r.GetRampUpProfile()
+	assert.EqualError(t, err, "stages profile requires at least one stage")
+}
+
+func TestRampUp_InvalidProfileInConfigFile(t *testing.T) {
+	file := writeFile(t, "mittens.yaml", `
+ramp-up:
+  profile: sine
+`)
+	_, fs := newTestRoot(t)
+	err := loadConfigFile(fs, file)
+
+	require.Error(t, err)
+	assert.Equal(t, file+`:3: ramp-up.profile: value "sine" is not supported, expected one of ["linear" "step" "exponential" "stages"]`, err.Error())
+}
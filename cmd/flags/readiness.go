@@ -0,0 +1,48 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"mittens/internal/pkg/stats"
+	"time"
+)
+
+// ReadinessThresholds stores flags related to the thresholds the warmup must meet when `fail-readiness` is set.
+type ReadinessThresholds struct {
+	MaxErrorRatePercent    float64
+	MinSuccessesPerRequest int
+	MaxP99LatencyMillis    int
+}
+
+func (r *ReadinessThresholds) String() string {
+	return fmt.Sprintf("%+v", *r)
+}
+
+func (r *ReadinessThresholds) initFlags(fs *flag.FlagSet) {
+	fs.Float64Var(&r.MaxErrorRatePercent, "fail-readiness-max-error-rate", 100, "Maximum percentage of warmup requests which failed or got no response. Only applies if `fail-readiness` is set to true.")
+	fs.IntVar(&r.MinSuccessesPerRequest, "fail-readiness-min-successes-per-request", 0, "Minimum number of successful responses for each warmup request. 0 means no minimum. Only applies if `fail-readiness` is set to true.")
+	fs.IntVar(&r.MaxP99LatencyMillis, "fail-readiness-max-p99-milliseconds", 0, "Maximum p99 latency in the last `report-window-seconds` of the warmup. 0 means no limit. Only applies if `fail-readiness` is set to true.")
+}
+
+func (r *ReadinessThresholds) getThresholds() (stats.Thresholds, error) {
+	thresholds := stats.Thresholds{
+		MaxErrorRate:            r.MaxErrorRatePercent / 100,
+		MinSuccessesPerEndpoint: r.MinSuccessesPerRequest,
+		MaxP99:                  time.Duration(r.MaxP99LatencyMillis) * time.Millisecond,
+	}
+	return thresholds, thresholds.Validate()
+}
@@ -0,0 +1,47 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"mittens/internal/pkg/stats"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadinessThresholds_Default(t *testing.T) {
+	r, _ := newTestRoot(t)
+
+	thresholds, err := r.GetReadinessThresholds()
+	require.NoError(t, err)
+	assert.Equal(t, stats.Thresholds{MaxErrorRate: 1}, thresholds)
+}
+
+func TestReadinessThresholds(t *testing.T) {
+	r, _ := newTestRoot(t, "-fail-readiness-max-error-rate=2.5", "-fail-readiness-min-successes-per-request=10", "-fail-readiness-max-p99-milliseconds=250")
+
+	thresholds, err := r.GetReadinessThresholds()
+	require.NoError(t, err)
+	assert.Equal(t, stats.Thresholds{MaxErrorRate: 0.025, MinSuccessesPerEndpoint: 10, MaxP99: 250 * time.Millisecond}, thresholds)
+}
+
+func TestReadinessThresholds_Invalid(t *testing.T) {
+	r, _ := newTestRoot(t, "-fail-readiness-max-error-rate=150")
+
+	_, err := r.GetReadinessThresholds()
+	assert.EqualError(t, err, "max error rate must be between 0 and 100%, got 150%")
+}
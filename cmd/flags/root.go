@@ -15,11 +15,16 @@
 package flags
 
 import (
+	"crypto/tls"
 	"flag"
 	"fmt"
+	"mittens/internal/pkg/convergence"
 	"mittens/internal/pkg/grpc"
 	"mittens/internal/pkg/http"
+	"mittens/internal/pkg/rampup"
+	"mittens/internal/pkg/stats"
 	"mittens/internal/pkg/warmup"
+	"time"
 )
 
 // Root stores all the flags.
@@ -30,13 +35,28 @@ type Root struct {
 	Concurrency              int
 	RequestDelayMilliseconds int
 	ConcurrencyTargetSeconds int
+	RequestsPerSecond        int
+	MaxInFlightRequests      int
+	ReportWindowSeconds      int
+	ReportFile               string
 	ExitAfterWarmup          bool
 	FailReadiness            bool
+	ConfigFile               string
+	ReadinessThresholds
+	Convergence
 	FileProbe
+	Server
 	Target
+	Targets
+	Scenarios
 	HTTP
+	HAR
+	OpenAPI
+	AccessLog
+	Datasets
 	HTTPHeaders
 	Grpc
+	RampUp
 }
 
 func (r *Root) String() string {
@@ -45,21 +65,40 @@ func (r *Root) String() string {
 
 // InitFlags initialises all the flags.
 func (r *Root) InitFlags() {
+	r.initFlags(flag.CommandLine)
+}
+
+func (r *Root) initFlags(fs *flag.FlagSet) {
 	// TODO: rename this to `max-global-duration-seconds`
-	flag.IntVar(&r.MaxDurationSeconds, "max-duration-seconds", 60, "Global maximum duration. This includes both the time spent warming up the target service and also the time waiting for the target to become ready")
-	flag.IntVar(&r.MaxReadinessWaitSeconds, "max-readiness-wait-seconds", 30, "Maximum time to wait for the target to become ready")
-	flag.IntVar(&r.MaxWarmupDurationSeconds, "max-warmup-seconds", 30, "Maximum time spent sending warmup requests to the target service. Please note that `max-duration-seconds` may cap this duration.")
-	flag.IntVar(&r.Concurrency, "concurrency", 2, "Number of concurrent requests for warm up")
-	flag.IntVar(&r.RequestDelayMilliseconds, "request-delay-milliseconds", 500, "Delay in milliseconds between requests")
-	flag.IntVar(&r.ConcurrencyTargetSeconds, "concurrency-target-seconds", 0, "Time taken to reach expected concurrency. This is useful to ramp up traffic.")
-	flag.BoolVar(&r.ExitAfterWarmup, "exit-after-warmup", false, "If warm up process should finish after completion. This is useful to prevent container restarts.")
-	flag.BoolVar(&r.FailReadiness, "fail-readiness", false, "If set to true readiness will fail if no requests were sent.")
-
-	r.FileProbe.initFlags()
-	r.Target.initFlags()
-	r.HTTPHeaders.initFlags()
-	r.HTTP.initFlags()
-	r.Grpc.initFlags()
+	fs.IntVar(&r.MaxDurationSeconds, "max-duration-seconds", 60, "Global maximum duration. This includes both the time spent warming up the target service and also the time waiting for the target to become ready")
+	fs.IntVar(&r.MaxReadinessWaitSeconds, "max-readiness-wait-seconds", 30, "Maximum time to wait for the target to become ready")
+	fs.IntVar(&r.MaxWarmupDurationSeconds, "max-warmup-seconds", 30, "Maximum time spent sending warmup requests to the target service. Please note that `max-duration-seconds` may cap this duration.")
+	fs.IntVar(&r.Concurrency, "concurrency", 2, "Number of concurrent requests for warm up")
+	fs.IntVar(&r.RequestDelayMilliseconds, "request-delay-milliseconds", 500, "Delay in milliseconds between requests")
+	fs.IntVar(&r.ConcurrencyTargetSeconds, "concurrency-target-seconds", 0, "Time taken to reach expected concurrency. This is useful to ramp up traffic.")
+	fs.IntVar(&r.RequestsPerSecond, "requests-per-second", 0, "Target number of requests per second across HTTP and gRPC. 0 means no limit. If set, `request-delay-milliseconds` is ignored.")
+	fs.IntVar(&r.MaxInFlightRequests, "max-in-flight-requests", 100, "Maximum number of requests waiting for a response, for each protocol, when a target rate is set. Requests are then sent at the rate whatever the `concurrency`.")
+	fs.IntVar(&r.ReportWindowSeconds, "report-window-seconds", 10, "Duration of the first and last windows of the warmup whose latencies are compared in the summary. 0 disables the comparison.")
+	fs.StringVar(&r.ReportFile, "report-file", "", "Path of a file to write a JSON report to once the warmup finishes. No report is written if empty.")
+	fs.BoolVar(&r.ExitAfterWarmup, "exit-after-warmup", false, "If warm up process should finish after completion. This is useful to prevent container restarts.")
+	fs.BoolVar(&r.FailReadiness, "fail-readiness", false, "If set to true readiness will fail if no requests were sent or the warmup does not meet the `fail-readiness-*` thresholds.")
+	fs.StringVar(&r.ConfigFile, "config", "", "Path to a YAML or JSON file with the options to use. Options set on the command line take precedence over the ones in the file.")
+
+	r.ReadinessThresholds.initFlags(fs)
+	r.Convergence.initFlags(fs)
+	r.FileProbe.initFlags(fs)
+	r.Server.initFlags(fs)
+	r.Target.initFlags(fs)
+	r.Targets.initFlags(fs)
+	r.Scenarios.initFlags(fs)
+	r.HTTPHeaders.initFlags(fs)
+	r.HTTP.initFlags(fs)
+	r.HAR.initFlags(fs)
+	r.OpenAPI.initFlags(fs)
+	r.AccessLog.initFlags(fs)
+	r.Datasets.initFlags(fs)
+	r.Grpc.initFlags(fs)
+	r.RampUp.initFlags(fs)
 }
 
 // GetMaxDurationSeconds returns the value of the max-duration-seconds parameter.
@@ -87,34 +126,77 @@ func (r *Root) GetConcurrency() int {
 	return r.Concurrency
 }
 
-// GetReadinessHTTPClient creates the HTTP client to be used for the readiness requests.
-func (r *Root) GetReadinessHTTPClient() http.Client {
-	return r.Target.getReadinessHTTPClient()
+// GetRampUpProfile validates and returns the ramp-up profile built from the concurrency, concurrency-target-seconds and ramp-up-* parameters.
+func (r *Root) GetRampUpProfile() (rampup.Profile, error) {
+	return r.RampUp.getRampUpProfile(r.Concurrency, r.ConcurrencyTargetSeconds)
+}
+
+// GetRequestsPerSecond returns the value of the requests-per-second parameter.
+func (r *Root) GetRequestsPerSecond() int {
+	return r.RequestsPerSecond
+}
+
+// GetMaxInFlightRequests validates and returns the value of the max-in-flight-requests parameter.
+func (r *Root) GetMaxInFlightRequests() (int, error) {
+	if r.MaxInFlightRequests < 1 {
+		return 0, fmt.Errorf("max in-flight requests must be greater than 0, got %d", r.MaxInFlightRequests)
+	}
+	return r.MaxInFlightRequests, nil
+}
+
+// GetHTTPRequestsPerSecond returns the value of the http-requests-per-second parameter.
+func (r *Root) GetHTTPRequestsPerSecond() int {
+	return r.HTTP.RequestsPerSecond
+}
+
+// GetGrpcRequestsPerSecond returns the value of the grpc-requests-per-second parameter.
+func (r *Root) GetGrpcRequestsPerSecond() int {
+	return r.Grpc.RequestsPerSecond
+}
+
+// GetReportWindow returns the value of the report-window-seconds parameter as a duration.
+func (r *Root) GetReportWindow() time.Duration {
+	return time.Duration(r.ReportWindowSeconds) * time.Second
+}
+
+// GetReadinessThresholds validates and returns the thresholds built from the fail-readiness-* parameters.
+func (r *Root) GetReadinessThresholds() (stats.Thresholds, error) {
+	return r.ReadinessThresholds.getThresholds()
 }
 
-// GetReadinessGrpcClient creates the gRPC client to be used for the readiness requests.
-func (r *Root) GetReadinessGrpcClient() grpc.Client {
-	return r.Target.getReadinessGrpcClient()
+// GetConvergenceDetector validates the convergence-* parameters and returns the detector used to stop the warmup early.
+// It returns nil if convergence is not enabled.
+func (r *Root) GetConvergenceDetector() (*convergence.Detector, error) {
+	return r.Convergence.getDetector()
 }
 
-// GetHTTPClient creates the HTTP client to be used for the actual requests.
-func (r *Root) GetHTTPClient() http.Client {
-	return r.Target.getHTTPClient()
+// IsServerEnabled returns true if any endpoint of the server exposed by mittens is enabled.
+func (r *Root) IsServerEnabled() bool {
+	return r.Server.enabled()
 }
 
-// GetGrpcClient creates the gRPC client to be used for the actual requests.
-func (r *Root) GetGrpcClient() grpc.Client {
-	return r.Target.getGrpcClient()
+// GetTLSConfig validates the target-tls-* parameters and returns the TLS configuration used by all the clients.
+func (r *Root) GetTLSConfig() (*tls.Config, error) {
+	return r.Target.getTLSConfig()
 }
 
 // GetWarmupTargetOptions validates and returns any options that apply to the target.
 func (r *Root) GetWarmupTargetOptions() (warmup.TargetOptions, error) {
 	options := r.Target.getWarmupTargetOptions()
-	if options.ReadinessProtocol != "http" && options.ReadinessProtocol != "grpc" {
-		err := fmt.Errorf("readiness protocol %s not supported, please use http or grpc", r.ReadinessProtocol)
-		return options, err
+	return options, validateReadinessProtocol(options.ReadinessProtocol)
+}
+
+func validateReadinessProtocol(protocol string) error {
+	if protocol != "http" && protocol != "grpc" {
+		return fmt.Errorf("readiness protocol %s not supported, please use http or grpc", protocol)
 	}
-	return options, nil
+	return nil
+}
+
+// GetWarmupTargets validates and returns the targets to warm up along with their requests.
+// These are either the named targets of the targets parameter or the single target of the target-* parameters.
+func (r *Root) GetWarmupTargets() ([]WarmupTarget, error) {
+	return r.getWarmupTargets()
 }
 
 // GetWarmupHTTPHeaders returns the HTTP headers.
@@ -122,13 +204,28 @@ func (r *Root) GetWarmupHTTPHeaders() []string {
 	return r.HTTPHeaders.getWarmupHTTPHeaders()
 }
 
-// GetWarmupHTTPRequests HTTP requests.
+// GetWarmupHTTPRequests HTTP requests, including the ones imported from HAR files, generated from OpenAPI documents and
+// read from access logs unless they are replayed.
 func (r *Root) GetWarmupHTTPRequests() ([]http.Request, error) {
 	requests, err := r.HTTP.getWarmupHTTPRequests()
 	if err != nil {
 		return nil, err
 	}
-	return requests, nil
+	harRequests, err := r.HAR.getHTTPRequests(http.CompressionType(r.HTTP.Compression))
+	if err != nil {
+		return nil, err
+	}
+	openAPIRequests, err := r.OpenAPI.getHTTPRequests(http.CompressionType(r.HTTP.Compression))
+	if err != nil {
+		return nil, err
+	}
+	accessLogRequests, err := r.AccessLog.getHTTPRequests(http.CompressionType(r.HTTP.Compression))
+	if err != nil {
+		return nil, err
+	}
+	requests = append(requests, harRequests...)
+	requests = append(requests, openAPIRequests...)
+	return append(requests, accessLogRequests...), nil
 }
 
 // GetWarmupGrpcRequests returns gRPC requests.
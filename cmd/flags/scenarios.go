@@ -0,0 +1,53 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"mittens/internal/pkg/http"
+	"mittens/internal/pkg/scenario"
+)
+
+// Scenarios stores the scenarios, which are sequences of requests that pass values from one response to the next requests.
+type Scenarios struct {
+	Scenarios stringArray
+}
+
+func (s *Scenarios) String() string {
+	return fmt.Sprintf("%+v", *s)
+}
+
+func (s *Scenarios) initFlags(fs *flag.FlagSet) {
+	fs.Var(&s.Scenarios, "scenarios", `Scenario to run, which is a JSON object with a name and steps sent one after the other. Can be repeated. Each step has an http or grpc request, in the format of http-requests or grpc-requests, and may extract variables from the response with a json-path, header or regex. Later steps use them as {$var|<name>} placeholders. E.g. {"name": "cart", "steps": [{"http": "post:/carts", "extract": {"cart": {"json-path": "$.id"}}}, {"http": "get:/carts/{$var|cart}"}]}`)
+}
+
+// toScenarios parses the scenarios. Their names must be unique since the results of their steps are recorded under them.
+func toScenarios(values []string, compression http.CompressionType) ([]scenario.Scenario, error) {
+	var scenarios []scenario.Scenario
+	names := make(map[string]bool)
+	for _, value := range values {
+		s, err := scenario.ToScenario(value, compression)
+		if err != nil {
+			return nil, err
+		}
+		if names[s.Name] {
+			return nil, fmt.Errorf("duplicate scenario %s", s.Name)
+		}
+		names[s.Name] = true
+		scenarios = append(scenarios, s)
+	}
+	return scenarios, nil
+}
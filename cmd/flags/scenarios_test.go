@@ -0,0 +1,82 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScenarios(t *testing.T) {
+	r, _ := newTestRoot(t,
+		"-http-requests-compression=gzip",
+		`-scenarios={"name": "cart", "steps": [{"http": "post:/carts", "extract": {"cart": {"json-path": "$.id"}}}, {"http": "put:/carts/{$var|cart}:{\"item\": 1}"}]}`,
+	)
+
+	targets, err := r.GetWarmupTargets()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(targets[0].Scenarios))
+
+	s := targets[0].Scenarios[0]
+	assert.Equal(t, "cart", s.Name)
+	request, err := s.Steps[1].HTTPRequest(map[string]string{"cart": "42"})
+	require.NoError(t, err)
+	assert.Equal(t, "/carts/42", request.Path)
+	assert.Equal(t, "gzip", request.Headers["Content-Encoding"])
+}
+
+func TestScenarios_Invalid(t *testing.T) {
+	tests := map[string][]string{
+		"duplicate scenario cart": {
+			`-scenarios={"name": "cart", "steps": [{"http": "get:/ping"}]}`,
+			`-scenarios={"name": "cart", "steps": [{"http": "get:/pong"}]}`,
+		},
+		"cannot be used with targets":                           {`-targets={"name": "app"}`, `-scenarios={"name": "cart", "steps": [{"http": "get:/ping"}]}`},
+		"target app: invalid scenario cart: steps are required": {`-targets={"name": "app", "scenarios": [{"name": "cart"}]}`},
+	}
+	for expected, args := range tests {
+		r, _ := newTestRoot(t, args...)
+		_, err := r.GetWarmupTargets()
+		assert.ErrorContains(t, err, expected)
+	}
+}
+
+func TestScenarios_ConfigFile(t *testing.T) {
+	file := writeFile(t, "mittens.yaml", `
+scenarios:
+  - name: session
+    steps:
+      - http: post:/sessions
+        extract:
+          session:
+            header: X-Session
+      - grpc:
+          service-method: sessions.Sessions/Get
+          message:
+            id: "{$var|session}"
+`)
+	r, fs := newTestRoot(t)
+	require.NoError(t, loadConfigFile(fs, file))
+
+	targets, err := r.GetWarmupTargets()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(targets[0].Scenarios))
+	steps := targets[0].Scenarios[0].Steps
+	require.Equal(t, 2, len(steps))
+	assert.Equal(t, "X-Session", steps[0].Extractions[0].Header)
+	assert.JSONEq(t, `{"id": "abc"}`, steps[1].GrpcRequest(map[string]string{"session": "abc"}).Message)
+}
@@ -0,0 +1,48 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"flag"
+	"fmt"
+)
+
+// Server stores flags related to the HTTP server exposed by mittens.
+type Server struct {
+	Port               int
+	ProbeEnabled       bool
+	ProbeLivenessPath  string
+	ProbeReadinessPath string
+	MetricsEnabled     bool
+	MetricsPath        string
+}
+
+func (s *Server) String() string {
+	return fmt.Sprintf("%+v", *s)
+}
+
+func (s *Server) initFlags(fs *flag.FlagSet) {
+	fs.IntVar(&s.Port, "server-port", 8088, "Port of the HTTP server exposed by mittens. The server is only started if one of its endpoints is enabled.")
+	fs.BoolVar(&s.ProbeEnabled, "server-probe-enabled", false, "If set to true exposes HTTP endpoints to be used as readiness/liveness probes")
+	fs.StringVar(&s.ProbeLivenessPath, "server-probe-liveness-path", "/alive", "Path of the HTTP liveness probe")
+	fs.StringVar(&s.ProbeReadinessPath, "server-probe-readiness-path", "/ready", "Path of the HTTP readiness probe")
+	fs.BoolVar(&s.MetricsEnabled, "server-metrics-enabled", false, "If set to true exposes the warmup metrics in the Prometheus format")
+	fs.StringVar(&s.MetricsPath, "server-metrics-path", "/metrics", "Path of the Prometheus metrics")
+}
+
+// enabled returns true if any endpoint of the server is enabled.
+func (s *Server) enabled() bool {
+	return s.ProbeEnabled || s.MetricsEnabled
+}
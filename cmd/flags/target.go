@@ -15,11 +15,16 @@
 package flags
 
 import (
+	"crypto/tls"
+	"errors"
 	"flag"
 	"fmt"
 	"mittens/internal/pkg/grpc"
 	"mittens/internal/pkg/http"
 	"mittens/internal/pkg/warmup"
+	"strings"
+
+	"github.com/fullstorydev/grpcurl"
 )
 
 // Target stores flags related to the target.
@@ -37,26 +42,42 @@ type Target struct {
 	ReadinessGrpcMethod     string
 	ReadinessPort           int
 	Insecure                bool
+	TLSCAFile               string
+	TLSCertFile             string
+	TLSKeyFile              string
+	TLSServerName           string
 }
 
 func (t *Target) String() string {
 	return fmt.Sprintf("%+v", *t)
 }
 
-func (t *Target) initFlags() {
-	flag.StringVar(&t.HTTPProtocol, "target-http-protocol", string(http.HTTP1), "Protocol used for HTTP requests")
-	flag.StringVar(&t.HTTPHost, "target-http-host", "http://localhost", "HTTP host to warm up")
-	flag.IntVar(&t.HTTPPort, "target-http-port", 8080, "HTTP port for warm up requests")
-	flag.IntVar(&t.HTTPTimeoutMilliseconds, "target-http-timeout-milliseconds", 10000, "HTTP timeout for requests")
-	flag.StringVar(&t.GrpcHost, "target-grpc-host", "localhost", "Grpc host to warm up")
-	flag.IntVar(&t.GrpcPort, "target-grpc-port", 50051, "Grpc port for warm up requests")
-	flag.IntVar(&t.GrpcTimeoutMilliseconds, "target-grpc-timeout-milliseconds", 1000, "Grpc timeout for requests")
-	flag.StringVar(&t.ReadinessProtocol, "target-readiness-protocol", "http", "Protocol to be used for readiness check. One of [http, grpc]")
-	flag.StringVar(&t.ReadinessHTTPPath, "target-readiness-http-path", "/ready", "The path used for HTTP target readiness probe")
-	flag.StringVar(&t.ReadinessHTTPHost, "target-readiness-http-host", toStringOrDefaultIfNull(&t.HTTPHost, "http://localhost"), "The HTTP host used for target readiness probe")
-	flag.StringVar(&t.ReadinessGrpcMethod, "target-readiness-grpc-method", "grpc.health.v1.Health/Check", "The service method used for gRPC target readiness probe")
-	flag.IntVar(&t.ReadinessPort, "target-readiness-port", toIntOrDefaultIfNull(&t.HTTPPort, 8080), "The port used for target readiness probe")
-	flag.BoolVar(&t.Insecure, "target-insecure", false, "Whether to skip TLS validation")
+func (t *Target) initFlags(fs *flag.FlagSet) {
+	fs.StringVar(&t.HTTPProtocol, "target-http-protocol", string(http.HTTP1), "Protocol used for HTTP requests")
+	fs.StringVar(&t.HTTPHost, "target-http-host", "http://localhost", "HTTP host to warm up, or a Unix socket, e.g. unix:///var/run/app.sock")
+	fs.IntVar(&t.HTTPPort, "target-http-port", 8080, "HTTP port for warm up requests")
+	fs.IntVar(&t.HTTPTimeoutMilliseconds, "target-http-timeout-milliseconds", 10000, "HTTP timeout for requests")
+	fs.StringVar(&t.GrpcHost, "target-grpc-host", "localhost", "Grpc host to warm up, or a Unix socket, e.g. unix:///var/run/app.sock")
+	fs.IntVar(&t.GrpcPort, "target-grpc-port", 50051, "Grpc port for warm up requests")
+	fs.IntVar(&t.GrpcTimeoutMilliseconds, "target-grpc-timeout-milliseconds", 1000, "Grpc timeout for requests")
+	fs.StringVar(&t.ReadinessProtocol, "target-readiness-protocol", "http", "Protocol to be used for readiness check. One of [http, grpc]")
+	fs.StringVar(&t.ReadinessHTTPPath, "target-readiness-http-path", "/ready", "The path used for HTTP target readiness probe")
+	fs.StringVar(&t.ReadinessHTTPHost, "target-readiness-http-host", toStringOrDefaultIfNull(&t.HTTPHost, "http://localhost"), "The HTTP host used for target readiness probe")
+	fs.StringVar(&t.ReadinessGrpcMethod, "target-readiness-grpc-method", "grpc.health.v1.Health/Check", "The service method used for gRPC target readiness probe")
+	fs.IntVar(&t.ReadinessPort, "target-readiness-port", toIntOrDefaultIfNull(&t.HTTPPort, 8080), "The port used for target readiness probe")
+	fs.BoolVar(&t.Insecure, "target-insecure", false, "Whether to skip TLS validation")
+	fs.StringVar(&t.TLSCAFile, "target-tls-ca-file", "", "PEM file with the certificate authorities used to verify the target, instead of the system ones")
+	fs.StringVar(&t.TLSCertFile, "target-tls-cert-file", "", "PEM file with the client certificate presented to the target (mTLS). Requires target-tls-key-file")
+	fs.StringVar(&t.TLSKeyFile, "target-tls-key-file", "", "PEM file with the private key of the client certificate")
+	fs.StringVar(&t.TLSServerName, "target-tls-server-name", "", "Server name sent to the target (SNI) and used to verify its certificate, instead of the host name")
+}
+
+// address appends the port to the host, unless the host is a Unix socket, e.g. unix:///var/run/app.sock.
+func address(host string, port int) string {
+	if strings.HasPrefix(host, http.UnixSocketPrefix) {
+		return host
+	}
+	return fmt.Sprintf("%s:%d", host, port)
 }
 
 func toIntOrDefaultIfNull(value *int, defaultValue int) int {
@@ -83,18 +104,39 @@ func (t *Target) getWarmupTargetOptions() warmup.TargetOptions {
 	}
 }
 
-func (t *Target) getReadinessHTTPClient() http.Client {
-	return http.NewClient(fmt.Sprintf("%s:%d", t.ReadinessHTTPHost, t.ReadinessPort), t.Insecure, t.HTTPTimeoutMilliseconds, http.ProtocolType(t.HTTPProtocol))
+// getTLSConfig loads the certificates and returns the TLS configuration shared by all the clients.
+func (t *Target) getTLSConfig() (*tls.Config, error) {
+	if (t.TLSCertFile == "") != (t.TLSKeyFile == "") {
+		return nil, errors.New("target-tls-cert-file and target-tls-key-file must be set together")
+	}
+	config, err := grpcurl.ClientTLSConfig(t.Insecure, t.TLSCAFile, t.TLSCertFile, t.TLSKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	config.ServerName = t.TLSServerName
+	return config, nil
+}
+
+// grpcTLSConfig returns nil when TLS is disabled, as target-insecure makes gRPC clients use plaintext.
+func (t *Target) grpcTLSConfig(tlsConfig *tls.Config) *tls.Config {
+	if t.Insecure {
+		return nil
+	}
+	return tlsConfig.Clone()
+}
+
+func (t *Target) getReadinessHTTPClient(tlsConfig *tls.Config) http.Client {
+	return http.NewClient(address(t.ReadinessHTTPHost, t.ReadinessPort), tlsConfig.Clone(), t.HTTPTimeoutMilliseconds, http.ProtocolType(t.HTTPProtocol))
 }
 
-func (t *Target) getReadinessGrpcClient() grpc.Client {
-	return grpc.NewClient(fmt.Sprintf("%s:%d", t.GrpcHost, t.ReadinessPort), t.Insecure, t.GrpcTimeoutMilliseconds)
+func (t *Target) getReadinessGrpcClient(tlsConfig *tls.Config, descriptors grpc.Descriptors) grpc.Client {
+	return grpc.NewClient(address(t.GrpcHost, t.ReadinessPort), t.grpcTLSConfig(tlsConfig), t.GrpcTimeoutMilliseconds, descriptors)
 }
 
-func (t *Target) getHTTPClient() http.Client {
-	return http.NewClient(fmt.Sprintf("%s:%d", t.HTTPHost, t.HTTPPort), t.Insecure, t.HTTPTimeoutMilliseconds, http.ProtocolType(t.HTTPProtocol))
+func (t *Target) getHTTPClient(tlsConfig *tls.Config) http.Client {
+	return http.NewClient(address(t.HTTPHost, t.HTTPPort), tlsConfig.Clone(), t.HTTPTimeoutMilliseconds, http.ProtocolType(t.HTTPProtocol))
 }
 
-func (t *Target) getGrpcClient() grpc.Client {
-	return grpc.NewClient(fmt.Sprintf("%s:%d", t.GrpcHost, t.GrpcPort), t.Insecure, t.GrpcTimeoutMilliseconds)
+func (t *Target) getGrpcClient(tlsConfig *tls.Config, descriptors grpc.Descriptors) grpc.Client {
+	return grpc.NewClient(address(t.GrpcHost, t.GrpcPort), t.grpcTLSConfig(tlsConfig), t.GrpcTimeoutMilliseconds, descriptors)
 }
@@ -0,0 +1,76 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"mittens/fixture"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSConfig_Default(t *testing.T) {
+	r, _ := newTestRoot(t)
+
+	config, err := r.GetTLSConfig()
+	require.NoError(t, err)
+	assert.False(t, config.InsecureSkipVerify)
+	assert.Nil(t, config.RootCAs)
+	assert.Empty(t, config.Certificates)
+	assert.Empty(t, config.ServerName)
+}
+
+func TestTLSConfig(t *testing.T) {
+	certificates := fixture.WriteCertificates(t.TempDir())
+	r, _ := newTestRoot(t,
+		"-target-tls-ca-file="+certificates.CAFile,
+		"-target-tls-cert-file="+certificates.ClientCertFile,
+		"-target-tls-key-file="+certificates.ClientKeyFile,
+		"-target-tls-server-name=mittens.test",
+	)
+
+	config, err := r.GetTLSConfig()
+	require.NoError(t, err)
+	assert.NotNil(t, config.RootCAs)
+	assert.Len(t, config.Certificates, 1)
+	assert.Equal(t, "mittens.test", config.ServerName)
+}
+
+func TestTLSConfig_Invalid(t *testing.T) {
+	certificates := fixture.WriteCertificates(t.TempDir())
+
+	r, _ := newTestRoot(t, "-target-tls-cert-file="+certificates.ClientCertFile)
+	_, err := r.GetTLSConfig()
+	assert.EqualError(t, err, "target-tls-cert-file and target-tls-key-file must be set together")
+
+	r, _ = newTestRoot(t, "-target-tls-ca-file=missing.pem")
+	_, err = r.GetTLSConfig()
+	assert.ErrorContains(t, err, "could not read ca certificate")
+}
+
+func TestGrpcClientIsPlaintextWhenInsecure(t *testing.T) {
+	r, _ := newTestRoot(t, "-target-insecure=true")
+
+	config, err := r.GetTLSConfig()
+	require.NoError(t, err)
+	assert.True(t, config.InsecureSkipVerify)
+	assert.Nil(t, r.Target.grpcTLSConfig(config))
+}
+
+func TestAddress(t *testing.T) {
+	assert.Equal(t, "http://localhost:8080", address("http://localhost", 8080))
+	assert.Equal(t, "unix:///var/run/app.sock", address("unix:///var/run/app.sock", 8080))
+}
@@ -0,0 +1,272 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"mittens/internal/pkg/accesslog"
+	"mittens/internal/pkg/dataset"
+	"mittens/internal/pkg/grpc"
+	"mittens/internal/pkg/http"
+	"mittens/internal/pkg/scenario"
+	"mittens/internal/pkg/warmup"
+	"strings"
+)
+
+// Targets stores the named targets which are warmed up instead of the single target of the target-* flags.
+type Targets struct {
+	Targets stringArray
+}
+
+func (t *Targets) String() string {
+	return fmt.Sprintf("%+v", *t)
+}
+
+func (t *Targets) initFlags(fs *flag.FlagSet) {
+	fs.Var(&t.Targets, "targets", `Named target to warm up, with its own host, port, protocol, readiness probe, requests and scenarios. Can be repeated. The target is a JSON object whose options default to the target-* flags. E.g. {"name": "cache", "http-port": 8081, "readiness-http-path": "/health", "http-requests": ["get:/ping"]}`)
+}
+
+// jsonTarget is the JSON representation of a named target. Options which are not set are taken from the target-* flags.
+type jsonTarget struct {
+	Name                    string            `json:"name"`
+	HTTPProtocol            *string           `json:"http-protocol"`
+	HTTPHost                *string           `json:"http-host"`
+	HTTPPort                *int              `json:"http-port"`
+	HTTPTimeoutMilliseconds *int              `json:"http-timeout-milliseconds"`
+	GrpcHost                *string           `json:"grpc-host"`
+	GrpcPort                *int              `json:"grpc-port"`
+	GrpcTimeoutMilliseconds *int              `json:"grpc-timeout-milliseconds"`
+	ReadinessProtocol       *string           `json:"readiness-protocol"`
+	ReadinessHTTPPath       *string           `json:"readiness-http-path"`
+	ReadinessHTTPHost       *string           `json:"readiness-http-host"`
+	ReadinessGrpcMethod     *string           `json:"readiness-grpc-method"`
+	ReadinessPort           *int              `json:"readiness-port"`
+	HTTPRequests            []json.RawMessage `json:"http-requests"`
+	GrpcRequests            []json.RawMessage `json:"grpc-requests"`
+	Scenarios               []json.RawMessage `json:"scenarios"`
+}
+
+// WarmupTarget is a target to warm up along with the requests sent to it.
+type WarmupTarget struct {
+	// Name is empty for the single target of the target-* flags.
+	Name         string
+	Options      warmup.TargetOptions
+	HTTPRequests []http.Request
+	GrpcRequests []grpc.Request
+	Scenarios    []scenario.Scenario
+	// Replay holds the requests of the access logs when they are replayed rather than picked at random.
+	Replay      []accesslog.Entry
+	target      Target
+	descriptors grpc.Descriptors
+}
+
+// NewTarget creates the HTTP and gRPC clients of the target.
+func (w WarmupTarget) NewTarget(tlsConfig *tls.Config) warmup.Target {
+	return warmup.NewTarget(
+		w.target.getReadinessHTTPClient(tlsConfig),
+		w.target.getReadinessGrpcClient(tlsConfig, w.descriptors),
+		w.target.getHTTPClient(tlsConfig),
+		w.target.getGrpcClient(tlsConfig, w.descriptors),
+		w.Options,
+	)
+}
+
+func parseTarget(value string) (jsonTarget, error) {
+	var t jsonTarget
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&t); err != nil {
+		return jsonTarget{}, fmt.Errorf("invalid target: %s, %v", value, err)
+	}
+	if t.Name == "" {
+		return jsonTarget{}, fmt.Errorf("invalid target: %s, name is required", value)
+	}
+	return t, nil
+}
+
+// getTarget applies the options of a named target over the ones of the target-* flags.
+// The readiness probe of a target which sets its own HTTP host or port defaults to that host or port.
+func (j jsonTarget) getTarget(defaults Target) Target {
+	t := defaults
+	setString(&t.HTTPProtocol, j.HTTPProtocol)
+	setString(&t.HTTPHost, j.HTTPHost)
+	setInt(&t.HTTPPort, j.HTTPPort)
+	setInt(&t.HTTPTimeoutMilliseconds, j.HTTPTimeoutMilliseconds)
+	setString(&t.GrpcHost, j.GrpcHost)
+	setInt(&t.GrpcPort, j.GrpcPort)
+	setInt(&t.GrpcTimeoutMilliseconds, j.GrpcTimeoutMilliseconds)
+	setString(&t.ReadinessProtocol, j.ReadinessProtocol)
+	setString(&t.ReadinessHTTPPath, j.ReadinessHTTPPath)
+	setString(&t.ReadinessHTTPHost, j.HTTPHost)
+	setString(&t.ReadinessHTTPHost, j.ReadinessHTTPHost)
+	setString(&t.ReadinessGrpcMethod, j.ReadinessGrpcMethod)
+	setInt(&t.ReadinessPort, j.HTTPPort)
+	setInt(&t.ReadinessPort, j.ReadinessPort)
+	return t
+}
+
+func setString(field *string, value *string) {
+	if value != nil {
+		*field = *value
+	}
+}
+
+func setInt(field *int, value *int) {
+	if value != nil {
+		*field = *value
+	}
+}
+
+// requestFlags turns the requests of a named target into flag values. A request is either a string in the format of the
+// http-requests and grpc-requests flags, or a JSON object.
+func requestFlags(requests []json.RawMessage) ([]string, error) {
+	var values []string
+	for _, raw := range requests {
+		var value string
+		if err := json.Unmarshal(raw, &value); err == nil {
+			values = append(values, value)
+			continue
+		}
+		if !strings.HasPrefix(strings.TrimSpace(string(raw)), "{") {
+			return nil, fmt.Errorf("invalid request: %s, expected a string or an object", raw)
+		}
+		values = append(values, string(raw))
+	}
+	return values, nil
+}
+
+// getWarmupTargets returns the named targets, or the single target of the target-* flags if there are none.
+func (r *Root) getWarmupTargets() ([]WarmupTarget, error) {
+	descriptors := r.Grpc.getDescriptors()
+	datasets, err := r.Datasets.getDatasets()
+	if err != nil {
+		return nil, err
+	}
+	if len(r.Targets.Targets) == 0 {
+		options, err := r.GetWarmupTargetOptions()
+		if err != nil {
+			return nil, err
+		}
+		httpRequests, err := r.GetWarmupHTTPRequests()
+		if err != nil {
+			return nil, err
+		}
+		grpcRequests, err := r.GetWarmupGrpcRequests()
+		if err != nil {
+			return nil, err
+		}
+		scenarios, err := toScenarios(r.Scenarios.Scenarios, http.CompressionType(r.HTTP.Compression))
+		if err != nil {
+			return nil, err
+		}
+		replay, err := r.AccessLog.getReplay(http.CompressionType(r.HTTP.Compression))
+		if err != nil {
+			return nil, err
+		}
+		target := WarmupTarget{Options: options, HTTPRequests: httpRequests, GrpcRequests: grpcRequests, Scenarios: scenarios, Replay: replay, target: r.Target, descriptors: descriptors}
+		if err := target.bindDatasets(datasets); err != nil {
+			return nil, err
+		}
+		return []WarmupTarget{target}, nil
+	}
+
+	if len(r.HTTP.Requests) > 0 || len(r.Grpc.Requests) > 0 || len(r.Scenarios.Scenarios) > 0 || len(r.HAR.Files) > 0 || len(r.OpenAPI.Files) > 0 || len(r.AccessLog.Files) > 0 {
+		return nil, errors.New("http-requests, grpc-requests, scenarios, har-files, openapi-files and access-log-files cannot be used with targets, set the requests of each target instead")
+	}
+	if err := descriptors.Validate(); err != nil {
+		return nil, err
+	}
+
+	var targets []WarmupTarget
+	names := make(map[string]bool)
+	for _, value := range r.Targets.Targets {
+		j, err := parseTarget(value)
+		if err != nil {
+			return nil, err
+		}
+		if names[j.Name] {
+			return nil, fmt.Errorf("duplicate target %s", j.Name)
+		}
+		names[j.Name] = true
+
+		target := j.getTarget(r.Target)
+		options := target.getWarmupTargetOptions()
+		if err := validateReadinessProtocol(options.ReadinessProtocol); err != nil {
+			return nil, fmt.Errorf("target %s: %v", j.Name, err)
+		}
+		httpFlags, err := requestFlags(j.HTTPRequests)
+		if err != nil {
+			return nil, fmt.Errorf("target %s: %v", j.Name, err)
+		}
+		httpRequests, err := toHTTPRequests(httpFlags, http.CompressionType(r.HTTP.Compression))
+		if err != nil {
+			return nil, fmt.Errorf("target %s: %v", j.Name, err)
+		}
+		grpcFlags, err := requestFlags(j.GrpcRequests)
+		if err != nil {
+			return nil, fmt.Errorf("target %s: %v", j.Name, err)
+		}
+		grpcRequests, err := toGrpcRequests(grpcFlags)
+		if err != nil {
+			return nil, fmt.Errorf("target %s: %v", j.Name, err)
+		}
+		scenarioFlags, err := requestFlags(j.Scenarios)
+		if err != nil {
+			return nil, fmt.Errorf("target %s: %v", j.Name, err)
+		}
+		scenarios, err := toScenarios(scenarioFlags, http.CompressionType(r.HTTP.Compression))
+		if err != nil {
+			return nil, fmt.Errorf("target %s: %v", j.Name, err)
+		}
+		warmupTarget := WarmupTarget{Name: j.Name, Options: options, HTTPRequests: httpRequests, GrpcRequests: grpcRequests, Scenarios: scenarios, target: target, descriptors: descriptors}
+		if err := warmupTarget.bindDatasets(datasets); err != nil {
+			return nil, fmt.Errorf("target %s: %v", j.Name, err)
+		}
+		targets = append(targets, warmupTarget)
+	}
+	return targets, nil
+}
+
+// bindDatasets sets the datasets used by the data placeholders of the HTTP requests of the target, including the replayed ones.
+// Data placeholders cannot be used in scenarios, whose steps pass values along with variables instead.
+func (w *WarmupTarget) bindDatasets(datasets map[string]*dataset.Dataset) error {
+	if err := bindDatasets(w.HTTPRequests, datasets); err != nil {
+		return err
+	}
+
+	replayed := make([]http.Request, len(w.Replay))
+	for i, entry := range w.Replay {
+		replayed[i] = entry.Request
+	}
+	if err := bindDatasets(replayed, datasets); err != nil {
+		return err
+	}
+	for i := range w.Replay {
+		w.Replay[i].Request = replayed[i]
+	}
+
+	for _, s := range w.Scenarios {
+		for _, step := range s.Steps {
+			if step.HTTP != nil && len(step.HTTP.DataReferences()) > 0 {
+				return fmt.Errorf("invalid scenario %s: data placeholders cannot be used in scenarios", s.Name)
+			}
+		}
+	}
+	return nil
+}
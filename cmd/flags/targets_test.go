@@ -0,0 +1,102 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarmupTargets_Default(t *testing.T) {
+	r, _ := newTestRoot(t, "-http-requests=get:/ping", "-target-readiness-http-path=/health")
+
+	targets, err := r.GetWarmupTargets()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(targets))
+	assert.Equal(t, "", targets[0].Name)
+	assert.Equal(t, "/health", targets[0].Options.ReadinessHTTPPath)
+	require.Equal(t, 1, len(targets[0].HTTPRequests))
+	assert.Equal(t, "/ping", targets[0].HTTPRequests[0].Path)
+}
+
+func TestWarmupTargets_Named(t *testing.T) {
+	r, _ := newTestRoot(t,
+		"-target-readiness-http-path=/health",
+		"-target-http-port=8080",
+		`-targets={"name": "app", "http-requests": ["get:/ping", {"method": "post", "path": "/search"}]}`,
+		`-targets={"name": "cache", "http-port": 8081, "readiness-protocol": "grpc", "grpc-requests": ["cache.Cache/Get"]}`,
+	)
+
+	targets, err := r.GetWarmupTargets()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(targets))
+
+	app := targets[0]
+	assert.Equal(t, "app", app.Name)
+	assert.Equal(t, "/health", app.Options.ReadinessHTTPPath)
+	assert.Equal(t, 8080, app.Options.ReadinessPort)
+	require.Equal(t, 2, len(app.HTTPRequests))
+	assert.Equal(t, "POST", app.HTTPRequests[1].Method)
+	assert.Empty(t, app.GrpcRequests)
+
+	cache := targets[1]
+	assert.Equal(t, "cache", cache.Name)
+	assert.Equal(t, "grpc", cache.Options.ReadinessProtocol)
+	assert.Equal(t, 8081, cache.Options.ReadinessPort, "the readiness port defaults to the port of the target")
+	assert.Equal(t, 8081, cache.target.HTTPPort)
+	require.Equal(t, 1, len(cache.GrpcRequests))
+	assert.Equal(t, "cache.Cache/Get", cache.GrpcRequests[0].ServiceMethod)
+}
+
+func TestWarmupTargets_Invalid(t *testing.T) {
+	tests := map[string][]string{
+		"name is required":                     {`-targets={"http-port": 8081}`},
+		"duplicate target app":                 {`-targets={"name": "app"}`, `-targets={"name": "app"}`},
+		"unknown field":                        {`-targets={"name": "app", "port": 8081}`},
+		"readiness protocol tcp not supported": {`-targets={"name": "app", "readiness-protocol": "tcp"}`},
+		"target app: invalid request flag":     {`-targets={"name": "app", "http-requests": ["ping"]}`},
+		"cannot be used with targets":          {`-targets={"name": "app"}`, "-http-requests=get:/ping"},
+		"target app: invalid request: 1, expected a string or an object": {`-targets={"name": "app", "grpc-requests": [1]}`},
+	}
+	for expected, args := range tests {
+		r, _ := newTestRoot(t, args...)
+		_, err := r.GetWarmupTargets()
+		assert.ErrorContains(t, err, expected)
+	}
+}
+
+func TestWarmupTargets_ConfigFile(t *testing.T) {
+	file := writeFile(t, "mittens.yaml", `
+targets:
+  - name: app
+    http-requests:
+      - get:/ping
+  - name: cache
+    http-port: 8081
+    http-requests:
+      - method: get
+        path: /cached
+`)
+	r, fs := newTestRoot(t)
+	require.NoError(t, loadConfigFile(fs, file))
+
+	targets, err := r.GetWarmupTargets()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(targets))
+	assert.Equal(t, "cache", targets[1].Name)
+	assert.Equal(t, "/cached", targets[1].HTTPRequests[0].Path)
+}
@@ -12,3 +12,8 @@ func (s *stringArray) Set(value string) error {
 	*s = append(*s, value)
 	return nil
 }
+
+// Get returns the values as a slice so that stringArray implements flag.Getter.
+func (s *stringArray) Get() interface{} {
+	return []string(*s)
+}
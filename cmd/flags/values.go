@@ -0,0 +1,114 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"strings"
+)
+
+const redacted = "<redacted>"
+
+// jsonRequestFlags are the flags whose values may be JSON objects holding requests, and so the headers of the requests.
+var jsonRequestFlags = []string{"http-requests", "targets", "scenarios"}
+
+// Values returns the value of every option, keyed by flag name, e.g. to echo the config in the report.
+// The values of HTTP headers are redacted as they often hold credentials, including the headers of the requests given as JSON objects.
+func (r *Root) Values() map[string]interface{} {
+	return values(flag.CommandLine)
+}
+
+func values(fs *flag.FlagSet) map[string]interface{} {
+	values := make(map[string]interface{})
+	fs.VisitAll(func(f *flag.Flag) {
+		getter, ok := f.Value.(flag.Getter)
+		if !ok {
+			values[f.Name] = f.Value.String()
+			return
+		}
+		values[f.Name] = getter.Get()
+	})
+
+	if headers, ok := values["http-headers"].([]string); ok {
+		values["http-headers"] = redactHeaders(headers)
+	}
+	for _, name := range jsonRequestFlags {
+		if entries, ok := values[name].([]string); ok {
+			values[name] = redactJSONHeaders(entries)
+		}
+	}
+	return values
+}
+
+// redactHeaders keeps the names of the headers but hides their values, e.g. `Authorization: <redacted>`.
+func redactHeaders(headers []string) []string {
+	redactedHeaders := make([]string, len(headers))
+	for i, header := range headers {
+		name, _, _ := strings.Cut(header, ":")
+		redactedHeaders[i] = name + ": " + redacted
+	}
+	return redactedHeaders
+}
+
+// redactJSONHeaders hides the values of the headers of the entries which are JSON objects, e.g. {"path": "/", "headers": {"Authorization": "<redacted>"}}.
+// This covers the requests nested in targets and in the steps of scenarios. Other entries are kept as they are.
+func redactJSONHeaders(entries []string) []string {
+	redactedEntries := make([]string, len(entries))
+	for i, entry := range entries {
+		redactedEntries[i] = entry
+		if !strings.HasPrefix(strings.TrimSpace(entry), "{") {
+			continue
+		}
+		var object interface{}
+		if err := json.Unmarshal([]byte(entry), &object); err != nil {
+			continue
+		}
+		redactHeaderValues(object)
+
+		var b bytes.Buffer
+		encoder := json.NewEncoder(&b)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(object); err == nil {
+			redactedEntries[i] = strings.TrimSpace(b.String())
+		}
+	}
+	return redactedEntries
+}
+
+// redactHeaderValues replaces the values of the headers objects found in a JSON value. The headers of response expectations are kept
+// since they describe what the target returns.
+func redactHeaderValues(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if key == "expect" {
+				continue
+			}
+			if headers, ok := nested.(map[string]interface{}); ok && key == "headers" {
+				for name := range headers {
+					headers[name] = redacted
+				}
+				continue
+			}
+			redactHeaderValues(nested)
+		}
+	case []interface{}:
+		for _, nested := range v {
+			redactHeaderValues(nested)
+		}
+	}
+}
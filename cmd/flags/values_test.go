@@ -0,0 +1,49 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package flags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValues(t *testing.T) {
+	_, fs := newTestRoot(t, "-concurrency=4", "-http-requests=get:/ping", "-http-headers=Authorization: Bearer secret", "-http-headers=X-Foo")
+
+	v := values(fs)
+
+	assert.Equal(t, 4, v["concurrency"])
+	assert.Equal(t, true, v["file-probe-enabled"])
+	assert.Equal(t, "", v["report-file"])
+	assert.Equal(t, []string{"get:/ping"}, v["http-requests"])
+	assert.Equal(t, []string{"Authorization: <redacted>", "X-Foo: <redacted>"}, v["http-headers"])
+}
+
+func TestValues_JSONHeaders(t *testing.T) {
+	_, fs := newTestRoot(t,
+		`-http-requests={"method": "get", "path": "/ping", "headers": {"Authorization": "Bearer secret"}, "expect": {"headers": {"Content-Type": "text/plain"}}}`,
+		`-targets={"name": "cache", "http-requests": ["get:/ping", {"method": "get", "path": "/", "headers": {"X-Api-Key": "secret"}}]}`,
+		`-scenarios={"name": "cart", "steps": [{"http": {"method": "post", "path": "/carts", "headers": {"Authorization": "Bearer secret"}}}]}`,
+	)
+
+	v := values(fs)
+
+	assert.Equal(t, []string{`{"expect":{"headers":{"Content-Type":"text/plain"}},"headers":{"Authorization":"<redacted>"},"method":"get","path":"/ping"}`}, v["http-requests"])
+	assert.NotContains(t, v["targets"].([]string)[0], "secret")
+	assert.Contains(t, v["targets"].([]string)[0], `"get:/ping"`)
+	assert.NotContains(t, v["scenarios"].([]string)[0], "secret")
+	assert.Contains(t, v["scenarios"].([]string)[0], `"Authorization":"<redacted>"`)
+}
@@ -15,117 +15,280 @@
 package cmd
 
 import (
+	"crypto/tls"
 	"flag"
+	"fmt"
 	"log"
 	"mittens/cmd/flags"
+	"mittens/internal/pkg/convergence"
+	"mittens/internal/pkg/metrics"
 	"mittens/internal/pkg/probe"
+	"mittens/internal/pkg/rampup"
+	"mittens/internal/pkg/report"
 	"mittens/internal/pkg/safe"
+	"mittens/internal/pkg/server"
+	"mittens/internal/pkg/stats"
 	"mittens/internal/pkg/warmup"
 	"os"
+	"strings"
+	"sync"
 	"time"
 )
 
 var opts *flags.Root
 
-// CreateConfig creates a flag set and parses the command line arguments.
+// probeStatus is exposed by the HTTP probes of the server. It is updated along with the probe files.
+var probeStatus *probe.Status
+
+// warmupMetrics is exposed by the server if metrics are enabled. It is nil otherwise.
+var warmupMetrics *metrics.Metrics
+
+// CreateConfig creates a flag set, parses the command line arguments and applies the environment variables and the config file if one was set.
 func CreateConfig() {
 	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	opts = &flags.Root{}
 	opts.InitFlags()
 	flag.Parse()
+
+	// command line flags take precedence over environment variables, which take precedence over the config file
+	if err := opts.LoadEnv(); err != nil {
+		log.Fatalf("Invalid environment variable: %v", err)
+	}
+	if err := opts.LoadConfigFile(); err != nil {
+		log.Fatalf("Invalid config file: %v", err)
+	}
 }
 
 // RunCmdRoot runs the main logic
 //
 //	It blocks forever unless `-exit-after-warmup` is set to true
 func RunCmdRoot() {
-	requestsSent := safe.DoAndReturn(run, 0)
-	postProcess(requestsSent)
+	probeStatus = &probe.Status{}
+	warmupMetrics = nil
+	if opts.Server.MetricsEnabled {
+		warmupMetrics = metrics.New()
+	}
+	if opts.IsServerEnabled() {
+		srv := startServer()
+		defer srv.Close()
+	}
+
+	result := safe.DoAndReturn(run, runResult{})
+	postProcess(result)
 	block()
 }
 
-// run runs the main logic and returns the number of warmup requests actually sent.
-func run() int {
+// startServer starts the HTTP server exposed by mittens with the endpoints that are enabled.
+func startServer() *server.Server {
+	srv := server.New(opts.Server.Port)
+	if opts.Server.ProbeEnabled {
+		srv.Handle(opts.Server.ProbeLivenessPath, probeStatus.LivenessHandler())
+		srv.Handle(opts.Server.ProbeReadinessPath, probeStatus.ReadinessHandler())
+	}
+	if opts.Server.MetricsEnabled {
+		srv.Handle(opts.Server.MetricsPath, warmupMetrics.Handler())
+	}
+	if err := srv.Start(); err != nil {
+		log.Fatalf("Unable to start server: %v", err)
+	}
+	return srv
+}
+
+// runResult holds the outcome of the readiness wait and of the warmup.
+type runResult struct {
+	summary stats.Summary
+	// targets holds the outcome of each target, in the order in which they were defined.
+	targets []targetResult
+	// thresholds are the limits the warmup must stay within for mittens to become ready when fail-readiness is set.
+	thresholds stats.Thresholds
+}
+
+// targetResult holds the outcome of the readiness wait and of the warmup of a single target.
+type targetResult struct {
+	// name is empty for the single target of the target-* flags.
+	name           string
+	summary        stats.Summary
+	ready          bool
+	readinessWait  time.Duration
+	warmupDuration time.Duration
+	// rates holds the target rates which were set along with the rates which were achieved.
+	rates []report.Rate
+}
+
+// named returns true if several named targets were warmed up.
+func (r runResult) named() bool {
+	return len(r.targets) > 0 && r.targets[0].name != ""
+}
+
+// overall returns whether every target became ready, the longest readiness wait and the longest warmup.
+func (r runResult) overall() (ready bool, readinessWait time.Duration, warmupDuration time.Duration) {
+	ready = len(r.targets) > 0
+	for _, t := range r.targets {
+		ready = ready && t.ready
+		if t.readinessWait > readinessWait {
+			readinessWait = t.readinessWait
+		}
+		if t.warmupDuration > warmupDuration {
+			warmupDuration = t.warmupDuration
+		}
+	}
+	return ready, readinessWait, warmupDuration
+}
+
+// run runs the main logic and returns the statistics of the warmup requests.
+func run() runResult {
 	if opts.FileProbe.Enabled {
 		probe.WriteFile(opts.FileProbe.LivenessPath)
 	}
+	probeStatus.SetAlive()
 
 	var validationError bool
-	httpRequests, err := opts.GetWarmupHTTPRequests()
+	targets, err := opts.GetWarmupTargets()
 	if err != nil {
-		log.Printf("invalid HTTP options: %v", err)
+		log.Printf("invalid target options: %v", err)
 		validationError = true
 	}
-	grpcRequests, err := opts.GetWarmupGrpcRequests()
+	rampUpProfile, err := opts.GetRampUpProfile()
 	if err != nil {
-		log.Printf("invalid grpc options: %v", err)
+		log.Printf("invalid ramp-up options: %v", err)
 		validationError = true
 	}
-	targetOptions, err := opts.GetWarmupTargetOptions()
+	thresholds, err := opts.GetReadinessThresholds()
 	if err != nil {
-		log.Printf("invalid target options: %v", err)
+		log.Printf("invalid fail-readiness options: %v", err)
 		validationError = true
 	}
-
-	// this is used to decide on whether we should create goroutines for HTTP and/or gRPC requests
-	// since requests are passed to a channel after that point we need to store that info and pass it
-	var hasHttpRequests bool
-	if len(opts.HTTP.Requests) > 0 {
-		hasHttpRequests = true
+	detector, err := opts.GetConvergenceDetector()
+	if err != nil {
+		log.Printf("invalid convergence options: %v", err)
+		validationError = true
 	}
-	var hasGrpcRequests bool
-	if len(opts.Grpc.Requests) > 0 {
-		hasGrpcRequests = true
+	tlsConfig, err := opts.GetTLSConfig()
+	if err != nil {
+		log.Printf("invalid TLS options: %v", err)
+		validationError = true
+	}
+	if _, err := opts.GetMaxInFlightRequests(); err != nil {
+		log.Printf("invalid rate options: %v", err)
+		validationError = true
 	}
 
-	// The next block contains the "wait for target readiness" + "warmup" logic.
-	c1 := make(chan bool, 1)
-
-	requestsSentCounter := 0
+	var observers []stats.Observer
+	if warmupMetrics != nil {
+		observers = append(observers, warmupMetrics)
+	}
+	// each target stops early on its own once its latencies converge
+	detectors := make([]*convergence.Detector, len(targets))
+	if detector != nil {
+		for i, target := range targets {
+			detectors[i] = convergence.New(detector.Options)
+			observers = append(observers, stats.ForTarget(target.Name, detectors[i]))
+		}
+	}
+	recorder := stats.NewRecorder(opts.GetReportWindow(), observers...)
+	result := runResult{thresholds: thresholds, targets: make([]targetResult, len(targets))}
+	for i, target := range targets {
+		result.targets[i].name = target.Name
+	}
 
 	// current time
 	start := time.Now()
 
-	go safe.Do(func() {
-		if !validationError {
-			target := createTarget(targetOptions)
+	// The targets are waited for and warmed up alongside each other.
+	if !validationError {
+		var wg sync.WaitGroup
+		for i, target := range targets {
+			wg.Add(1)
+			go func(i int, target flags.WarmupTarget) {
+				defer wg.Done()
+				result.targets[i] = safe.DoAndReturn(func() targetResult {
+					return warmUp(target, tlsConfig, rampUpProfile, detectors[i], recorder, start)
+				}, targetResult{name: target.Name})
+				warmupMetrics.SetPhase(target.Name, metrics.DONE)
+			}(i, target)
+		}
+		wg.Wait()
+	}
 
-			maxReadinessWaitDurationInSeconds := Min(opts.MaxDurationSeconds, opts.MaxReadinessWaitSeconds)
+	// with several targets the whole warmup is only done once every target is
+	warmupMetrics.SetPhase("", metrics.DONE)
+	log.Println("🟢 Warmup completed")
+	result.summary = recorder.Summary()
+	for i := range result.targets {
+		result.targets[i].summary = recorder.TargetSummary(result.targets[i].name)
+	}
+	return result
+}
 
-			if err := target.WaitForReadinessProbe(maxReadinessWaitDurationInSeconds, opts.GetWarmupHTTPHeaders()); err == nil {
-				elapsed := time.Since(start).Seconds()
+// warmUp waits for a target to become ready and then sends it the warmup requests.
+// start is the time at which mittens started, from which the global maximum duration is counted.
+func warmUp(t flags.WarmupTarget, tlsConfig *tls.Config, rampUpProfile rampup.Profile, detector *convergence.Detector, recorder *stats.Recorder, start time.Time) targetResult {
+	result := targetResult{name: t.Name}
+	target := t.NewTarget(tlsConfig)
+	warmupMetrics.SetPhase(t.Name, metrics.WAITING)
 
-				log.Printf("💚 Target took %d second(s) to become ready", int(elapsed))
+	maxReadinessWaitDurationInSeconds := Min(opts.MaxDurationSeconds, opts.MaxReadinessWaitSeconds)
 
-				globalMaxDurationSecondsLeft := opts.MaxDurationSeconds - int(elapsed)
+	if err := target.WaitForReadinessProbe(maxReadinessWaitDurationInSeconds, opts.GetWarmupHTTPHeaders()); err != nil {
+		result.readinessWait = time.Since(start)
+		log.Printf("%s still not ready. Giving up!", describeTarget(t.Name))
+		return result
+	}
 
-				maxDurationInSeconds := Min(globalMaxDurationSecondsLeft, opts.MaxWarmupDurationSeconds)
+	result.ready = true
+	result.readinessWait = time.Since(start)
+	warmupMetrics.SetReadinessWait(t.Name, result.readinessWait)
+	elapsed := result.readinessWait.Seconds()
 
-				if maxDurationInSeconds < opts.MaxWarmupDurationSeconds {
-					log.Printf("⚠️ Warmup requests will only run for %d seconds instead of the configured %d seconds as to meet the global maximum duration of %d seconds", maxDurationInSeconds, opts.MaxWarmupDurationSeconds, opts.MaxDurationSeconds)
-				}
+	log.Printf("💚 %s took %d second(s) to become ready", describeTarget(t.Name), int(elapsed))
 
-				wp := warmup.Warmup{
-					Target:                   target,
-					Concurrency:              opts.GetConcurrency(),
-					HttpRequests:             httpRequests,
-					GrpcRequests:             grpcRequests,
-					HttpHeaders:              opts.GetWarmupHTTPHeaders(),
-					RequestDelayMilliseconds: opts.RequestDelayMilliseconds,
-					ConcurrencyTargetSeconds: opts.GetConcurrencyTargetSeconds(),
-				}
+	globalMaxDurationSecondsLeft := opts.MaxDurationSeconds - int(elapsed)
 
-				wp.Run(hasHttpRequests, hasGrpcRequests, maxDurationInSeconds, &requestsSentCounter)
-			} else {
-				log.Print("Target still not ready. Giving up!")
-			}
-		}
-		c1 <- true
-	})
+	maxDurationInSeconds := Min(globalMaxDurationSecondsLeft, opts.MaxWarmupDurationSeconds)
 
-	<-c1
-	log.Println("🟢 Warmup completed")
-	return requestsSentCounter
+	if maxDurationInSeconds < opts.MaxWarmupDurationSeconds {
+		log.Printf("⚠️ Warmup requests will only run for %d seconds instead of the configured %d seconds as to meet the global maximum duration of %d seconds", maxDurationInSeconds, opts.MaxWarmupDurationSeconds, opts.MaxDurationSeconds)
+	}
+
+	wp := warmup.Warmup{
+		Name:                     t.Name,
+		Target:                   target,
+		HttpRequests:             t.HTTPRequests,
+		GrpcRequests:             t.GrpcRequests,
+		Scenarios:                t.Scenarios,
+		Replay:                   t.Replay,
+		ReplaySpeed:              opts.AccessLog.ReplaySpeed,
+		HttpHeaders:              opts.GetWarmupHTTPHeaders(),
+		RequestDelayMilliseconds: opts.RequestDelayMilliseconds,
+		RampUp:                   rampUpProfile,
+		RequestsPerSecond:        opts.GetRequestsPerSecond(),
+		HttpRequestsPerSecond:    opts.GetHTTPRequestsPerSecond(),
+		GrpcRequestsPerSecond:    opts.GetGrpcRequestsPerSecond(),
+		MaxInFlight:              opts.MaxInFlightRequests,
+		Convergence:              detector,
+	}
+
+	warmupMetrics.SetPhase(t.Name, metrics.WARMING)
+	if t.Name != "" {
+		// the whole warmup is warming as soon as any target is
+		warmupMetrics.SetPhase("", metrics.WARMING)
+	}
+	warmupStart := time.Now()
+	// this is used to decide on whether we should create goroutines for HTTP and/or gRPC requests
+	rates := wp.Run(len(t.HTTPRequests) > 0, len(t.GrpcRequests) > 0, maxDurationInSeconds, recorder)
+	result.warmupDuration = time.Since(warmupStart)
+	for _, rate := range rates {
+		result.rates = append(result.rates, report.Rate{Name: rate.Name, Target: rate.Target, Achieved: rate.Achieved})
+	}
+	return result
+}
+
+// describeTarget returns how a target is referred to in the logs.
+func describeTarget(name string) string {
+	if name == "" {
+		return "Target"
+	}
+	return fmt.Sprintf("Target %s", name)
 }
 
 func Min(x, y int) int {
@@ -144,30 +307,68 @@ func block() {
 
 // postProcess includes steps that run once the warmup finishes.
 // For now this either announces that the app is ready or fails the readiness probe.
-// The latter only happens if mittens did not send any requests and the user allows the readiness to fail.
-func postProcess(requestsSentCounter int) {
-	if opts.FailReadiness && requestsSentCounter == 0 {
-		log.Print("🛑 Warmup did not run. Mittens readiness probe will fail 🙁")
+// The latter only happens if the user allows the readiness to fail and mittens either did not send any requests
+// or the warmup did not meet the readiness thresholds.
+// If a report file was set, the report is written with the final verdict.
+func postProcess(result runResult) {
+	summary := result.summary
+	targetReady, readinessWait, warmupDuration := result.overall()
+	rep := report.New(summary, opts.Values(), targetReady, readinessWait, warmupDuration)
+	if !result.named() && len(result.targets) > 0 {
+		rep.Rates = result.targets[0].rates
+	}
+
+	if summary.Sent == 0 {
+		log.Print("🛑 Warm up finished but no requests were sent 🙁")
 	} else {
-		if requestsSentCounter == 0 {
-			log.Print("🛑 Warm up finished but no requests were sent 🙁")
+		log.Printf("Warm up finished 😊 %d reqs were sent: %d succeeded, %d failed and %d got no response", summary.Sent+summary.Errors, summary.Succeeded, summary.Failed, summary.Errors)
+		if !result.named() {
+			log.Printf("Warmup summary:\n%s", summary.Table())
+		}
+	}
+	if result.named() {
+		for _, t := range result.targets {
+			rep.AddTarget(t.name, t.summary, t.ready, t.readinessWait, t.warmupDuration, t.rates)
+			log.Printf("Warmup summary of target %s:\n%s", t.name, t.summary.Table())
+		}
+	}
+
+	if opts.FailReadiness {
+		if summary.Sent == 0 {
+			rep.Fail("no requests were sent")
 		} else {
-			log.Printf("Warm up finished 😊 Approximately %d reqs were sent", requestsSentCounter)
+			// every target must meet the thresholds on its own
+			for _, t := range result.targets {
+				reasons := result.thresholds.Check(t.summary)
+				if result.named() {
+					if t.summary.Sent == 0 {
+						reasons = []string{"no requests were sent"}
+					}
+					for i, reason := range reasons {
+						reasons[i] = fmt.Sprintf("%s: %s", t.name, reason)
+					}
+				}
+				for _, reason := range reasons {
+					rep.Fail(reason)
+				}
+			}
 		}
+	}
 
+	if rep.Verdict == report.NOT_READY {
+		log.Printf("🛑 Mittens readiness probe will fail 🙁 because %s", strings.Join(rep.Reasons, ", "))
+	} else {
 		if opts.FileProbe.Enabled {
 			probe.WriteFile(opts.FileProbe.ReadinessPath)
 		}
+		probeStatus.SetReady()
 	}
-}
 
-// createTarget creates the target versus which mittens will run.
-func createTarget(targetOptions warmup.TargetOptions) warmup.Target {
-	return warmup.NewTarget(
-		opts.GetReadinessHTTPClient(),
-		opts.GetReadinessGrpcClient(),
-		opts.GetHTTPClient(),
-		opts.GetGrpcClient(),
-		targetOptions,
-	)
+	if opts.ReportFile != "" {
+		if err := rep.Write(opts.ReportFile); err != nil {
+			log.Printf("🛑 %v", err)
+		} else {
+			log.Printf("Report written to %s", opts.ReportFile)
+		}
+	}
 }
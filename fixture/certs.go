@@ -0,0 +1,146 @@
+package fixture
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// TLSServerName is the only name in the server certificate, so clients must override the server name to connect to localhost.
+const TLSServerName = "mittens.test"
+
+// Certificates are the PEM files of a test certificate authority and of the server and client certificates it signed.
+type Certificates struct {
+	CAFile         string
+	ServerCertFile string
+	ServerKeyFile  string
+	ClientCertFile string
+	ClientKeyFile  string
+}
+
+// WriteCertificates generates a certificate authority, a server and a client certificate and writes them to dir.
+func WriteCertificates(dir string) Certificates {
+	caKey := newKey()
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "mittens test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		panic(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		panic(err)
+	}
+
+	certificates := Certificates{
+		CAFile:         filepath.Join(dir, "ca.pem"),
+		ServerCertFile: filepath.Join(dir, "server.pem"),
+		ServerKeyFile:  filepath.Join(dir, "server-key.pem"),
+		ClientCertFile: filepath.Join(dir, "client.pem"),
+		ClientKeyFile:  filepath.Join(dir, "client-key.pem"),
+	}
+	writePEM(certificates.CAFile, "CERTIFICATE", caDER)
+
+	server := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: TLSServerName},
+		DNSNames:     []string{TLSServerName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	writeSignedCertificate(server, ca, caKey, certificates.ServerCertFile, certificates.ServerKeyFile)
+
+	client := &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "mittens"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	writeSignedCertificate(client, ca, caKey, certificates.ClientCertFile, certificates.ClientKeyFile)
+	return certificates
+}
+
+// ServerTLSConfig returns a TLS configuration which serves the server certificate and requires a client certificate
+// signed by the certificate authority.
+func (c Certificates) ServerTLSConfig() *tls.Config {
+	certificate, err := tls.LoadX509KeyPair(c.ServerCertFile, c.ServerKeyFile)
+	if err != nil {
+		panic(err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		ClientCAs:    c.caPool(),
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+}
+
+// ClientTLSConfig returns a TLS configuration which presents the client certificate, trusts the certificate authority
+// and overrides the server name.
+func (c Certificates) ClientTLSConfig() *tls.Config {
+	certificate, err := tls.LoadX509KeyPair(c.ClientCertFile, c.ClientKeyFile)
+	if err != nil {
+		panic(err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		RootCAs:      c.caPool(),
+		ServerName:   TLSServerName,
+	}
+}
+
+func (c Certificates) caPool() *x509.CertPool {
+	ca, err := os.ReadFile(c.CAFile)
+	if err != nil {
+		panic(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(ca)
+	return pool
+}
+
+func newKey() *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func writeSignedCertificate(template *x509.Certificate, ca *x509.Certificate, caKey *ecdsa.PrivateKey, certFile string, keyFile string) {
+	key := newKey()
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		panic(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		panic(err)
+	}
+	writePEM(certFile, "CERTIFICATE", der)
+	writePEM(keyFile, "PRIVATE KEY", keyDER)
+}
+
+func writePEM(file string, blockType string, content []byte) {
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: content}), 0600); err != nil {
+		panic(err)
+	}
+}
@@ -1,7 +1,9 @@
 package fixture
 
 import (
+	"crypto/tls"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"net/http"
@@ -10,6 +12,7 @@ import (
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/interop/grpc_testing"
 	"google.golang.org/grpc/reflection"
 	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
@@ -39,19 +42,44 @@ func StartGrpcTargetTestServerReflectionV1Alpha(callStats *CallStats) (*grpc.Ser
 	})
 }
 
-// It uses the test.proto from grpc-testing: https://github.com/grpc/grpc-go/blob/40a879c23a0dc77234d17e0699d074d5fd151bd0/test/grpc_testing/test.proto
-func startGrpcTargetTestServer(callStats *CallStats, reflRegFunc func(*grpc.Server)) (*grpc.Server, int) {
-	server := grpc.NewServer(
-		grpc.UnaryInterceptor(callStats.UnaryInterceptor()),
-	)
-	grpc_testing.RegisterTestServiceServer(server, &grpc_testing.UnimplementedTestServiceServer{})
-	reflRegFunc(server)
+func StartGrpcTargetTestServerWithoutReflection(callStats *CallStats) (*grpc.Server, int) {
+	return startGrpcTargetTestServer(callStats, func(server *grpc.Server) {})
+}
 
+// StartGrpcTargetTestServerWithTLS starts a server with reflection which only accepts TLS connections.
+func StartGrpcTargetTestServerWithTLS(callStats *CallStats, tlsConfig *tls.Config) (*grpc.Server, int) {
+	return startGrpcTargetTestServer(callStats, func(server *grpc.Server) {
+		reflection.Register(server)
+	}, grpc.Creds(credentials.NewTLS(tlsConfig)))
+}
+
+// It uses the test.proto from grpc-testing: https://github.com/grpc/grpc-go/blob/40a879c23a0dc77234d17e0699d074d5fd151bd0/test/grpc_testing/test.proto
+func startGrpcTargetTestServer(callStats *CallStats, reflRegFunc func(*grpc.Server), options ...grpc.ServerOption) (*grpc.Server, int) {
 	listener, err := net.Listen("tcp", ":0")
 	if err != nil {
 		panic(err)
 	}
 	port := listener.Addr().(*net.TCPAddr).Port
+	return serveGrpcTargetTestServer(listener, callStats, reflRegFunc, options...), port
+}
+
+// StartGrpcTargetTestServerOnSocket starts a server with reflection which listens on the given Unix socket.
+func StartGrpcTargetTestServerOnSocket(callStats *CallStats, socket string) *grpc.Server {
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		panic(err)
+	}
+	return serveGrpcTargetTestServer(listener, callStats, func(server *grpc.Server) {
+		reflection.Register(server)
+	})
+}
+
+func serveGrpcTargetTestServer(listener net.Listener, callStats *CallStats, reflRegFunc func(*grpc.Server), options ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(
+		append([]grpc.ServerOption{grpc.UnaryInterceptor(callStats.UnaryInterceptor())}, options...)...,
+	)
+	grpc_testing.RegisterTestServiceServer(server, &streamingTestService{})
+	reflRegFunc(server)
 
 	go func() {
 		err := server.Serve(listener)
@@ -59,12 +87,76 @@ func startGrpcTargetTestServer(callStats *CallStats, reflRegFunc func(*grpc.Serv
 			log.Fatal("Server failed : ", err)
 		}
 	}()
-	return server, port
+	return server
+}
+
+// streamingTestService implements the streaming methods of the test service. Unary methods are left unimplemented.
+type streamingTestService struct {
+	grpc_testing.UnimplementedTestServiceServer
+}
+
+// StreamingOutputCall sends one response for each of the response parameters.
+func (s *streamingTestService) StreamingOutputCall(request *grpc_testing.StreamingOutputCallRequest, stream grpc_testing.TestService_StreamingOutputCallServer) error {
+	for _, parameters := range request.ResponseParameters {
+		if err := stream.Send(&grpc_testing.StreamingOutputCallResponse{Payload: &grpc_testing.Payload{Body: make([]byte, parameters.Size)}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamingInputCall returns the total size of the payloads it received.
+func (s *streamingTestService) StreamingInputCall(stream grpc_testing.TestService_StreamingInputCallServer) error {
+	var size int32
+	for {
+		request, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&grpc_testing.StreamingInputCallResponse{AggregatedPayloadSize: size})
+		}
+		if err != nil {
+			return err
+		}
+		size += int32(len(request.GetPayload().GetBody()))
+	}
+}
+
+// FullDuplexCall sends one response for each request it received.
+func (s *streamingTestService) FullDuplexCall(stream grpc_testing.TestService_FullDuplexCallServer) error {
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&grpc_testing.StreamingOutputCallResponse{}); err != nil {
+			return err
+		}
+	}
 }
 
 // StartHttpTargetTestServer starts a HTTP server on the provided port
 // Optionally, it receives a list of handler functions
 func StartHttpTargetTestServer(pathHandlers []PathResponseHandler) (*http.Server, int) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		panic(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	return serveHttpTargetTestServer(listener, pathHandlers), port
+}
+
+// StartHttpTargetTestServerOnSocket starts a HTTP server, which also accepts h2c, on the given Unix socket.
+func StartHttpTargetTestServerOnSocket(pathHandlers []PathResponseHandler, socket string) *http.Server {
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		panic(err)
+	}
+	return serveHttpTargetTestServer(listener, pathHandlers)
+}
+
+func serveHttpTargetTestServer(listener net.Listener, pathHandlers []PathResponseHandler) *http.Server {
 	router := http.NewServeMux()
 
 	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -77,13 +169,7 @@ func StartHttpTargetTestServer(pathHandlers []PathResponseHandler) (*http.Server
 		router.HandleFunc(pathHandler.Path, pathHandler.PathHandlerFunc)
 	}
 
-	listener, err := net.Listen("tcp", ":0")
-	if err != nil {
-		panic(err)
-	}
-	addr := listener.Addr().(*net.TCPAddr).String()
-	port := listener.Addr().(*net.TCPAddr).Port
-
+	addr := listener.Addr().String()
 	server := &http.Server{
 		Handler: h2c.NewHandler(router, &http2.Server{}),
 	}
@@ -96,5 +182,5 @@ func StartHttpTargetTestServer(pathHandlers []PathResponseHandler) (*http.Server
 		}
 	}()
 
-	return server, port
+	return server
 }
@@ -0,0 +1,230 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+// Package accesslog turns the requests recorded in access logs into warmup requests, so that real traffic can be replayed.
+package accesslog
+
+import (
+	"fmt"
+	"mittens/internal/pkg/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	COMBINED = "combined"
+	COMMON   = "common"
+	JSON     = "json"
+	CSV      = "csv"
+)
+
+// Entry is a request read from an access log, along with the time at which it was received. The time is zero if the log does not have it.
+type Entry struct {
+	Request http.Request
+	Time    time.Time
+}
+
+// Options describe the format of an access log and which of its requests are kept.
+type Options struct {
+	// Format is the format of the log: combined, common, json (one object per line) or csv (with a header row).
+	Format string
+	// SampleRate is the share of the requests which are kept, between 0 (excluded) and 1. Zero keeps every request.
+	SampleRate float64
+	// IncludePaths holds regular expressions, one of which the path must match for the request to be kept. If empty, any path is kept.
+	IncludePaths []string
+	// ExcludePaths holds regular expressions which the path must not match for the request to be kept.
+	ExcludePaths []string
+	// DropQueryParams holds the names of the query parameters which are removed from the requests, e.g. ones holding personal data.
+	DropQueryParams []string
+	// Replacements hold <regex>=<replacement> rules applied to the path, query and body of the requests, e.g. to hide IDs or emails.
+	Replacements []string
+}
+
+// Rule replaces the matches of a regular expression, see regexp.Regexp.ReplaceAllString.
+type Rule struct {
+	Regex       *regexp.Regexp
+	Replacement string
+}
+
+// ToRule parses a rule in the <regex>=<replacement> format. The regular expression cannot contain =, which can be written \x3D.
+// The replacement can refer to the groups of the regular expression, e.g. $1, and hold placeholders.
+func ToRule(value string) (Rule, error) {
+	expr, replacement, ok := strings.Cut(value, "=")
+	if !ok || expr == "" {
+		return Rule{}, fmt.Errorf("invalid replacement %s, expected format <regex>=<replacement>", value)
+	}
+	regex, err := regexp.Compile(expr)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid replacement %s: %v", value, err)
+	}
+	// placeholders such as {$range|min=1,max=5} are kept as they are rather than read as references to groups
+	return Rule{Regex: regex, Replacement: strings.ReplaceAll(replacement, "{$", "{$$")}, nil
+}
+
+// filter holds the compiled options.
+type filter struct {
+	sampleRate   float64
+	includePaths []*regexp.Regexp
+	excludePaths []*regexp.Regexp
+	dropParams   map[string]bool
+	rules        []Rule
+}
+
+func newFilter(options Options) (filter, error) {
+	if options.SampleRate < 0 || options.SampleRate > 1 {
+		return filter{}, fmt.Errorf("sample rate must be between 0 and 1")
+	}
+	f := filter{sampleRate: options.SampleRate, dropParams: make(map[string]bool)}
+	var err error
+	if f.includePaths, err = compileAll(options.IncludePaths); err != nil {
+		return filter{}, err
+	}
+	if f.excludePaths, err = compileAll(options.ExcludePaths); err != nil {
+		return filter{}, err
+	}
+	for _, name := range options.DropQueryParams {
+		f.dropParams[name] = true
+	}
+	for _, value := range options.Replacements {
+		rule, err := ToRule(value)
+		if err != nil {
+			return filter{}, err
+		}
+		f.rules = append(f.rules, rule)
+	}
+	return f, nil
+}
+
+func compileAll(expressions []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, expr := range expressions {
+		regex, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path filter %s: %v", expr, err)
+		}
+		compiled = append(compiled, regex)
+	}
+	return compiled, nil
+}
+
+// Read returns the requests of an access log, in the order in which they were logged, along with the number of lines which
+// were skipped because they could not be parsed or have a method which is not supported, e.g. garbage sent to the server.
+//
+// Query parameters are dropped and replacements applied to the requests which pass the path filters. They are then prepared
+// like any other request, which interpolates their placeholders and compresses their body if needed, and sampled evenly,
+// e.g. one in four for a sample rate of 0.25.
+func Read(path string, options Options, compression http.CompressionType) ([]Entry, int, error) {
+	f, err := newFilter(options)
+	if err != nil {
+		return nil, 0, err
+	}
+	parse, err := newParser(options.Format)
+	if err != nil {
+		return nil, 0, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to read access log: %v", err)
+	}
+
+	records, skipped, err := parse(string(content))
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid access log %s: %v", path, err)
+	}
+
+	var entries []Entry
+	kept := 0
+	for _, r := range records {
+		// absolute URIs are logged for proxied requests
+		requestPath, query, _ := strings.Cut(http.RequestURI(r.uri), "?")
+		if !f.allowsPath(requestPath) {
+			continue
+		}
+		request := http.Request{Method: r.method, Path: requestPath + f.stripQuery(query), Headers: make(map[string]string)}
+		for _, rule := range f.rules {
+			request.Path = rule.Regex.ReplaceAllString(request.Path, rule.Replacement)
+		}
+		// requests are recorded under their path without the query, so that the summary and metrics are not split by every value of the query
+		namePath, _, _ := strings.Cut(request.Path, "?")
+		request.Name = strings.ToUpper(request.Method) + " " + namePath
+		if r.body != "" {
+			body := r.body
+			for _, rule := range f.rules {
+				body = rule.Regex.ReplaceAllString(body, rule.Replacement)
+			}
+			request.Body = &body
+		}
+		prepared, err := http.NewRequest(request, compression)
+		if err != nil {
+			skipped++
+			continue
+		}
+		kept++
+		if !f.sample(kept) {
+			continue
+		}
+		entries = append(entries, Entry{Request: prepared, Time: r.time})
+	}
+	return entries, skipped, nil
+}
+
+func (f filter) allowsPath(path string) bool {
+	for _, regex := range f.excludePaths {
+		if regex.MatchString(path) {
+			return false
+		}
+	}
+	if len(f.includePaths) == 0 {
+		return true
+	}
+	for _, regex := range f.includePaths {
+		if regex.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// sample returns true if the n-th request which passed the filters is kept. The kept requests are spread evenly across the log.
+func (f filter) sample(n int) bool {
+	if f.sampleRate == 0 || f.sampleRate == 1 {
+		return true
+	}
+	return int(float64(n)*f.sampleRate) > int(float64(n-1)*f.sampleRate)
+}
+
+// stripQuery removes the dropped parameters from a raw query, keeping the other parameters as they were logged.
+// It returns the query with its leading ?, or an empty string if no parameter is left.
+func (f filter) stripQuery(query string) string {
+	if query == "" {
+		return ""
+	}
+	var params []string
+	for _, param := range strings.Split(query, "&") {
+		name, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if !f.dropParams[name] {
+			params = append(params, param)
+		}
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	return "?" + strings.Join(params, "&")
+}
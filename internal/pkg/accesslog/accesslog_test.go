@@ -0,0 +1,126 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package accesslog
+
+import (
+	"mittens/internal/pkg/http"
+	"mittens/internal/pkg/internal"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const combinedLog = `
+127.0.0.1 - frank [10/Oct/2024:13:55:36 -0700] "GET /hotels/42?email=jane%40example.com&lang=en HTTP/1.1" 200 2326 "https://example.com/" "Mozilla/5.0"
+127.0.0.1 - - [10/Oct/2024:13:55:38 -0700] "POST /bookings HTTP/1.1" 201 12
+127.0.0.1 - - [10/Oct/2024:13:55:39 -0700] "\x16\x03\x01" 400 0
+127.0.0.1 - - [10/Oct/2024:13:55:40 -0700] "GET /health HTTP/1.1" 200 2
+127.0.0.1 - - [10/Oct/2024:13:55:41 -0700] "BREW /pot HTTP/1.1" 418 0
+127.0.0.1 - - [10/Oct/2024:13:55:45 -0700] "GET http://api.example.com/search?q=paris HTTP/1.1" 200 512
+`
+
+func TestRead_Combined(t *testing.T) {
+	entries, skipped, err := Read(internal.WriteTempFile(t, "access.log", combinedLog), Options{Format: COMBINED}, http.COMPRESSION_NONE)
+	require.NoError(t, err)
+	assert.Equal(t, 2, skipped, "garbage and unsupported methods are skipped")
+	require.Equal(t, 4, len(entries))
+
+	first := entries[0]
+	assert.Equal(t, "GET", first.Request.Method)
+	assert.Equal(t, "/hotels/42?email=jane%40example.com&lang=en", first.Request.Path)
+	assert.Equal(t, "GET /hotels/42", first.Request.DisplayName(), "requests are named without their query")
+	assert.Equal(t, time.Date(2024, 10, 10, 20, 55, 36, 0, time.UTC), first.Time.UTC())
+
+	assert.Equal(t, "POST", entries[1].Request.Method)
+	assert.Nil(t, entries[1].Request.Body)
+	assert.Equal(t, "/search?q=paris", entries[3].Request.Path, "absolute URIs are reduced to their path")
+	assert.Equal(t, 9*time.Second, entries[3].Time.Sub(first.Time))
+}
+
+func TestRead_Filters(t *testing.T) {
+	options := Options{
+		ExcludePaths:    []string{"^/health$"},
+		DropQueryParams: []string{"email"},
+		Replacements:    []string{`/hotels/\d+=/hotels/{$range|min=7,max=7}`},
+	}
+	entries, _, err := Read(internal.WriteTempFile(t, "access.log", combinedLog), options, http.COMPRESSION_NONE)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(entries))
+	assert.Equal(t, "/hotels/7?lang=en", entries[0].Request.Path, "placeholders of the replacements are interpolated")
+	assert.Equal(t, "GET /hotels/{$range|min=7,max=7}", entries[0].Request.Name)
+
+	entries, _, err = Read(internal.WriteTempFile(t, "access.log", combinedLog), Options{IncludePaths: []string{"^/hotels", "^/search"}}, http.COMPRESSION_NONE)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(entries))
+	assert.Equal(t, "/search?q=paris", entries[1].Request.Path)
+}
+
+func TestRead_Sampling(t *testing.T) {
+	entries, _, err := Read(internal.WriteTempFile(t, "access.log", combinedLog), Options{SampleRate: 0.5}, http.COMPRESSION_NONE)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(entries))
+	assert.Equal(t, "/bookings", entries[0].Request.Path, "every other request is kept")
+	assert.Equal(t, "/search?q=paris", entries[1].Request.Path)
+}
+
+func TestRead_JSONLines(t *testing.T) {
+	log := `{"time": "2024-10-10T13:55:36Z", "method": "post", "path": "/bookings", "body": {"hotel": 42}}
+not json
+{"timestamp": 1728568537.5, "request": "GET /hotels/42?lang=en HTTP/2.0", "status": 200}
+{"method": "GET"}
+`
+	entries, skipped, err := Read(internal.WriteTempFile(t, "access.log", log), Options{Format: JSON}, http.COMPRESSION_GZIP)
+	require.NoError(t, err)
+	assert.Equal(t, 2, skipped)
+	require.Equal(t, 2, len(entries))
+
+	assert.Equal(t, "POST", entries[0].Request.Method)
+	assert.Equal(t, "gzip", entries[0].Request.Headers["Content-Encoding"], "bodies are compressed")
+	assert.Equal(t, "/hotels/42?lang=en", entries[1].Request.Path)
+	assert.Equal(t, 1500*time.Millisecond, entries[1].Time.Sub(entries[0].Time))
+}
+
+func TestRead_CSV(t *testing.T) {
+	log := "Time,Method,URI,Status\n10/Oct/2024:13:55:36 +0000,GET,/hotels/42,200\n,DELETE,/bookings/1,204\nbad time,GET,/,200\n"
+	entries, skipped, err := Read(internal.WriteTempFile(t, "access.log", log), Options{Format: CSV}, http.COMPRESSION_NONE)
+	require.NoError(t, err)
+	assert.Equal(t, 1, skipped)
+	require.Equal(t, 2, len(entries))
+	assert.Equal(t, "/hotels/42", entries[0].Request.Path)
+	assert.Equal(t, "DELETE", entries[1].Request.Method)
+	assert.True(t, entries[1].Time.IsZero())
+}
+
+func TestRead_Invalid(t *testing.T) {
+	file := internal.WriteTempFile(t, "access.log", combinedLog)
+	tests := map[string]Options{
+		"access log format \"xml\" not supported": {Format: "xml"},
+		"sample rate must be between 0 and 1":     {SampleRate: 1.5},
+		"invalid path filter (":                   {IncludePaths: []string{"("}},
+		"expected format <regex>=<replacement>":   {Replacements: []string{"/hotels"}},
+	}
+	for expected, options := range tests {
+		_, _, err := Read(file, options, http.COMPRESSION_NONE)
+		assert.ErrorContains(t, err, expected)
+	}
+
+	_, _, err := Read(internal.WriteTempFile(t, "access.log", "path,status\n/,200\n"), Options{Format: CSV}, http.COMPRESSION_NONE)
+	assert.ErrorContains(t, err, "the header row must name either the method and path columns")
+
+	_, _, err = Read("/does-not-exist.log", Options{}, http.COMPRESSION_NONE)
+	assert.ErrorContains(t, err, "unable to read access log")
+}
@@ -0,0 +1,226 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package accesslog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// record is a request as it was logged.
+type record struct {
+	method string
+	uri    string
+	body   string
+	time   time.Time
+}
+
+// parser reads the records of a log, along with the number of lines which could not be parsed.
+type parser func(content string) ([]record, int, error)
+
+func newParser(format string) (parser, error) {
+	switch format {
+	case COMBINED, COMMON, "":
+		return parseCombined, nil
+	case JSON:
+		return parseJSONLines, nil
+	case CSV:
+		return parseCSV, nil
+	}
+	return nil, fmt.Errorf("access log format %q not supported, please use %s, %s, %s or %s", format, COMBINED, COMMON, JSON, CSV)
+}
+
+// combinedLineRegex matches the lines of the common log format, e.g.
+// 127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326
+// The combined log format, which adds the referer and user agent, matches as well.
+var combinedLineRegex = regexp.MustCompile(`^\S+ \S+ .*?\[([^\]]+)\] "(\S+) (\S+)(?: [^"]*)?" `)
+
+// combinedTimeLayout is the layout of the times of the common log format.
+const combinedTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+func parseCombined(content string) ([]record, int, error) {
+	var records []record
+	skipped := 0
+	for _, line := range lines(content) {
+		match := combinedLineRegex.FindStringSubmatch(line)
+		if match == nil {
+			skipped++
+			continue
+		}
+		t, err := time.Parse(combinedTimeLayout, match[1])
+		if err != nil {
+			skipped++
+			continue
+		}
+		records = append(records, record{method: match[2], uri: match[3], time: t})
+	}
+	return records, skipped, nil
+}
+
+// fields holds the names under which the values of a request are looked up in JSON lines and CSV logs, in order of preference.
+var fields = struct {
+	method, uri, request, body, time []string
+}{
+	method:  []string{"method", "request_method"},
+	uri:     []string{"path", "uri", "url", "request_uri"},
+	request: []string{"request"},
+	body:    []string{"body", "request_body"},
+	time:    []string{"time", "timestamp", "@timestamp", "time_local"},
+}
+
+func parseJSONLines(content string) ([]record, int, error) {
+	var records []record
+	skipped := 0
+	for _, line := range lines(content) {
+		var object map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &object); err != nil {
+			skipped++
+			continue
+		}
+		r, ok := toRecord(func(name string) (string, bool) {
+			switch v := object[name].(type) {
+			case string:
+				return v, v != ""
+			case float64:
+				return strconv.FormatFloat(v, 'f', -1, 64), true
+			case nil:
+				return "", false
+			default:
+				b, _ := json.Marshal(v)
+				return string(b), true
+			}
+		})
+		if !ok {
+			skipped++
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, skipped, nil
+}
+
+func parseCSV(content string) ([]record, int, error) {
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(rows) == 0 {
+		return nil, 0, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if !hasAny(columns, fields.request) && !(hasAny(columns, fields.method) && hasAny(columns, fields.uri)) {
+		return nil, 0, fmt.Errorf("the header row must name either the method and path columns, or a request column")
+	}
+
+	var records []record
+	skipped := 0
+	for _, row := range rows[1:] {
+		r, ok := toRecord(func(name string) (string, bool) {
+			i, ok := columns[name]
+			if !ok || i >= len(row) || row[i] == "" {
+				return "", false
+			}
+			return row[i], true
+		})
+		if !ok {
+			skipped++
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, skipped, nil
+}
+
+func hasAny(columns map[string]int, names []string) bool {
+	for _, name := range names {
+		if _, ok := columns[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// toRecord builds a record from the values of a JSON or CSV line. The method and URI are either separate fields, or a
+// request field as logged by nginx, e.g. "GET /search?q=hotels HTTP/1.1". It returns false if the request or time cannot be read.
+func toRecord(value func(name string) (string, bool)) (record, bool) {
+	var r record
+	r.method, _ = lookup(value, fields.method)
+	r.uri, _ = lookup(value, fields.uri)
+	if request, ok := lookup(value, fields.request); ok && (r.method == "" || r.uri == "") {
+		parts := strings.Fields(request)
+		if len(parts) < 2 {
+			return record{}, false
+		}
+		r.method, r.uri = parts[0], parts[1]
+	}
+	if r.method == "" || r.uri == "" {
+		return record{}, false
+	}
+	r.body, _ = lookup(value, fields.body)
+	if t, ok := lookup(value, fields.time); ok {
+		parsed, err := parseTime(t)
+		if err != nil {
+			return record{}, false
+		}
+		r.time = parsed
+	}
+	return r, true
+}
+
+func lookup(value func(name string) (string, bool), names []string) (string, bool) {
+	for _, name := range names {
+		if v, ok := value(name); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// parseTime reads a time written in RFC 3339, in the layout of the common log format, or as seconds since the Unix epoch.
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(combinedTimeLayout, value); err == nil {
+		return t, nil
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unsupported time %s", value)
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), nil
+}
+
+// lines returns the lines of a log which are not empty.
+func lines(content string) []string {
+	var result []string
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
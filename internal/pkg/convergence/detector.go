@@ -0,0 +1,212 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+// Package convergence detects when the latencies of the warmup requests have settled, so that the warmup can stop early.
+package convergence
+
+import (
+	"fmt"
+	"mittens/internal/pkg/stats"
+	"sync"
+	"time"
+)
+
+// Options configure when latencies are considered to have converged.
+type Options struct {
+	// Tolerance is the maximum relative deviation, e.g. 0.1 for 10%, of the average latency of each second of the
+	// window from the average latency of the whole window.
+	Tolerance float64
+	// Window is how long latencies must stay within the tolerance.
+	Window time.Duration
+	// MinDuration is the minimum duration of the warmup, whether latencies converged or not.
+	MinDuration time.Duration
+	// MinRequests is the minimum number of successful responses for each endpoint.
+	MinRequests int
+	// MaxErrorRate is the maximum share, between 0 and 1, of the responses of each endpoint during the window which
+	// failed or did not get a response.
+	MaxErrorRate float64
+}
+
+// Validate checks that the options are within their allowed ranges.
+func (o Options) Validate() error {
+	if o.Tolerance <= 0 {
+		return fmt.Errorf("tolerance must be greater than 0, got %g", o.Tolerance)
+	}
+	if o.Window < 2*time.Second {
+		return fmt.Errorf("window must be at least 2s, got %v", o.Window)
+	}
+	if o.MinDuration < 0 {
+		return fmt.Errorf("min duration cannot be negative, got %v", o.MinDuration)
+	}
+	if o.MinRequests < 0 {
+		return fmt.Errorf("min requests cannot be negative, got %d", o.MinRequests)
+	}
+	if o.MaxErrorRate < 0 || o.MaxErrorRate > 1 {
+		return fmt.Errorf("max error rate must be between 0 and 100%%, got %g%%", o.MaxErrorRate*100)
+	}
+	return nil
+}
+
+// bucket holds the latencies of the successful responses received during one second, and the number of failures.
+type bucket struct {
+	total  time.Duration
+	count  int
+	failed int
+}
+
+// series holds the rolling latency of an endpoint.
+type series struct {
+	count   int
+	buckets map[int64]*bucket
+	// finished is set once the endpoint is no longer sent, see Detector.Finish.
+	finished bool
+}
+
+// Detector tracks a rolling latency for each endpoint. It is a stats.Observer and is safe for concurrent use.
+type Detector struct {
+	Options   Options
+	mu        sync.Mutex
+	endpoints map[string]*series
+}
+
+// New creates a detector with the given options.
+func New(options Options) *Detector {
+	return &Detector{Options: options, endpoints: make(map[string]*series)}
+}
+
+// Observe adds the latency of a successful response to the rolling latency of its endpoint, or counts the failure.
+func (d *Detector) Observe(result stats.Result) {
+	d.observeAt(result, time.Now())
+}
+
+func (d *Detector) observeAt(result stats.Result, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s := d.series(result.Protocol, result.Endpoint)
+	b, ok := s.buckets[now.Unix()]
+	if !ok {
+		b = &bucket{}
+		s.buckets[now.Unix()] = b
+	}
+	if !result.Success() {
+		b.failed++
+		return
+	}
+	s.count++
+	b.total += result.Duration
+	b.count++
+}
+
+// Finish tells the detector that an endpoint is no longer sent, e.g. a request with a count which was sent that many times
+// or whose datasets have no rows left. A finished endpoint no longer needs MinRequests successful responses, since it cannot
+// get more than it was sent, and it is ignored once it has no responses left in the window.
+func (d *Detector) Finish(protocol string, endpoint string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.series(protocol, endpoint).finished = true
+}
+
+// series returns the series of an endpoint, creating it if needed. The caller must hold the lock.
+func (d *Detector) series(protocol string, endpoint string) *series {
+	key := protocol + " " + endpoint
+	s, ok := d.endpoints[key]
+	if !ok {
+		s = &series{buckets: make(map[int64]*bucket)}
+		d.endpoints[key] = s
+	}
+	return s
+}
+
+// Converged returns true if every endpoint got at least MinRequests successful responses and, over the Window that
+// ended with the last complete second before now, the average latency of every second stayed within Tolerance of the
+// average latency of the whole window. Seconds without successful responses are ignored, but each endpoint needs at least
+// two, and the share of failures of each endpoint during the window must not be above MaxErrorRate. Finished endpoints
+// without responses in the window are ignored. It does not check MinDuration, which is up to the caller.
+func (d *Detector) Converged(now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	end := now.Unix()
+	start := end - int64(d.Options.Window/time.Second)
+	if len(d.endpoints) == 0 {
+		return false
+	}
+
+	converged := true
+	for _, s := range d.endpoints {
+		// older buckets are no longer needed
+		for second := range s.buckets {
+			if second < start {
+				delete(s.buckets, second)
+			}
+		}
+		if converged && !s.converged(start, end, d.Options) {
+			converged = false
+		}
+	}
+	return converged
+}
+
+// converged checks the seconds in [start, end) of a single endpoint.
+func (s *series) converged(start int64, end int64, options Options) bool {
+	if s.finished && !s.hasBuckets(start, end) {
+		return true
+	}
+	if !s.finished && s.count < options.MinRequests {
+		return false
+	}
+
+	var averages []float64
+	var total time.Duration
+	var count, failed int
+	for second := start; second < end; second++ {
+		b, ok := s.buckets[second]
+		if !ok {
+			continue
+		}
+		failed += b.failed
+		if b.count == 0 {
+			continue
+		}
+		averages = append(averages, float64(b.total)/float64(b.count))
+		total += b.total
+		count += b.count
+	}
+	if len(averages) < 2 {
+		return false
+	}
+	if float64(failed) > options.MaxErrorRate*float64(count+failed) {
+		return false
+	}
+
+	average := float64(total) / float64(count)
+	for _, a := range averages {
+		if a < average*(1-options.Tolerance) || a > average*(1+options.Tolerance) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasBuckets returns true if the endpoint got any response, successful or not, in [start, end).
+func (s *series) hasBuckets(start int64, end int64) bool {
+	for second := range s.buckets {
+		if second >= start && second < end {
+			return true
+		}
+	}
+	return false
+}
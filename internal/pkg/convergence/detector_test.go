@@ -0,0 +1,127 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package convergence
+
+import (
+	"errors"
+	"mittens/internal/pkg/stats"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Unix(1000, 0)
+
+// observe records one response per latency, one second after the other, starting at the given second.
+func observe(d *Detector, endpoint string, second int, latencies ...time.Duration) {
+	for i, latency := range latencies {
+		d.observeAt(stats.Result{Endpoint: endpoint, Protocol: stats.HTTP, StatusCode: 200, Duration: latency}, start.Add(time.Duration(second+i)*time.Second))
+	}
+}
+
+func TestDetector_Converged(t *testing.T) {
+	d := New(Options{Tolerance: 0.1, Window: 3 * time.Second, MinRequests: 5})
+
+	// latencies are still dropping at first, then settle around 10ms
+	observe(d, "GET /ping", 0, 100*time.Millisecond, 50*time.Millisecond, 20*time.Millisecond, 10*time.Millisecond, 11*time.Millisecond, 10*time.Millisecond)
+
+	assert.False(t, d.Converged(start.Add(4*time.Second)), "the window still includes the 20ms second")
+	assert.True(t, d.Converged(start.Add(6*time.Second)))
+}
+
+func TestDetector_EveryEndpointMustConverge(t *testing.T) {
+	d := New(Options{Tolerance: 0.1, Window: 3 * time.Second})
+
+	observe(d, "GET /ping", 0, 10*time.Millisecond, 10*time.Millisecond, 10*time.Millisecond)
+	observe(d, "GET /search", 0, 90*time.Millisecond, 60*time.Millisecond, 30*time.Millisecond)
+
+	assert.False(t, d.Converged(start.Add(3*time.Second)))
+}
+
+func TestDetector_MinRequests(t *testing.T) {
+	d := New(Options{Tolerance: 0.1, Window: 2 * time.Second, MinRequests: 10})
+
+	observe(d, "GET /ping", 0, 10*time.Millisecond, 10*time.Millisecond, 10*time.Millisecond)
+
+	assert.False(t, d.Converged(start.Add(3*time.Second)))
+}
+
+func TestDetector_FailuresDoNotCountAsLatencies(t *testing.T) {
+	d := New(Options{Tolerance: 0.1, Window: 2 * time.Second, MaxErrorRate: 0.5})
+
+	observe(d, "GET /ping", 0, 10*time.Millisecond, 10*time.Millisecond)
+	d.observeAt(stats.Result{Endpoint: "GET /ping", Protocol: stats.HTTP, StatusCode: 503, Duration: time.Second}, start.Add(time.Second))
+
+	assert.True(t, d.Converged(start.Add(2*time.Second)))
+}
+
+func TestDetector_MaxErrorRate(t *testing.T) {
+	d := New(Options{Tolerance: 0.1, Window: 2 * time.Second, MaxErrorRate: 0.2})
+
+	observe(d, "GET /ping", 0, 10*time.Millisecond, 10*time.Millisecond, 10*time.Millisecond, 10*time.Millisecond, 10*time.Millisecond)
+	// the failures are fast, but must not let the endpoint converge
+	for i := 0; i < 2; i++ {
+		d.observeAt(stats.Result{Endpoint: "GET /ping", Protocol: stats.HTTP, StatusCode: 503, Duration: time.Millisecond}, start)
+	}
+	d.observeAt(stats.Result{Endpoint: "GET /ping", Protocol: stats.HTTP, Err: errors.New("timeout")}, start.Add(4*time.Second))
+
+	assert.False(t, d.Converged(start.Add(2*time.Second)), "2 of the 4 requests failed")
+	assert.True(t, d.Converged(start.Add(3*time.Second)), "the failures are before the window")
+	assert.False(t, d.Converged(start.Add(5*time.Second)), "1 of the 3 requests got no response")
+}
+
+func TestDetector_FailuresOnly(t *testing.T) {
+	d := New(Options{Tolerance: 0.1, Window: 2 * time.Second, MaxErrorRate: 1})
+
+	for second := 0; second < 3; second++ {
+		d.observeAt(stats.Result{Endpoint: "GET /ping", Protocol: stats.HTTP, StatusCode: 503, Duration: time.Millisecond}, start.Add(time.Duration(second)*time.Second))
+	}
+
+	assert.False(t, d.Converged(start.Add(3*time.Second)))
+}
+
+func TestDetector_FinishedEndpoints(t *testing.T) {
+	d := New(Options{Tolerance: 0.1, Window: 3 * time.Second, MinRequests: 5})
+
+	// a once request is sent first, then only the weighted request is sent
+	observe(d, "GET /once", 0, 500*time.Millisecond)
+	d.Finish(stats.HTTP, "GET /once")
+	observe(d, "GET /ping", 1, 10*time.Millisecond, 10*time.Millisecond, 10*time.Millisecond, 10*time.Millisecond, 10*time.Millisecond)
+
+	assert.True(t, d.Converged(start.Add(6*time.Second)), "the once request is ignored once it has no responses in the window")
+
+	d = New(Options{Tolerance: 0.1, Window: 3 * time.Second, MinRequests: 5})
+	observe(d, "GET /counted", 0, 10*time.Millisecond, 10*time.Millisecond, 10*time.Millisecond)
+	observe(d, "GET /ping", 0, 10*time.Millisecond, 10*time.Millisecond, 10*time.Millisecond, 10*time.Millisecond, 10*time.Millisecond)
+
+	assert.False(t, d.Converged(start.Add(3*time.Second)), "the counted request is still sent")
+	d.Finish(stats.HTTP, "GET /counted")
+	assert.True(t, d.Converged(start.Add(3*time.Second)), "the counted request got as many responses as it was sent")
+}
+
+func TestDetector_NoResponses(t *testing.T) {
+	d := New(Options{Tolerance: 0.1, Window: 2 * time.Second})
+
+	assert.False(t, d.Converged(start.Add(10*time.Second)))
+}
+
+func TestOptions_Validate(t *testing.T) {
+	assert.NoError(t, Options{Tolerance: 0.1, Window: 10 * time.Second, MinDuration: 10 * time.Second, MinRequests: 100}.Validate())
+	assert.Error(t, Options{Tolerance: 0, Window: 10 * time.Second}.Validate())
+	assert.Error(t, Options{Tolerance: 0.1, Window: time.Second}.Validate())
+	assert.Error(t, Options{Tolerance: 0.1, Window: 10 * time.Second, MinRequests: -1}.Validate())
+	assert.Error(t, Options{Tolerance: 0.1, Window: 10 * time.Second, MaxErrorRate: 1.5}.Validate())
+}
@@ -0,0 +1,194 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+// Package dataset reads the rows of the CSV and JSON lines files used by data placeholders, e.g. {$data|hotels.id}.
+package dataset
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	SEQUENTIAL = "sequential"
+	RANDOM     = "random"
+	ONCE       = "once"
+)
+
+// Dataset holds the rows of a file. Each row maps the name of a column to its value.
+type Dataset struct {
+	Name string
+	// Mode is how the rows are picked: sequential (in order, starting over after the last row), random, or once (in order, each row once).
+	Mode    string
+	Columns map[string]bool
+	Rows    []map[string]string
+}
+
+// Load reads a dataset from a CSV file, whose first row holds the names of the columns, or from a JSON file, which holds
+// either one object per line or an array of objects. JSON values which are not strings are written as JSON.
+func Load(name, path, mode string) (*Dataset, error) {
+	switch mode {
+	case "":
+		mode = SEQUENTIAL
+	case SEQUENTIAL, RANDOM, ONCE:
+	default:
+		return nil, fmt.Errorf("invalid dataset %s: mode %q not supported, please use %s, %s or %s", name, mode, SEQUENTIAL, RANDOM, ONCE)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read dataset %s: %v", name, err)
+	}
+
+	var rows []map[string]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		rows, err = readCSV(content)
+	case ".json", ".jsonl", ".ndjson":
+		rows, err = readJSON(content)
+	default:
+		err = fmt.Errorf("unsupported file %s, expected a .csv, .json, .jsonl or .ndjson file", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid dataset %s: %v", name, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("invalid dataset %s: %s has no rows", name, path)
+	}
+
+	d := &Dataset{Name: name, Mode: mode, Columns: make(map[string]bool), Rows: rows}
+	for _, row := range rows {
+		for column := range row {
+			d.Columns[column] = true
+		}
+	}
+	return d, nil
+}
+
+func readCSV(content []byte) ([]map[string]string, error) {
+	records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	header := records[0]
+	var rows []map[string]string
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, column := range header {
+			row[strings.TrimSpace(column)] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func readJSON(content []byte) ([]map[string]string, error) {
+	var objects []map[string]interface{}
+	if trimmed := bytes.TrimSpace(content); bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := json.Unmarshal(trimmed, &objects); err != nil {
+			return nil, err
+		}
+	} else {
+		for i, line := range bytes.Split(content, []byte("\n")) {
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			var object map[string]interface{}
+			if err := json.Unmarshal(line, &object); err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+			objects = append(objects, object)
+		}
+	}
+
+	var rows []map[string]string
+	for _, object := range objects {
+		row := make(map[string]string, len(object))
+		for column, value := range object {
+			if s, ok := value.(string); ok {
+				row[column] = s
+				continue
+			}
+			b, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			row[column] = string(b)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Cursor picks the rows of several datasets for the requests which use them. Each request gets its own cursor, so that
+// every request goes through the rows of a dataset on its own. A cursor is safe to use from several goroutines.
+type Cursor struct {
+	datasets []*Dataset
+	mu       sync.Mutex
+	next     int
+}
+
+// NewCursor returns a cursor over the given datasets.
+func NewCursor(datasets ...*Dataset) *Cursor {
+	return &Cursor{datasets: datasets}
+}
+
+// Next returns a row of each dataset, by dataset name. Sequential and once datasets move on to their next row, and random
+// datasets pick any row. It returns false once a dataset whose mode is once has no rows left.
+func (c *Cursor) Next() (map[string]map[string]string, bool) {
+	c.mu.Lock()
+	n := c.next
+	c.next++
+	c.mu.Unlock()
+
+	rows := make(map[string]map[string]string, len(c.datasets))
+	for _, d := range c.datasets {
+		switch d.Mode {
+		case RANDOM:
+			rows[d.Name] = d.Rows[rand.Intn(len(d.Rows))]
+		case ONCE:
+			if n >= len(d.Rows) {
+				return nil, false
+			}
+			rows[d.Name] = d.Rows[n]
+		default:
+			rows[d.Name] = d.Rows[n%len(d.Rows)]
+		}
+	}
+	return rows, true
+}
+
+// Done returns whether a dataset whose mode is once has no rows left, in which case Next returns false from then on.
+func (c *Cursor) Done() bool {
+	c.mu.Lock()
+	n := c.next
+	c.mu.Unlock()
+
+	for _, d := range c.datasets {
+		if d.Mode == ONCE && n >= len(d.Rows) {
+			return true
+		}
+	}
+	return false
+}
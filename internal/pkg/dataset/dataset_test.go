@@ -0,0 +1,119 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package dataset
+
+import (
+	"mittens/internal/pkg/internal"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_CSV(t *testing.T) {
+	d, err := Load("hotels", internal.WriteTempFile(t, "hotels.csv", "id, locale\n42,en_GB\n7,\"fr_FR\"\n"), "")
+	require.NoError(t, err)
+	assert.Equal(t, SEQUENTIAL, d.Mode)
+	assert.Equal(t, map[string]bool{"id": true, "locale": true}, d.Columns)
+	assert.Equal(t, []map[string]string{{"id": "42", "locale": "en_GB"}, {"id": "7", "locale": "fr_FR"}}, d.Rows)
+}
+
+func TestLoad_JSON(t *testing.T) {
+	lines := `{"from": "EUR", "to": "USD", "rate": 1.1}
+
+{"from": "GBP", "to": "EUR", "tags": ["a"]}
+`
+	d, err := Load("pairs", internal.WriteTempFile(t, "pairs.jsonl", lines), RANDOM)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]string{{"from": "EUR", "to": "USD", "rate": "1.1"}, {"from": "GBP", "to": "EUR", "tags": `["a"]`}}, d.Rows)
+	assert.True(t, d.Columns["tags"])
+
+	d, err = Load("pairs", internal.WriteTempFile(t, "pairs.json", `[{"from": "EUR"}]`), ONCE)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]string{{"from": "EUR"}}, d.Rows)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := map[string]struct {
+		name, content, mode string
+	}{
+		"mode \"shuffled\" not supported":           {"a.csv", "id\n1\n", "shuffled"},
+		"has no rows":                               {"a.csv", "id\n", ""},
+		"expected a .csv, .json, .jsonl or .ndjson": {"a.txt", "id\n1\n", ""},
+		"wrong number of fields":                    {"a.csv", "id,locale\n1\n", ""},
+		"line 2":                                    {"a.ndjson", "{\"id\": 1}\nnot json\n", ""},
+	}
+	for expected, test := range tests {
+		t.Run(expected, func(t *testing.T) {
+			_, err := Load("a", internal.WriteTempFile(t, test.name, test.content), test.mode)
+			assert.ErrorContains(t, err, expected)
+		})
+	}
+
+	_, err := Load("a", "/does-not-exist.csv", "")
+	assert.ErrorContains(t, err, "unable to read dataset a")
+}
+
+func TestCursor_Next(t *testing.T) {
+	hotels := &Dataset{Name: "hotels", Mode: SEQUENTIAL, Rows: []map[string]string{{"id": "1"}, {"id": "2"}}}
+	locales := &Dataset{Name: "locales", Mode: ONCE, Rows: []map[string]string{{"code": "en"}, {"code": "fr"}, {"code": "de"}}}
+	cursor := NewCursor(hotels, locales)
+	assert.False(t, cursor.Done())
+
+	var ids, codes []string
+	for {
+		rows, ok := cursor.Next()
+		if !ok {
+			break
+		}
+		ids = append(ids, rows["hotels"]["id"])
+		codes = append(codes, rows["locales"]["code"])
+	}
+	assert.Equal(t, []string{"1", "2", "1"}, ids, "sequential datasets start over after their last row")
+	assert.Equal(t, []string{"en", "fr", "de"}, codes, "once datasets use each row once")
+
+	assert.True(t, cursor.Done(), "the cursor is done once a once dataset has no rows left")
+
+	sequential := NewCursor(hotels)
+	for i := 0; i < 5; i++ {
+		sequential.Next()
+	}
+	assert.False(t, sequential.Done(), "only once datasets run out of rows")
+}
+
+func TestCursor_Random(t *testing.T) {
+	d := &Dataset{Name: "hotels", Mode: RANDOM, Rows: []map[string]string{{"id": "1"}, {"id": "2"}}}
+	cursor := NewCursor(d)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	picked := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				rows, ok := cursor.Next()
+				assert.True(t, ok)
+				mu.Lock()
+				picked[rows["hotels"]["id"]] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, map[string]bool{"1": true, "2": true}, picked)
+}
@@ -16,12 +16,16 @@ package grpc
 
 import (
 	"bytes"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"log"
 	"mittens/internal/pkg/placeholders"
 	"mittens/internal/pkg/response"
+	"net/http"
+	"net/textproto"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/fullstorydev/grpcurl"
@@ -29,30 +33,56 @@ import (
 	"github.com/jhump/protoreflect/grpcreflect"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // Client represents a gRPC client.
 type Client struct {
 	host                string
-	insecure            bool
+	tlsConfig           *tls.Config
 	timeoutMilliseconds int
 	connClose           func() error
 	conn                *grpc.ClientConn
+	descriptors         Descriptors
 	descriptorSource    grpcurl.DescriptorSource
 }
 
 // eventHandler is a custom event handler with the option to enable/disable logging of responses.
+// It counts the responses and ends the call once maxResponses have been received.
 type eventHandler struct {
 	grpcurl.InvocationEventHandler
 	logResponses bool
+	maxResponses int
+	cancel       context.CancelFunc
+	responses    int
+	status       *status.Status
+	// capture keeps the headers and the last response message, formatted with formatter.
+	capture   bool
+	formatter grpcurl.Formatter
+	headers   metadata.MD
+	body      []byte
+	formatErr error
 }
 
-// NewClient returns a gRPC client.
-func NewClient(host string, insecure bool, timeoutMilliseconds int) Client {
-	return Client{host: host, insecure: insecure, connClose: func() error { return nil }, timeoutMilliseconds: timeoutMilliseconds}
+// RequestOptions holds settings that apply to a single request.
+type RequestOptions struct {
+	// LogResponses logs the response messages.
+	LogResponses bool
+	// MaxResponses ends the call once that many responses have been received. Zero means reading until the end of the stream.
+	MaxResponses int
+	// CaptureResponse returns the response headers and the last response message as JSON, e.g. to extract values from them.
+	CaptureResponse bool
+}
+
+// NewClient returns a gRPC client which gets the descriptors of the services from the given source.
+// A nil TLS configuration connects over plaintext.
+func NewClient(host string, tlsConfig *tls.Config, timeoutMilliseconds int, descriptors Descriptors) Client {
+	return Client{host: host, tlsConfig: tlsConfig, connClose: func() error { return nil }, timeoutMilliseconds: timeoutMilliseconds, descriptors: descriptors}
 }
 
 // Connect attempts to establish a connection with a gRPC server.
@@ -65,17 +95,12 @@ func (c *Client) Connect(headers []string) error {
 	// grpc.WithReturnConnectionError() is EXPERIMENTAL and may be changed or removed in a later release
 	// Added to provide more information if a connection error occurs
 	dialOptions := []grpc.DialOption{grpc.WithBlock(), grpc.WithReturnConnectionError()}
-	if c.insecure {
+	if c.tlsConfig == nil {
 		log.Print("ignoring gRPC server SSL/TLS authentication")
 		dialOptions = append(dialOptions, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	} else {
 		log.Print("using gRPC server SSL/TLS authentication")
-		tlsConf, err := grpcurl.ClientTLSConfig(c.insecure, "", "", "")
-		if err != nil {
-			return fmt.Errorf("failed to create TLS config: %v", err)
-		}
-		var creds = credentials.NewTLS(tlsConf)
-		dialOptions = append(dialOptions, grpc.WithTransportCredentials(creds))
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(credentials.NewTLS(c.tlsConfig)))
 	}
 
 	grpc.WithReturnConnectionError()
@@ -84,8 +109,18 @@ func (c *Client) Connect(headers []string) error {
 		return fmt.Errorf("gRPC dial: %v", err)
 	}
 
-	reflectionClient := grpcreflect.NewClientAuto(contextWithMetadata, conn)
-	descriptorSource := grpcurl.DescriptorSourceFromServer(contextWithMetadata, reflectionClient)
+	var descriptorSource grpcurl.DescriptorSource
+	if c.descriptors.UsesReflection() {
+		reflectionClient := grpcreflect.NewClientAuto(contextWithMetadata, conn)
+		descriptorSource = grpcurl.DescriptorSourceFromServer(contextWithMetadata, reflectionClient)
+	} else {
+		descriptorSource, err = c.descriptors.source()
+		if err != nil {
+			cancel()
+			conn.Close()
+			return fmt.Errorf("gRPC descriptors: %v", err)
+		}
+	}
 
 	log.Print("gRPC client connected")
 	c.conn = conn
@@ -97,8 +132,14 @@ func (c *Client) Connect(headers []string) error {
 // SendRequest sends a request to the gRPC server and wraps useful information into a Response object.
 // Note that the message cannot be null. Even if there is no message to be sent this needs to be set to an empty string.
 func (c *Client) SendRequest(serviceMethod string, message string, headers []string, logResponses bool) response.Response {
+	return c.SendRequestWithOptions(serviceMethod, []string{message}, headers, RequestOptions{LogResponses: logResponses})
+}
+
+// SendRequestWithOptions sends the messages to the gRPC server one after the other, which allows for client-streaming and
+// bidi-streaming calls, and wraps useful information into a Response object. The duration covers the whole stream.
+func (c *Client) SendRequestWithOptions(serviceMethod string, messages []string, headers []string, options RequestOptions) response.Response {
 	const respType = "grpc"
-	in := bytes.NewBufferString(message)
+	in := bytes.NewBufferString(strings.Join(messages, "\n"))
 
 	// TODO - create generic parser and formatter for any request, can we use text parser/formatter?
 	requestParser, formatter, err := grpcurl.RequestParserAndFormatter("json", c.descriptorSource, in, grpcurl.FormatOptions{})
@@ -113,9 +154,6 @@ func (c *Client) SendRequest(serviceMethod string, message string, headers []str
 		Formatter: formatter,
 	}
 
-	loggingEventHandler := eventHandler{InvocationEventHandler: delegate, logResponses: logResponses}
-	startTime := time.Now()
-
 	// Interpolate
 	interpolatedHeaders := make([]string, len(headers))
 	for i, header := range headers {
@@ -127,21 +165,106 @@ func (c *Client) SendRequest(serviceMethod string, message string, headers []str
 		return response.Response{Duration: time.Duration(0), Err: errors.New("no connection available"), Type: respType}
 	}
 
-	ctx, _ := context.WithTimeout(context.Background(), time.Duration(c.timeoutMilliseconds)*time.Millisecond)
-	err = grpcurl.InvokeRPC(ctx, c.descriptorSource, c.conn, serviceMethod, interpolatedHeaders, loggingEventHandler, requestParser.Next)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.timeoutMilliseconds)*time.Millisecond)
+	defer cancel()
+	handler := &eventHandler{InvocationEventHandler: delegate, logResponses: options.LogResponses, maxResponses: options.MaxResponses, cancel: cancel, capture: options.CaptureResponse, formatter: formatter}
+	sent := 0
+	next := func(m proto.Message) error {
+		if err := requestParser.Next(m); err != nil {
+			return err
+		}
+		sent++
+		return nil
+	}
+
+	startTime := time.Now()
+	err = grpcurl.InvokeRPC(ctx, c.descriptorSource, c.conn, serviceMethod, interpolatedHeaders, handler, next)
 	endTime := time.Now()
+	resp := response.Response{Duration: endTime.Sub(startTime), Type: respType, MessagesSent: sent, MessagesReceived: handler.responses}
 	if err != nil {
-		log.Printf("grpc response error: %s", err)
-		return response.Response{Duration: endTime.Sub(startTime), Err: nil, Type: respType}
+		// errors with a status were returned by the server, any other error means that the call could not be made
+		st, ok := status.FromError(err)
+		if !ok {
+			log.Printf("grpc response error: %s", err)
+			resp.Err = err
+			return resp
+		}
+		handler.status = st
 	}
-	return response.Response{Duration: endTime.Sub(startTime), Err: nil, Type: respType}
+
+	st := handler.status
+	if st.Code() == codes.Canceled && handler.limitReached() {
+		// the call was ended on purpose once enough responses were received
+		st = nil
+	}
+	if c.raisedByClient(ctx, st) {
+		log.Printf("grpc response error: %s", st.Err())
+		resp.Err = st.Err()
+		return resp
+	}
+	resp.GrpcCode = st.Code()
+	resp.GrpcMessage = st.Message()
+	if handler.capture {
+		if handler.formatErr != nil {
+			resp.Err = handler.formatErr
+			return resp
+		}
+		resp.Headers = make(http.Header, len(handler.headers))
+		for name, values := range handler.headers {
+			resp.Headers[textproto.CanonicalMIMEHeaderKey(name)] = values
+		}
+		resp.Body = handler.body
+	}
+	return resp
+}
+
+// raisedByClient returns true if the status was not returned by the server but raised by the client, because the call timed out
+// or the connection is down. No response was received then, as for an HTTP request which times out or whose connection is refused.
+func (c *Client) raisedByClient(ctx context.Context, st *status.Status) bool {
+	switch st.Code() {
+	case codes.DeadlineExceeded:
+		return errors.Is(ctx.Err(), context.DeadlineExceeded)
+	case codes.Unavailable:
+		return c.conn.GetState() != connectivity.Ready
+	}
+	return false
 }
 
 // OnReceiveResponse overrides the default method and allows enabling/disabling logging of responses.
-func (h eventHandler) OnReceiveResponse(msg proto.Message) {
+// Responses that were already buffered when the call was ended are ignored.
+func (h *eventHandler) OnReceiveResponse(msg proto.Message) {
+	if h.limitReached() {
+		return
+	}
+	h.responses++
+	if h.capture {
+		body, err := h.formatter(msg)
+		h.body = []byte(body)
+		h.formatErr = err
+	}
 	if h.logResponses {
 		h.InvocationEventHandler.OnReceiveResponse(msg)
 	}
+	if h.limitReached() {
+		h.cancel()
+	}
+}
+
+// OnReceiveHeaders keeps the response headers before passing them on.
+func (h *eventHandler) OnReceiveHeaders(md metadata.MD) {
+	h.headers = md
+	h.InvocationEventHandler.OnReceiveHeaders(md)
+}
+
+// OnReceiveTrailers keeps the status of the call before passing it on.
+func (h *eventHandler) OnReceiveTrailers(stat *status.Status, md metadata.MD) {
+	h.status = stat
+	h.InvocationEventHandler.OnReceiveTrailers(stat, md)
+}
+
+// limitReached returns true if the call was ended because maxResponses were received.
+func (h *eventHandler) limitReached() bool {
+	return h.maxResponses > 0 && h.responses >= h.maxResponses
 }
 
 // Close calling close on a client that has not established connection does not return an error.
@@ -0,0 +1,169 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"mittens/fixture"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+)
+
+func newTestClient(t *testing.T) *Client {
+	server, port := fixture.StartGrpcTargetTestServer(fixture.NewCallStats())
+	t.Cleanup(server.Stop)
+
+	c := NewClient(fmt.Sprintf("localhost:%d", port), nil, 5000, Descriptors{})
+	require.NoError(t, c.Connect(nil))
+	t.Cleanup(func() { c.Close() })
+	return &c
+}
+
+func TestSendRequest_ServerStreaming(t *testing.T) {
+	c := newTestClient(t)
+
+	message := `{"response_parameters": [{"size": 1}, {"size": 2}, {"size": 3}]}`
+	resp := c.SendRequestWithOptions("grpc.testing.TestService/StreamingOutputCall", []string{message}, nil, RequestOptions{})
+	assert.Nil(t, resp.Err)
+	assert.Equal(t, 1, resp.MessagesSent)
+	assert.Equal(t, 3, resp.MessagesReceived)
+}
+
+func TestSendRequest_ServerStreamingMaxResponses(t *testing.T) {
+	c := newTestClient(t)
+
+	message := `{"response_parameters": [{"size": 1}, {"size": 2}, {"size": 3}]}`
+	resp := c.SendRequestWithOptions("grpc.testing.TestService/StreamingOutputCall", []string{message}, nil, RequestOptions{MaxResponses: 2})
+	assert.Nil(t, resp.Err)
+	assert.Equal(t, 2, resp.MessagesReceived)
+}
+
+func TestSendRequest_ClientStreaming(t *testing.T) {
+	c := newTestClient(t)
+
+	messages := []string{`{"payload": {"body": "YQ=="}}`, `{"payload": {"body": "YWI="}}`}
+	resp := c.SendRequestWithOptions("grpc.testing.TestService/StreamingInputCall", messages, nil, RequestOptions{})
+	assert.Nil(t, resp.Err)
+	assert.Equal(t, 2, resp.MessagesSent)
+	assert.Equal(t, 1, resp.MessagesReceived)
+}
+
+func TestSendRequest_BidiStreaming(t *testing.T) {
+	c := newTestClient(t)
+
+	messages := []string{`{}`, `{}`, `{}`}
+	resp := c.SendRequestWithOptions("grpc.testing.TestService/FullDuplexCall", messages, nil, RequestOptions{})
+	assert.Nil(t, resp.Err)
+	assert.Equal(t, 3, resp.MessagesSent)
+	assert.Equal(t, 3, resp.MessagesReceived)
+}
+
+func TestSendRequest_StatusCode(t *testing.T) {
+	c := newTestClient(t)
+
+	resp := c.SendRequestWithOptions("grpc.testing.TestService/EmptyCall", []string{""}, nil, RequestOptions{})
+	assert.Nil(t, resp.Err)
+	assert.Equal(t, codes.Unimplemented, resp.GrpcCode)
+	assert.Equal(t, "method EmptyCall not implemented", resp.GrpcMessage)
+
+	resp = c.SendRequestWithOptions("grpc.testing.TestService/StreamingOutputCall", []string{`{}`}, nil, RequestOptions{})
+	assert.Nil(t, resp.Err)
+	assert.Equal(t, codes.OK, resp.GrpcCode)
+}
+
+func TestSendRequest_ClientErrors(t *testing.T) {
+	server, port := fixture.StartGrpcTargetTestServer(fixture.NewCallStats())
+	c := NewClient(fmt.Sprintf("localhost:%d", port), nil, 5000, Descriptors{})
+	require.NoError(t, c.Connect(nil))
+	t.Cleanup(func() { c.Close() })
+
+	// the deadline is over before the call is made
+	c.timeoutMilliseconds = 0
+	resp := c.SendRequestWithOptions("grpc.testing.TestService/EmptyCall", []string{""}, nil, RequestOptions{})
+	assert.ErrorContains(t, resp.Err, "DeadlineExceeded", "timeouts are errors, as for HTTP requests")
+
+	c.timeoutMilliseconds = 5000
+	server.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c.conn.WaitForStateChange(ctx, connectivity.Ready)
+	resp = c.SendRequestWithOptions("grpc.testing.TestService/EmptyCall", []string{""}, nil, RequestOptions{})
+	assert.ErrorContains(t, resp.Err, "Unavailable", "refused connections are errors, as for HTTP requests")
+}
+
+func TestSendRequest_MaxResponsesIsNotCanceled(t *testing.T) {
+	c := newTestClient(t)
+
+	message := `{"response_parameters": [{"size": 1}, {"size": 2}, {"size": 3}]}`
+	resp := c.SendRequestWithOptions("grpc.testing.TestService/StreamingOutputCall", []string{message}, nil, RequestOptions{MaxResponses: 1})
+	assert.Nil(t, resp.Err)
+	assert.Equal(t, codes.OK, resp.GrpcCode)
+}
+
+func TestSendRequest_CaptureResponse(t *testing.T) {
+	c := newTestClient(t)
+
+	message := `{"response_parameters": [{"size": 1}, {"size": 3}]}`
+	resp := c.SendRequestWithOptions("grpc.testing.TestService/StreamingOutputCall", []string{message}, nil, RequestOptions{CaptureResponse: true})
+	assert.Nil(t, resp.Err)
+	assert.Equal(t, "application/grpc", resp.Headers.Get("Content-Type"))
+	assert.JSONEq(t, `{"payload": {"body": "AAAA"}}`, string(resp.Body), "the body holds the last response")
+
+	resp = c.SendRequestWithOptions("grpc.testing.TestService/StreamingOutputCall", []string{message}, nil, RequestOptions{})
+	assert.Nil(t, resp.Headers)
+	assert.Nil(t, resp.Body)
+}
+
+func TestSendRequest_UnknownMethod(t *testing.T) {
+	c := newTestClient(t)
+
+	resp := c.SendRequestWithOptions("grpc.testing.TestService/Unknown", []string{""}, nil, RequestOptions{})
+	assert.Error(t, resp.Err)
+}
+
+func TestConnect_MutualTLS(t *testing.T) {
+	certificates := fixture.WriteCertificates(t.TempDir())
+	server, port := fixture.StartGrpcTargetTestServerWithTLS(fixture.NewCallStats(), certificates.ServerTLSConfig())
+	t.Cleanup(server.Stop)
+
+	c := NewClient(fmt.Sprintf("localhost:%d", port), certificates.ClientTLSConfig(), 5000, Descriptors{})
+	require.NoError(t, c.Connect(nil))
+	t.Cleanup(func() { c.Close() })
+
+	resp := c.SendRequestWithOptions("grpc.testing.TestService/StreamingOutputCall", []string{`{}`}, nil, RequestOptions{})
+	assert.Nil(t, resp.Err)
+	assert.Equal(t, codes.OK, resp.GrpcCode)
+}
+
+func TestConnect_UnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "app.sock")
+	server := fixture.StartGrpcTargetTestServerOnSocket(fixture.NewCallStats(), socket)
+	t.Cleanup(server.Stop)
+
+	c := NewClient("unix://"+socket, nil, 5000, Descriptors{})
+	require.NoError(t, c.Connect(nil))
+	t.Cleanup(func() { c.Close() })
+
+	resp := c.SendRequestWithOptions("grpc.testing.TestService/StreamingOutputCall", []string{`{}`}, nil, RequestOptions{})
+	assert.Nil(t, resp.Err)
+	assert.Equal(t, codes.OK, resp.GrpcCode)
+}
@@ -0,0 +1,101 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package grpc
+
+import (
+	"errors"
+
+	"github.com/fullstorydev/grpcurl"
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Descriptors sets where the client gets the descriptors of the services and messages from.
+// If no files are set the descriptors are fetched from the server using reflection.
+type Descriptors struct {
+	// ProtoFiles are .proto source files. Their imports are looked up in ImportPaths.
+	ProtoFiles  []string
+	ImportPaths []string
+	// ProtosetFiles are compiled FileDescriptorSet files, e.g. generated with `protoc --descriptor_set_out --include_imports`.
+	ProtosetFiles []string
+}
+
+// UsesReflection returns true if the descriptors are fetched from the server.
+func (d Descriptors) UsesReflection() bool {
+	return len(d.ProtoFiles) == 0 && len(d.ProtosetFiles) == 0
+}
+
+// Validate checks that the files can be loaded.
+func (d Descriptors) Validate() error {
+	if d.UsesReflection() {
+		if len(d.ImportPaths) > 0 {
+			return errors.New("import paths require proto files")
+		}
+		return nil
+	}
+	_, err := d.source()
+	return err
+}
+
+// source loads the descriptors from the files. It must not be called if the descriptors come from reflection.
+// Symbols which are not in the files are looked up in the built-in descriptors of the gRPC health service, so that the
+// readiness probe can call grpc.health.v1.Health/Check without the files having to include it.
+func (d Descriptors) source() (grpcurl.DescriptorSource, error) {
+	if len(d.ProtoFiles) > 0 && len(d.ProtosetFiles) > 0 {
+		return nil, errors.New("proto files and protoset files cannot be used together")
+	}
+	var files grpcurl.DescriptorSource
+	var err error
+	if len(d.ProtosetFiles) > 0 {
+		files, err = grpcurl.DescriptorSourceFromProtoSets(d.ProtosetFiles...)
+	} else {
+		files, err = grpcurl.DescriptorSourceFromProtoFiles(d.ImportPaths, d.ProtoFiles...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	health, err := healthSource()
+	if err != nil {
+		return nil, err
+	}
+	return fallbackSource{DescriptorSource: files, fallback: health}, nil
+}
+
+// healthSource returns the descriptors of the gRPC health service which are compiled into the grpc module.
+func healthSource() (grpcurl.DescriptorSource, error) {
+	file, err := desc.WrapFile(grpc_health_v1.File_grpc_health_v1_health_proto)
+	if err != nil {
+		return nil, err
+	}
+	return grpcurl.DescriptorSourceFromFileDescriptors(file)
+}
+
+// fallbackSource looks up the symbols which are missing from a descriptor source in another one.
+// Services and extensions are only listed from the first source.
+type fallbackSource struct {
+	grpcurl.DescriptorSource
+	fallback grpcurl.DescriptorSource
+}
+
+func (s fallbackSource) FindSymbol(fullyQualifiedName string) (desc.Descriptor, error) {
+	d, err := s.DescriptorSource.FindSymbol(fullyQualifiedName)
+	if err == nil {
+		return d, nil
+	}
+	if fd, fallbackErr := s.fallback.FindSymbol(fullyQualifiedName); fallbackErr == nil {
+		return fd, nil
+	}
+	return nil, err
+}
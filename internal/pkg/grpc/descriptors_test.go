@@ -0,0 +1,127 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package grpc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const pingProto = `syntax = "proto3";
+package ping;
+import "messages/ping.proto";
+service PingService {
+  rpc Ping(ping.Ping) returns (ping.Ping);
+}
+`
+
+const pingMessagesProto = `syntax = "proto3";
+package ping;
+message Ping {
+  string body = 1;
+}
+`
+
+func TestDescriptors_ProtoFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "messages"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ping.proto"), []byte(pingProto), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "messages", "ping.proto"), []byte(pingMessagesProto), 0644))
+
+	descriptors := Descriptors{ProtoFiles: []string{"ping.proto"}, ImportPaths: []string{dir}}
+	assert.False(t, descriptors.UsesReflection())
+	require.NoError(t, descriptors.Validate())
+
+	source, err := descriptors.source()
+	require.NoError(t, err)
+	services, err := source.ListServices()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ping.PingService"}, services)
+}
+
+func TestDescriptors_ProtosetFiles(t *testing.T) {
+	protoset := writeProtoset(t, grpc_testing.File_grpc_testing_test_proto)
+
+	descriptors := Descriptors{ProtosetFiles: []string{protoset}}
+	source, err := descriptors.source()
+	require.NoError(t, err)
+	symbol, err := source.FindSymbol("grpc.testing.TestService")
+	require.NoError(t, err)
+	assert.Equal(t, "grpc.testing.TestService", symbol.GetFullyQualifiedName())
+}
+
+func TestDescriptors_HealthFallback(t *testing.T) {
+	protoset := writeProtoset(t, grpc_testing.File_grpc_testing_test_proto)
+
+	descriptors := Descriptors{ProtosetFiles: []string{protoset}}
+	source, err := descriptors.source()
+	require.NoError(t, err)
+	symbol, err := source.FindSymbol("grpc.health.v1.Health")
+	require.NoError(t, err)
+	assert.Equal(t, "grpc.health.v1.Health", symbol.GetFullyQualifiedName())
+
+	services, err := source.ListServices()
+	require.NoError(t, err)
+	assert.NotContains(t, services, "grpc.health.v1.Health")
+
+	_, err = source.FindSymbol("grpc.testing.MissingService")
+	assert.Error(t, err)
+}
+
+func TestDescriptors_Reflection(t *testing.T) {
+	assert.True(t, Descriptors{}.UsesReflection())
+	assert.NoError(t, Descriptors{}.Validate())
+}
+
+func TestDescriptors_Invalid(t *testing.T) {
+	assert.EqualError(t, Descriptors{ImportPaths: []string{"protos"}}.Validate(), "import paths require proto files")
+	assert.EqualError(t, Descriptors{ProtoFiles: []string{"a.proto"}, ProtosetFiles: []string{"a.protoset"}}.Validate(), "proto files and protoset files cannot be used together")
+	assert.Error(t, Descriptors{ProtoFiles: []string{filepath.Join(t.TempDir(), "missing.proto")}}.Validate())
+	assert.Error(t, Descriptors{ProtosetFiles: []string{filepath.Join(t.TempDir(), "missing.protoset")}}.Validate())
+}
+
+// writeProtoset writes a FileDescriptorSet with the given file and all its imports.
+func writeProtoset(t *testing.T, file protoreflect.FileDescriptor) string {
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := make(map[string]bool)
+	var add func(f protoreflect.FileDescriptor)
+	add = func(f protoreflect.FileDescriptor) {
+		if seen[f.Path()] {
+			return
+		}
+		seen[f.Path()] = true
+		imports := f.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(f))
+	}
+	add(file)
+
+	content, err := proto.Marshal(set)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "test.protoset")
+	require.NoError(t, os.WriteFile(path, content, 0644))
+	return path
+}
@@ -0,0 +1,45 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package grpc
+
+import (
+	"fmt"
+	"mittens/internal/pkg/response"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Expectation describes the response expected for a request. A response which does not meet it counts as a failure.
+type Expectation struct {
+	// Codes holds the allowed status codes, e.g. NOT_FOUND. If empty, only OK is allowed.
+	Codes []codes.Code `json:"codes"`
+}
+
+// Check returns an error if the status code of the response is not one of the expected codes.
+// A nil expectation only allows OK.
+func (e *Expectation) Check(resp response.Response) error {
+	if e == nil || len(e.Codes) == 0 {
+		if resp.GrpcCode != codes.OK {
+			return fmt.Errorf("unexpected code %s", resp.GrpcCode)
+		}
+		return nil
+	}
+	for _, code := range e.Codes {
+		if code == resp.GrpcCode {
+			return nil
+		}
+	}
+	return fmt.Errorf("unexpected code %s", resp.GrpcCode)
+}
@@ -15,19 +15,59 @@
 package grpc
 
 import (
+	"encoding/json"
 	"fmt"
 	"mittens/internal/pkg/placeholders"
+	"mittens/internal/pkg/selection"
 	"strings"
 )
 
 // Request represents a gRPC request.
 type Request struct {
+	Name          string
 	ServiceMethod string
 	Message       string
+	// Messages are sent one after the other instead of Message, for client-streaming and bidi-streaming calls.
+	Messages []string
+	// MaxResponses is the number of responses after which the call is ended. Zero means reading until the end of the stream.
+	MaxResponses int
+	// Expect describes the expected response. If nil, only the OK status code is expected.
+	Expect *Expectation
+	// Weight is the relative frequency with which the request is picked.
+	Weight int
+	// Count is the exact number of times the request is sent. Zero means that the request is picked according to its weight.
+	Count int
+}
+
+// jsonRequest is the JSON representation of a request.
+// Unlike the <service>/<method>[:message] format it allows setting a name, how often the request is sent
+// the messages of streaming calls and the expected status codes.
+type jsonRequest struct {
+	Name          string            `json:"name"`
+	ServiceMethod string            `json:"service-method"`
+	Message       json.RawMessage   `json:"message"`
+	Messages      []json.RawMessage `json:"messages"`
+	MaxResponses  int               `json:"max-responses"`
+	Expect        *Expectation      `json:"expect"`
+	Weight        int               `json:"weight"`
+	Count         int               `json:"count"`
+	Once          bool              `json:"once"`
+}
+
+// DisplayName returns the name of the request, or its service and method if the request has no name.
+func (r Request) DisplayName() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.ServiceMethod
 }
 
 // ToGrpcRequest parses a gRPC request which is in a string format and stores it in a struct.
+// The request is either in <service>/<method>[:message] format or a JSON object, e.g. {"service-method": "health/ping", "message": {"key": "value"}}.
 func ToGrpcRequest(requestFlag string) (Request, error) {
+	if strings.HasPrefix(strings.TrimSpace(requestFlag), "{") {
+		return toGrpcRequestFromJSON(requestFlag)
+	}
 
 	// service/method[:message]
 	parts := strings.SplitN(requestFlag, ":", 2)
@@ -35,7 +75,7 @@ func ToGrpcRequest(requestFlag string) (Request, error) {
 		return Request{}, fmt.Errorf("invalid request flag: %s, expected format <service>/<method>[:body]", requestFlag)
 	}
 
-	request := Request{ServiceMethod: parts[0]}
+	request := Request{ServiceMethod: parts[0], Weight: 1}
 	if len(parts) == 2 {
 		// the body of the request can either be inlined, or come from a file
 		rawBody, err := placeholders.GetBodyFromFileOrInlined(parts[1])
@@ -48,3 +88,84 @@ func ToGrpcRequest(requestFlag string) (Request, error) {
 	}
 	return request, nil
 }
+
+func toGrpcRequestFromJSON(requestFlag string) (Request, error) {
+	r := jsonRequest{Weight: 1}
+	decoder := json.NewDecoder(strings.NewReader(requestFlag))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&r); err != nil {
+		return Request{}, fmt.Errorf("invalid request: %s, %v", requestFlag, err)
+	}
+
+	if len(strings.Split(r.ServiceMethod, "/")) != 2 {
+		return Request{}, fmt.Errorf("invalid request: %s, expected service-method in <service>/<method> format", requestFlag)
+	}
+	if err := selection.Validate(r.Weight, r.Count, r.Once); err != nil {
+		return Request{}, fmt.Errorf("invalid request: %s, %v", requestFlag, err)
+	}
+	if len(r.Message) > 0 && len(r.Messages) > 0 {
+		return Request{}, fmt.Errorf("invalid request: %s, message and messages cannot be used together", requestFlag)
+	}
+	if r.MaxResponses < 0 {
+		return Request{}, fmt.Errorf("invalid request: %s, max-responses cannot be negative", requestFlag)
+	}
+
+	request := Request{Name: r.Name, ServiceMethod: r.ServiceMethod, MaxResponses: r.MaxResponses, Expect: r.Expect, Weight: r.Weight, Count: r.Count}
+	if r.Once {
+		request.Count = 1
+	}
+
+	message, err := messageFromJSON(r.Message)
+	if err != nil {
+		return Request{}, fmt.Errorf("invalid request: %s, %v", requestFlag, err)
+	}
+	if message != nil {
+		request.Message = *message
+	}
+	for _, raw := range r.Messages {
+		message, err := messageFromJSON(raw)
+		if err != nil {
+			return Request{}, fmt.Errorf("invalid request: %s, %v", requestFlag, err)
+		}
+		if message == nil {
+			return Request{}, fmt.Errorf("invalid request: %s, messages cannot be null", requestFlag)
+		}
+		request.Messages = append(request.Messages, *message)
+	}
+	return request, nil
+}
+
+// messageFromJSON returns the message held in a JSON string or value, or nil if there is none.
+func messageFromJSON(raw json.RawMessage) (*string, error) {
+	message, err := placeholders.BodyFromJSON(raw)
+	if err != nil || message == nil {
+		return nil, err
+	}
+	// the body of the request can either be inlined, or come from a file
+	rawBody, err := placeholders.GetBodyFromFileOrInlined(*message)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse body for request: %s", *message)
+	}
+	interpolated := placeholders.InterpolatePlaceholders(*rawBody)
+	return &interpolated, nil
+}
+
+// AllMessages returns the messages to send, which is either Messages or the single Message.
+func (r Request) AllMessages() []string {
+	if len(r.Messages) > 0 {
+		return r.Messages
+	}
+	return []string{r.Message}
+}
+
+// WithVariables returns a copy of the request whose messages have their variable placeholders, e.g. {$var|session},
+// replaced with the values of the variables.
+func (r Request) WithVariables(variables map[string]string) Request {
+	request := r
+	request.Message = placeholders.InterpolateVariables(r.Message, variables)
+	request.Messages = make([]string, len(r.Messages))
+	for i, message := range r.Messages {
+		request.Messages[i] = placeholders.InterpolateVariables(message, variables)
+	}
+	return request
+}
@@ -16,12 +16,14 @@ package grpc
 
 import (
 	"mittens/internal/pkg/internal"
+	"mittens/internal/pkg/response"
 	"os"
 	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
 )
 
 func TestBodyFromFile(t *testing.T) {
@@ -74,3 +76,75 @@ func TestGrpc_Interpolation(t *testing.T) {
 
 	assert.True(t, matchRequest)
 }
+
+func TestGrpc_JSONToGrpcRequest(t *testing.T) {
+	requestFlag := `{"name": "ping", "service-method": "health/ping", "message": {"db": "true"}, "weight": 3}`
+	request, err := ToGrpcRequest(requestFlag)
+	require.NoError(t, err)
+
+	assert.Equal(t, "ping", request.DisplayName())
+	assert.Equal(t, "health/ping", request.ServiceMethod)
+	assert.Equal(t, `{"db": "true"}`, request.Message)
+	assert.Equal(t, 3, request.Weight)
+	assert.Equal(t, 0, request.Count)
+}
+
+func TestGrpc_JSONOnceToGrpcRequest(t *testing.T) {
+	request, err := ToGrpcRequest(`{"service-method": "health/ping", "once": true}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, "health/ping", request.DisplayName())
+	assert.Equal(t, "", request.Message)
+	assert.Equal(t, 1, request.Count)
+}
+
+func TestGrpc_InvalidJSONToGrpcRequest(t *testing.T) {
+	_, err := ToGrpcRequest(`{"service-method": "health:ping"}`)
+	require.Error(t, err)
+
+	_, err = ToGrpcRequest(`{"service-method": "health/ping", "weight": 0}`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "weight must be greater than 0")
+}
+
+func TestJSONStreamingRequest(t *testing.T) {
+	request, err := ToGrpcRequest(`{"service-method": "grpc.testing.TestService/FullDuplexCall", "messages": [{"payload": {}}, "{}"], "max-responses": 2}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{`{"payload": {}}`, `{}`}, request.Messages)
+	assert.Equal(t, request.Messages, request.AllMessages())
+	assert.Equal(t, 2, request.MaxResponses)
+
+	request, err = ToGrpcRequest(`health/ping:{"key": "value"}`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"key": "value"}`}, request.AllMessages())
+}
+
+func TestInvalidJSONStreamingRequest(t *testing.T) {
+	_, err := ToGrpcRequest(`{"service-method": "health/ping", "message": {}, "messages": [{}]}`)
+	assert.ErrorContains(t, err, "message and messages cannot be used together")
+
+	_, err = ToGrpcRequest(`{"service-method": "health/ping", "messages": [null]}`)
+	assert.ErrorContains(t, err, "messages cannot be null")
+
+	_, err = ToGrpcRequest(`{"service-method": "health/ping", "max-responses": -1}`)
+	assert.ErrorContains(t, err, "max-responses cannot be negative")
+}
+
+func TestJSONExpectedCodes(t *testing.T) {
+	request, err := ToGrpcRequest(`{"service-method": "health/ping", "expect": {"codes": ["OK", "NOT_FOUND"]}}`)
+	require.NoError(t, err)
+	assert.Equal(t, []codes.Code{codes.OK, codes.NotFound}, request.Expect.Codes)
+
+	assert.NoError(t, request.Expect.Check(response.Response{GrpcCode: codes.NotFound}))
+	assert.EqualError(t, request.Expect.Check(response.Response{GrpcCode: codes.Internal}), "unexpected code Internal")
+
+	_, err = ToGrpcRequest(`{"service-method": "health/ping", "expect": {"codes": ["MISSING"]}}`)
+	assert.Error(t, err)
+}
+
+func TestNilExpectationOnlyAllowsOK(t *testing.T) {
+	var expect *Expectation
+	assert.NoError(t, expect.Check(response.Response{GrpcCode: codes.OK}))
+	assert.EqualError(t, expect.Check(response.Response{GrpcCode: codes.NotFound}), "unexpected code NotFound")
+}
@@ -0,0 +1,181 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+// Package har turns the requests recorded in HAR (HTTP Archive) files, e.g. exported from the developer tools of a browser,
+// into warmup requests.
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+	"mittens/internal/pkg/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Options filter the requests and headers imported from a HAR file.
+type Options struct {
+	// Hosts holds the hosts whose requests are imported, with or without a port. If empty, requests to any host are imported.
+	Hosts []string
+	// IncludeHeaders holds the only headers which are imported. If empty, every header is imported unless it is excluded.
+	IncludeHeaders []string
+	// ExcludeHeaders holds headers which are not imported, on top of the ones that cannot be replayed, see ignoredHeaders.
+	ExcludeHeaders []string
+}
+
+// ignoredHeaders are never imported. They describe the connection or the body of the recorded request and are set by
+// the client, if needed, when the request is sent to the target. HTTP/2 pseudo-headers such as :authority are ignored as well.
+// Content-Encoding is ignored since HAR files hold the decoded body, which is compressed according to the http-compression option.
+var ignoredHeaders = []string{"Host", "Content-Length", "Content-Encoding", "Connection", "Keep-Alive", "Transfer-Encoding", "Upgrade"}
+
+// archive holds the parts of a HAR file which describe the requests.
+type archive struct {
+	Log struct {
+		Entries []struct {
+			Request entry `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+type entry struct {
+	Method   string      `json:"method"`
+	URL      string      `json:"url"`
+	Headers  []nameValue `json:"headers"`
+	PostData *struct {
+		MimeType string      `json:"mimeType"`
+		Text     string      `json:"text"`
+		Params   []nameValue `json:"params"`
+	} `json:"postData"`
+}
+
+type nameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ToHTTPRequests reads the requests of a HAR file in the order in which they were recorded.
+// The requests keep the path and query of their URL, their headers and body. They are then prepared like any other request,
+// which interpolates their placeholders and compresses their body if needed.
+func ToHTTPRequests(path string, options Options, compression http.CompressionType) ([]http.Request, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read HAR file: %v", err)
+	}
+	var a archive
+	if err := json.Unmarshal(content, &a); err != nil {
+		return nil, fmt.Errorf("invalid HAR file %s: %v", path, err)
+	}
+
+	headers := newHeaderFilter(options)
+	var requests []http.Request
+	for i, e := range a.Log.Entries {
+		u, err := url.Parse(e.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid HAR file %s: entry %d: %v", path, i+1, err)
+		}
+		if !options.allowsHost(u) {
+			continue
+		}
+		request, err := e.Request.toHTTPRequest(headers, compression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid HAR file %s: entry %d: %v", path, i+1, err)
+		}
+		requests = append(requests, request)
+	}
+	return requests, nil
+}
+
+func (e entry) toHTTPRequest(headers headerFilter, compression http.CompressionType) (http.Request, error) {
+	request := http.Request{Method: e.Method, Path: http.RequestURI(e.URL), Headers: make(map[string]string)}
+	for _, header := range e.Headers {
+		if !headers.allows(header.Name) {
+			continue
+		}
+		name := textproto.CanonicalMIMEHeaderKey(header.Name)
+		// headers which are repeated, e.g. Accept recorded twice, are joined as the client would
+		if value, ok := request.Headers[name]; ok {
+			request.Headers[name] = value + ", " + header.Value
+		} else {
+			request.Headers[name] = header.Value
+		}
+	}
+
+	if e.PostData != nil {
+		body := e.PostData.Text
+		if body == "" && len(e.PostData.Params) > 0 {
+			// forms may be recorded as parameters only
+			form := url.Values{}
+			for _, param := range e.PostData.Params {
+				form.Add(param.Name, param.Value)
+			}
+			body = form.Encode()
+		}
+		if body != "" {
+			request.Body = &body
+		}
+		if _, ok := request.Headers["Content-Type"]; !ok && e.PostData.MimeType != "" && headers.allows("Content-Type") {
+			request.Headers["Content-Type"] = e.PostData.MimeType
+		}
+	}
+	return http.NewRequest(request, compression)
+}
+
+// allowsHost returns true if requests to the host of the URL are imported.
+func (o Options) allowsHost(u *url.URL) bool {
+	if len(o.Hosts) == 0 {
+		return true
+	}
+	for _, host := range o.Hosts {
+		if strings.EqualFold(host, u.Host) || strings.EqualFold(host, u.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerFilter decides which headers are imported. Header names are compared in their canonical form.
+type headerFilter struct {
+	include map[string]bool
+	exclude map[string]bool
+}
+
+func newHeaderFilter(options Options) headerFilter {
+	f := headerFilter{exclude: make(map[string]bool)}
+	if len(options.IncludeHeaders) > 0 {
+		f.include = make(map[string]bool)
+		for _, name := range options.IncludeHeaders {
+			f.include[textproto.CanonicalMIMEHeaderKey(name)] = true
+		}
+	}
+	for _, name := range ignoredHeaders {
+		f.exclude[textproto.CanonicalMIMEHeaderKey(name)] = true
+	}
+	for _, name := range options.ExcludeHeaders {
+		f.exclude[textproto.CanonicalMIMEHeaderKey(name)] = true
+	}
+	return f
+}
+
+func (f headerFilter) allows(name string) bool {
+	if strings.HasPrefix(name, ":") {
+		return false
+	}
+	name = textproto.CanonicalMIMEHeaderKey(name)
+	if f.exclude[name] {
+		return false
+	}
+	return f.include == nil || f.include[name]
+}
@@ -0,0 +1,160 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package har
+
+import (
+	"mittens/internal/pkg/http"
+	"mittens/internal/pkg/internal"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const archiveContent = `{
+  "log": {
+    "version": "1.2",
+    "entries": [
+      {
+        "request": {
+          "method": "GET",
+          "url": "https://api.example.com/search?q=hotels&page={$range|min=1,max=1}",
+          "headers": [
+            {"name": ":authority", "value": "api.example.com"},
+            {"name": "host", "value": "api.example.com"},
+            {"name": "accept", "value": "application/json"},
+            {"name": "accept", "value": "text/plain"},
+            {"name": "cookie", "value": "session=abc"},
+            {"name": "x-request-id", "value": "{$random|one}"}
+          ],
+          "queryString": [{"name": "q", "value": "hotels"}]
+        },
+        "response": {"status": 200}
+      },
+      {
+        "request": {
+          "method": "POST",
+          "url": "https://api.example.com:8443/bookings/{$range|min=3,max=3}#details",
+          "headers": [
+            {"name": "Content-Type", "value": "application/json"},
+            {"name": "Content-Length", "value": "16"},
+            {"name": "Content-Encoding", "value": "gzip"}
+          ],
+          "postData": {"mimeType": "application/json", "text": "{\"hotel\": \"{$range|min=7,max=7}\"}"}
+        },
+        "response": {"status": 201}
+      },
+      {
+        "request": {
+          "method": "POST",
+          "url": "https://api.example.com/login",
+          "headers": [],
+          "postData": {"mimeType": "application/x-www-form-urlencoded", "params": [{"name": "user", "value": "jane doe"}]}
+        },
+        "response": {"status": 200}
+      },
+      {
+        "request": {
+          "method": "GET",
+          "url": "https://cdn.example.com?v=2",
+          "headers": []
+        },
+        "response": {"status": 200}
+      }
+    ]
+  }
+}`
+
+func TestToHTTPRequests(t *testing.T) {
+	requests, err := ToHTTPRequests(internal.WriteTempFile(t, "traffic.har", archiveContent), Options{}, http.COMPRESSION_NONE)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(requests))
+
+	search := requests[0]
+	assert.Equal(t, "GET", search.Method)
+	assert.Equal(t, "/search?q=hotels&page=1", search.Path, "placeholders of the path are interpolated")
+	assert.Equal(t, 1, search.Weight)
+	assert.Equal(t, map[string]string{
+		"Accept":       "application/json, text/plain",
+		"Cookie":       "session=abc",
+		"X-Request-Id": "{$random|one}",
+	}, search.Headers, "pseudo-headers and Host are not imported, headers are interpolated when they are sent")
+	assert.Nil(t, search.Body)
+
+	booking := requests[1]
+	assert.Equal(t, "POST", booking.Method)
+	assert.Equal(t, "/bookings/3", booking.Path)
+	assert.Equal(t, map[string]string{"Content-Type": "application/json"}, booking.Headers)
+	require.NotNil(t, booking.Body)
+	assert.Equal(t, `{"hotel": "7"}`, *booking.Body)
+
+	login := requests[2]
+	require.NotNil(t, login.Body)
+	assert.Equal(t, "user=jane+doe", *login.Body, "forms recorded as parameters are encoded")
+	assert.Equal(t, "application/x-www-form-urlencoded", login.Headers["Content-Type"])
+
+	assert.Equal(t, "/?v=2", requests[3].Path)
+}
+
+func TestToHTTPRequests_Filters(t *testing.T) {
+	options := Options{
+		Hosts:          []string{"API.example.com"},
+		ExcludeHeaders: []string{"cookie"},
+	}
+	requests, err := ToHTTPRequests(internal.WriteTempFile(t, "traffic.har", archiveContent), options, http.COMPRESSION_NONE)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(requests), "requests to other hosts are not imported")
+	assert.Equal(t, "/bookings/3", requests[1].Path, "hosts match with or without their port")
+	assert.NotContains(t, requests[0].Headers, "Cookie")
+	assert.Contains(t, requests[0].Headers, "Accept")
+
+	options = Options{Hosts: []string{"api.example.com:8443"}, IncludeHeaders: []string{"Accept", "Content-Length"}}
+	requests, err = ToHTTPRequests(internal.WriteTempFile(t, "traffic.har", archiveContent), options, http.COMPRESSION_NONE)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(requests))
+	assert.Empty(t, requests[0].Headers, "ignored headers are not imported even if they are included")
+
+	requests, err = ToHTTPRequests(internal.WriteTempFile(t, "traffic.har", archiveContent), Options{IncludeHeaders: []string{"accept"}}, http.COMPRESSION_NONE)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"Accept": "application/json, text/plain"}, requests[0].Headers)
+	assert.Empty(t, requests[1].Headers)
+
+	ignored := append([]string(nil), ignoredHeaders...)
+	newHeaderFilter(Options{ExcludeHeaders: []string{"Cookie", "Authorization"}})
+	assert.Equal(t, ignored, ignoredHeaders, "excluded headers are not added to the ignored ones")
+}
+
+func TestToHTTPRequests_Compression(t *testing.T) {
+	requests, err := ToHTTPRequests(internal.WriteTempFile(t, "traffic.har", archiveContent), Options{}, http.COMPRESSION_GZIP)
+	require.NoError(t, err)
+	assert.Equal(t, "gzip", requests[1].Headers["Content-Encoding"])
+	assert.NotContains(t, requests[0].Headers, "Content-Encoding", "requests without a body are not compressed")
+
+	requests, err = ToHTTPRequests(internal.WriteTempFile(t, "traffic.har", archiveContent), Options{}, http.COMPRESSION_NONE)
+	require.NoError(t, err)
+	assert.NotContains(t, requests[1].Headers, "Content-Encoding", "recorded encodings are dropped since HAR files hold the decoded body")
+	assert.Equal(t, `{"hotel": "7"}`, *requests[1].Body)
+}
+
+func TestToHTTPRequests_Invalid(t *testing.T) {
+	_, err := ToHTTPRequests("/does-not-exist.har", Options{}, http.COMPRESSION_NONE)
+	assert.ErrorContains(t, err, "unable to read HAR file")
+
+	_, err = ToHTTPRequests(internal.WriteTempFile(t, "traffic.har", `{"log": [`), Options{}, http.COMPRESSION_NONE)
+	assert.ErrorContains(t, err, "invalid HAR file")
+
+	_, err = ToHTTPRequests(internal.WriteTempFile(t, "traffic.har", `{"log": {"entries": [{"request": {"method": "FETCH", "url": "https://example.com/"}}]}}`), Options{}, http.COMPRESSION_NONE)
+	assert.ErrorContains(t, err, "entry 1: invalid request: FETCH /, method FETCH is not supported")
+}
@@ -16,6 +16,7 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"fmt"
 	"io"
@@ -35,8 +36,21 @@ import (
 type Client struct {
 	httpClient *http.Client
 	host       string
+	timeout    time.Duration
 }
 
+// RequestOptions holds settings that apply to a single request.
+type RequestOptions struct {
+	// Timeout overrides the timeout of the client if greater than zero.
+	Timeout time.Duration
+	// CaptureBody returns the body of the response, e.g. to check it against the expectations of the request.
+	// Otherwise the body is discarded.
+	CaptureBody bool
+}
+
+// maxCapturedBodyBytes caps the size of a captured response body, anything beyond it is discarded.
+const maxCapturedBodyBytes = 10 << 20
+
 type ProtocolType string
 
 const (
@@ -45,44 +59,97 @@ const (
 	H2C   ProtocolType = "h2c"
 )
 
+// UnixSocketPrefix marks a host which is the path of a Unix domain socket, e.g. unix:///var/run/app.sock.
+const UnixSocketPrefix = "unix://"
+
 // NewClient creates a new HTTP client for a given host.
-// If insecure is true, the client will not verify the server's certificate chain and host name.
-func NewClient(host string, insecure bool, timeoutMilliseconds int, protocol ProtocolType) Client {
-	client := &http.Client{
-		Timeout: time.Duration(timeoutMilliseconds) * time.Millisecond,
+// The TLS configuration holds the certificates used to verify the server and to authenticate the client, if any.
+// A nil configuration uses the system certificate authorities.
+// If the host is a Unix socket, requests are sent over the socket. They use TLS only with the h2 protocol, which requires it.
+func NewClient(host string, tlsConfig *tls.Config, timeoutMilliseconds int, protocol ProtocolType) Client {
+	// the timeout is set per request, see SendRequestWithOptions
+	client := &http.Client{}
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+
+	var dialer net.Dialer
+	dial := dialer.DialContext
+	socket, isSocket := strings.CutPrefix(host, UnixSocketPrefix)
+	if isSocket {
+		dial = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+		// the host of the URLs is only used for the Host header
+		host = "http://localhost"
+		if protocol == HTTP2 {
+			host = "https://localhost"
+		}
 	}
 
 	switch protocol {
 	case HTTP2:
-		client.Transport = &http2.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+		transport := &http2.Transport{
+			TLSClientConfig: tlsConfig,
 		}
+		if isSocket {
+			transport.DialTLSContext = func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+				conn, err := dial(ctx, network, addr)
+				if err != nil {
+					return nil, err
+				}
+				tlsConn := tls.Client(conn, cfg)
+				if err := tlsConn.HandshakeContext(ctx); err != nil {
+					conn.Close()
+					return nil, err
+				}
+				return tlsConn, nil
+			}
+		}
+		client.Transport = transport
 	case H2C:
 		client.Transport = &http2.Transport{
 			AllowHTTP: true,
-			DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
-				return net.Dial(network, addr)
+			DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+				return dial(ctx, network, addr)
 			},
 		}
 	default:
 		client.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			TLSClientConfig: tlsConfig,
+			DialContext:     dial,
 		}
 	}
 
-	return Client{httpClient: client, host: strings.TrimRight(host, "/")}
+	return Client{httpClient: client, host: strings.TrimRight(host, "/"), timeout: time.Duration(timeoutMilliseconds) * time.Millisecond}
 }
 
 // SendRequest sends a request to the HTTP server and wraps useful information into a Response object.
 func (c Client) SendRequest(method, path string, headers map[string]string, requestBody *string) response.Response {
+	return c.SendRequestWithOptions(method, path, headers, requestBody, RequestOptions{})
+}
+
+// SendRequestWithOptions sends a request to the HTTP server applying the given options and wraps useful information into a Response object.
+func (c Client) SendRequestWithOptions(method, path string, headers map[string]string, requestBody *string, options RequestOptions) response.Response {
 	const respType = "http"
 	var body io.Reader
 	if requestBody != nil {
 		body = bytes.NewBufferString(*requestBody)
 	}
 	
+	timeout := c.timeout
+	if options.Timeout > 0 {
+		timeout = options.Timeout
+	}
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	url := fmt.Sprintf("%s/%s", c.host, strings.TrimLeft(path, "/"))
-	req, err := http.NewRequest(method, url, body)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		log.Printf("Failed to create request: %s %s: %v", method, url, err)
 		return response.Response{Duration: time.Duration(0), Err: err, Type: respType}
@@ -108,8 +175,14 @@ func (c Client) SendRequest(method, path string, headers map[string]string, requ
 	}
 	defer resp.Body.Close()
 
+	var responseBody []byte
+	if options.CaptureBody {
+		if responseBody, err = io.ReadAll(io.LimitReader(resp.Body, maxCapturedBodyBytes)); err != nil {
+			return response.Response{Duration: endTime.Sub(startTime), Err: err, Type: respType, StatusCode: resp.StatusCode}
+		}
+	}
 	if _, err = io.Copy(ioutil.Discard, resp.Body); err != nil {
 		return response.Response{Duration: endTime.Sub(startTime), Err: err, Type: respType, StatusCode: resp.StatusCode}
 	}
-	return response.Response{Duration: endTime.Sub(startTime), Err: nil, Type: respType, StatusCode: resp.StatusCode}
+	return response.Response{Duration: endTime.Sub(startTime), Err: nil, Type: respType, StatusCode: resp.StatusCode, Headers: resp.Header, Body: responseBody}
 }
@@ -18,15 +18,22 @@ import (
 	"context"
 	"fmt"
 	"mittens/fixture"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var mockServer *http.Server
 
 const WorkingPath = "/path"
+const SlowPath = "/slow"
+const JSONPath = "/json"
 
 var serverUrl string
 
@@ -37,21 +44,21 @@ func TestMain(m *testing.M) {
 }
 
 func TestRequestSuccessHTTP1(t *testing.T) {
-	c := NewClient(serverUrl, false, 10000, HTTP1)
+	c := NewClient(serverUrl, nil, 10000, HTTP1)
 	reqBody := ""
 	resp := c.SendRequest("GET", WorkingPath, make(map[string]string), &reqBody)
 	assert.Nil(t, resp.Err)
 }
 
 func TestRequestSuccessH2C(t *testing.T) {
-	c := NewClient(serverUrl, false, 10000, H2C)
+	c := NewClient(serverUrl, nil, 10000, H2C)
 	reqBody := ""
 	resp := c.SendRequest("GET", WorkingPath, make(map[string]string), &reqBody)
 	assert.Nil(t, resp.Err)
 }
 
 func TestHttpErrorHTTP1(t *testing.T) {
-	c := NewClient(serverUrl, false, 10000, HTTP1)
+	c := NewClient(serverUrl, nil, 10000, HTTP1)
 	reqBody := ""
 	resp := c.SendRequest("GET", "/", make(map[string]string), &reqBody)
 	assert.Nil(t, resp.Err)
@@ -59,7 +66,7 @@ func TestHttpErrorHTTP1(t *testing.T) {
 }
 
 func TestHttpErrorH2C(t *testing.T) {
-	c := NewClient(serverUrl, false, 10000, H2C)
+	c := NewClient(serverUrl, nil, 10000, H2C)
 	reqBody := ""
 	resp := c.SendRequest("GET", "/", make(map[string]string), &reqBody)
 	assert.Nil(t, resp.Err)
@@ -67,19 +74,105 @@ func TestHttpErrorH2C(t *testing.T) {
 }
 
 func TestConnectionErrorHTTP1(t *testing.T) {
-	c := NewClient("http://localhost:9999", false, 10000, HTTP1)
+	c := NewClient("http://localhost:9999", nil, 10000, HTTP1)
 	reqBody := ""
 	resp := c.SendRequest("GET", "/potato", make(map[string]string), &reqBody)
 	assert.NotNil(t, resp.Err)
 }
 
 func TestConnectionErrorH2C(t *testing.T) {
-	c := NewClient("http://localhost:9999", false, 10000, H2C)
+	c := NewClient("http://localhost:9999", nil, 10000, H2C)
 	reqBody := ""
 	resp := c.SendRequest("GET", "/potato", make(map[string]string), &reqBody)
 	assert.NotNil(t, resp.Err)
 }
 
+func TestRequestTimeoutOverride(t *testing.T) {
+	c := NewClient(serverUrl, nil, 10000, HTTP1)
+	resp := c.SendRequestWithOptions("GET", SlowPath, make(map[string]string), nil, RequestOptions{Timeout: 10 * time.Millisecond})
+	assert.NotNil(t, resp.Err)
+
+	resp = c.SendRequestWithOptions("GET", SlowPath, make(map[string]string), nil, RequestOptions{})
+	assert.Nil(t, resp.Err)
+}
+
+func TestRequestCaptureBody(t *testing.T) {
+	c := NewClient(serverUrl, nil, 10000, HTTP1)
+	resp := c.SendRequestWithOptions("GET", JSONPath, make(map[string]string), nil, RequestOptions{CaptureBody: true})
+	assert.Nil(t, resp.Err)
+	assert.Equal(t, `{"status":"UP"}`, string(resp.Body))
+	assert.Equal(t, "application/json", resp.Headers.Get("Content-Type"))
+
+	resp = c.SendRequestWithOptions("GET", JSONPath, make(map[string]string), nil, RequestOptions{})
+	assert.Nil(t, resp.Err)
+	assert.Nil(t, resp.Body)
+}
+
+func TestRequestMutualTLS(t *testing.T) {
+	certificates := fixture.WriteCertificates(t.TempDir())
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = certificates.ServerTLSConfig()
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	for _, protocol := range []ProtocolType{HTTP1, HTTP2} {
+		c := NewClient(server.URL, certificates.ClientTLSConfig(), 10000, protocol)
+		resp := c.SendRequest("GET", WorkingPath, make(map[string]string), nil)
+		assert.Nil(t, resp.Err, protocol)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, protocol)
+	}
+
+	// without a client certificate the handshake fails
+	config := certificates.ClientTLSConfig()
+	config.Certificates = nil
+	resp := NewClient(server.URL, config, 10000, HTTP1).SendRequest("GET", WorkingPath, make(map[string]string), nil)
+	assert.NotNil(t, resp.Err)
+
+	// without the server name override the certificate does not match localhost
+	config = certificates.ClientTLSConfig()
+	config.ServerName = ""
+	resp = NewClient(server.URL, config, 10000, HTTP1).SendRequest("GET", WorkingPath, make(map[string]string), nil)
+	assert.NotNil(t, resp.Err)
+}
+
+func TestRequestUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "app.sock")
+	server := fixture.StartHttpTargetTestServerOnSocket([]fixture.PathResponseHandler{{Path: WorkingPath, PathHandlerFunc: func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(r.Proto))
+	}}}, socket)
+	t.Cleanup(func() { server.Close() })
+
+	for protocol, expected := range map[ProtocolType]string{HTTP1: "HTTP/1.1", H2C: "HTTP/2.0"} {
+		c := NewClient(UnixSocketPrefix+socket, nil, 10000, protocol)
+		resp := c.SendRequestWithOptions("GET", WorkingPath, make(map[string]string), nil, RequestOptions{CaptureBody: true})
+		assert.Nil(t, resp.Err, protocol)
+		assert.Equal(t, expected, string(resp.Body), protocol)
+	}
+}
+
+func TestRequestUnixSocketHTTP2(t *testing.T) {
+	dir := t.TempDir()
+	certificates := fixture.WriteCertificates(dir)
+	listener, err := net.Listen("unix", filepath.Join(dir, "app.sock"))
+	require.NoError(t, err)
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.Write([]byte(r.Proto))
+		}),
+		TLSConfig: certificates.ServerTLSConfig(),
+	}
+	go server.ServeTLS(listener, "", "")
+	t.Cleanup(func() { server.Close() })
+
+	c := NewClient(UnixSocketPrefix+listener.Addr().String(), certificates.ClientTLSConfig(), 10000, HTTP2)
+	resp := c.SendRequestWithOptions("GET", WorkingPath, make(map[string]string), nil, RequestOptions{CaptureBody: true})
+	assert.Nil(t, resp.Err)
+	assert.Equal(t, "HTTP/2.0", string(resp.Body))
+}
+
 func setup() {
 	pathResponseHandlerFunc := func(rw http.ResponseWriter, r *http.Request) {
 		if want, have := "/path", r.URL.Path; want != have {
@@ -87,8 +180,15 @@ func setup() {
 		}
 	}
 	pathHandler := fixture.PathResponseHandler{Path: WorkingPath, PathHandlerFunc: pathResponseHandlerFunc}
+	slowHandler := fixture.PathResponseHandler{Path: SlowPath, PathHandlerFunc: func(rw http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}}
+	jsonHandler := fixture.PathResponseHandler{Path: JSONPath, PathHandlerFunc: func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write([]byte(`{"status":"UP"}`))
+	}}
 	var mockServerPort int
-	mockServer, mockServerPort = fixture.StartHttpTargetTestServer([]fixture.PathResponseHandler{pathHandler})
+	mockServer, mockServerPort = fixture.StartHttpTargetTestServer([]fixture.PathResponseHandler{pathHandler, slowHandler, jsonHandler})
 
 	serverUrl = "http://localhost:" + fmt.Sprint(mockServerPort)
 }
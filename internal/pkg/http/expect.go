@@ -0,0 +1,161 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mittens/internal/pkg/jsonpath"
+	"mittens/internal/pkg/response"
+	"net/textproto"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Expectation describes the response expected for a request. A response which does not meet it counts as a failure.
+type Expectation struct {
+	// Status holds the allowed status codes. If empty, any 2xx status is allowed.
+	Status []int `json:"status"`
+	// Headers holds the required headers. The value of a header must contain the given value, if any.
+	Headers map[string]string `json:"headers"`
+	// BodyContains is a text the body must contain.
+	BodyContains string `json:"body-contains"`
+	// BodyMatches is a regular expression the body must match.
+	BodyMatches string `json:"body-matches"`
+	// JSONPath maps JSONPath expressions, e.g. $.status, to the value they must select in the JSON body.
+	JSONPath map[string]interface{} `json:"json-path"`
+
+	bodyRegexp *regexp.Regexp
+	headers    []string
+	paths      []expectedValue
+}
+
+// expectedValue is a value expected at a JSONPath.
+type expectedValue struct {
+	path  jsonpath.Path
+	value interface{}
+}
+
+// compile validates the expectation and prepares its regular expression and JSONPath expressions.
+func (e *Expectation) compile() error {
+	for _, status := range e.Status {
+		if status < 100 || status > 599 {
+			return fmt.Errorf("invalid expected status %d", status)
+		}
+	}
+	if e.BodyMatches != "" {
+		re, err := regexp.Compile(e.BodyMatches)
+		if err != nil {
+			return fmt.Errorf("invalid body-matches: %v", err)
+		}
+		e.bodyRegexp = re
+	}
+
+	e.headers = make([]string, 0, len(e.Headers))
+	for name := range e.Headers {
+		e.headers = append(e.headers, name)
+	}
+	// check the headers and paths in a stable order so that the same failure is always reported
+	sort.Strings(e.headers)
+
+	expressions := make([]string, 0, len(e.JSONPath))
+	for expression := range e.JSONPath {
+		expressions = append(expressions, expression)
+	}
+	sort.Strings(expressions)
+	for _, expression := range expressions {
+		path, err := jsonpath.Compile(expression)
+		if err != nil {
+			return err
+		}
+		e.paths = append(e.paths, expectedValue{path: path, value: e.JSONPath[expression]})
+	}
+	return nil
+}
+
+// NeedsBody returns true if the body of the response must be read to check the expectation.
+func (e *Expectation) NeedsBody() bool {
+	return e != nil && (e.BodyContains != "" || e.bodyRegexp != nil || len(e.paths) > 0)
+}
+
+// Check returns an error describing the first expectation the response does not meet.
+// A nil expectation only checks that the response has a 2xx status.
+func (e *Expectation) Check(resp response.Response) error {
+	if e == nil || len(e.Status) == 0 {
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+	} else if !containsStatus(e.Status, resp.StatusCode) {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if e == nil {
+		return nil
+	}
+
+	for _, name := range e.headers {
+		value := e.Headers[name]
+		actual, ok := resp.Headers[textproto.CanonicalMIMEHeaderKey(name)]
+		if !ok {
+			return fmt.Errorf("missing header %s", name)
+		}
+		if value != "" && !strings.Contains(strings.Join(actual, ", "), value) {
+			return fmt.Errorf("header %s does not contain %q", name, value)
+		}
+	}
+	if e.BodyContains != "" && !bytes.Contains(resp.Body, []byte(e.BodyContains)) {
+		return fmt.Errorf("body does not contain %q", e.BodyContains)
+	}
+	if e.bodyRegexp != nil && !e.bodyRegexp.Match(resp.Body) {
+		return fmt.Errorf("body does not match %q", e.BodyMatches)
+	}
+	if len(e.paths) == 0 {
+		return nil
+	}
+
+	document, err := jsonpath.Decode(resp.Body)
+	if err != nil {
+		return fmt.Errorf("body is not JSON")
+	}
+	for _, expected := range e.paths {
+		actual, err := expected.path.Select(document)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(actual, expected.value) {
+			return fmt.Errorf("%s is %s, expected %s", expected.path, toJSON(actual), toJSON(expected.value))
+		}
+	}
+	return nil
+}
+
+func containsStatus(statuses []int, status int) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func toJSON(value interface{}) string {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(content)
+}
@@ -0,0 +1,36 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package http
+
+import "strings"
+
+// RequestURI returns the path and query of a recorded URL, e.g. from a HAR file or an access log. Absolute URLs are reduced to
+// their path and query, fragments are dropped and the path always starts with a slash. Unlike url.URL.RequestURI, nothing is
+// escaped, so that placeholders such as {$range|min=1,max=5} can still be interpolated.
+func RequestURI(rawURL string) string {
+	rawURL, _, _ = strings.Cut(rawURL, "#")
+	if _, rest, ok := strings.Cut(rawURL, "://"); ok {
+		rawURL = rest
+		if i := strings.IndexAny(rawURL, "/?"); i >= 0 {
+			rawURL = rawURL[i:]
+		} else {
+			rawURL = ""
+		}
+	}
+	if !strings.HasPrefix(rawURL, "/") {
+		rawURL = "/" + rawURL
+	}
+	return rawURL
+}
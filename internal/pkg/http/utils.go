@@ -18,9 +18,13 @@ import (
 	"bytes"
 	"compress/flate"
 	"compress/gzip"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mittens/internal/pkg/placeholders"
+	"mittens/internal/pkg/selection"
+	"net/textproto"
 	"strings"
 
 	"github.com/andybalholm/brotli"
@@ -28,10 +32,56 @@ import (
 
 // Request represents an HTTP request.
 type Request struct {
-	Method  string
-	Headers map[string]string
-	Path    string
-	Body    *string
+	Name                string
+	Method              string
+	Headers             map[string]string
+	Path                string
+	Body                *string
+	TimeoutMilliseconds int
+	// Weight is the relative frequency with which the request is picked.
+	Weight int
+	// Count is the exact number of times the request is sent. Zero means that the request is picked according to its weight.
+	Count int
+	// Expect describes the expected response. If nil, any 2xx response is a success.
+	Expect *Expectation
+	// Data picks the rows used by the data placeholders of the request, e.g. {$data|hotels.id}, each time it is sent. See WithData.
+	Data DataSource
+	// compression is applied to the body once its data placeholders are set, since the body is otherwise compressed when the request is created.
+	compression CompressionType
+}
+
+// DataSource picks a row of each dataset used by a request, by dataset name. Next returns false once there are no rows left,
+// which Done reports beforehand so that the request is no longer picked.
+type DataSource interface {
+	Next() (map[string]map[string]string, bool)
+	Done() bool
+}
+
+// ErrNoRowsLeft is returned by WithData once the datasets of a request have no rows left.
+var ErrNoRowsLeft = errors.New("no rows left in the datasets of the request")
+
+// jsonRequest is the JSON representation of a request.
+// Unlike the <http-method>:<path>[:body] format it allows setting headers, a timeout, a name, how often the request is sent
+// and the expected response.
+type jsonRequest struct {
+	Name                string            `json:"name"`
+	Method              string            `json:"method"`
+	Path                string            `json:"path"`
+	Body                json.RawMessage   `json:"body"`
+	Headers             map[string]string `json:"headers"`
+	TimeoutMilliseconds int               `json:"timeout-milliseconds"`
+	Weight              int               `json:"weight"`
+	Count               int               `json:"count"`
+	Once                bool              `json:"once"`
+	Expect              *Expectation      `json:"expect"`
+}
+
+// DisplayName returns the name of the request, or its method and path if the request has no name.
+func (r Request) DisplayName() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.Method + " " + r.Path
 }
 
 type CompressionType string
@@ -56,7 +106,12 @@ var allowedHTTPMethods = map[string]interface{}{
 }
 
 // ToHTTPRequest parses an HTTP request which is in a string format and stores it in a struct.
+// The request is either in <http-method>:<path>[:body] format or a JSON object, e.g. {"method": "get", "path": "/ping", "headers": {"Accept": "application/json"}}.
 func ToHTTPRequest(requestString string, compression CompressionType) (Request, error) {
+	if strings.HasPrefix(strings.TrimSpace(requestString), "{") {
+		return toHTTPRequestFromJSON(requestString, compression)
+	}
+
 	parts := strings.SplitN(requestString, ":", 3)
 	if len(parts) < 2 {
 		return Request{}, fmt.Errorf("invalid request flag: %s, expected format <http-method>:<path>[:body]", requestString)
@@ -70,24 +125,151 @@ func ToHTTPRequest(requestString string, compression CompressionType) (Request,
 
 	// <method>:<path>
 	if len(parts) == 2 {
-		path := placeholders.InterpolatePlaceholders(parts[1])
+		return newRequest(Request{Method: method, Path: parts[1], Weight: 1}, compression)
+	}
+	return newRequest(Request{Method: method, Path: parts[1], Body: &parts[2], Weight: 1}, compression)
+}
+
+func toHTTPRequestFromJSON(requestString string, compression CompressionType) (Request, error) {
+	r := jsonRequest{Weight: 1}
+	decoder := json.NewDecoder(strings.NewReader(requestString))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&r); err != nil {
+		return Request{}, fmt.Errorf("invalid request: %s, %v", requestString, err)
+	}
+
+	method := strings.ToUpper(r.Method)
+	if _, ok := allowedHTTPMethods[method]; !ok {
+		return Request{}, fmt.Errorf("invalid request: %s, method %s is not supported", requestString, method)
+	}
+	if r.Path == "" {
+		return Request{}, fmt.Errorf("invalid request: %s, path is required", requestString)
+	}
+	if r.TimeoutMilliseconds < 0 {
+		return Request{}, fmt.Errorf("invalid request: %s, timeout-milliseconds cannot be negative", requestString)
+	}
+	if err := selection.Validate(r.Weight, r.Count, r.Once); err != nil {
+		return Request{}, fmt.Errorf("invalid request: %s, %v", requestString, err)
+	}
+	body, err := placeholders.BodyFromJSON(r.Body)
+	if err != nil {
+		return Request{}, fmt.Errorf("invalid request: %s, %v", requestString, err)
+	}
+	if r.Expect != nil {
+		if err := r.Expect.compile(); err != nil {
+			return Request{}, fmt.Errorf("invalid request: %s, %v", requestString, err)
+		}
+	}
+
+	request := Request{
+		Name:                r.Name,
+		Method:              method,
+		Path:                r.Path,
+		Body:                body,
+		Headers:             r.Headers,
+		TimeoutMilliseconds: r.TimeoutMilliseconds,
+		Weight:              r.Weight,
+		Count:               r.Count,
+		Expect:              r.Expect,
+	}
+	if r.Once {
+		request.Count = 1
+	}
+	return newRequest(request, compression)
+}
 
-		return Request{
-			Method: method,
-			Path:   path,
-			Body:   nil,
-		}, nil
+// NewRequest validates a request built from another source, e.g. a HAR file, and prepares it like the requests of the
+// http-requests flag: the placeholders of its path and body are interpolated and its body is compressed if needed.
+func NewRequest(request Request, compression CompressionType) (Request, error) {
+	request.Method = strings.ToUpper(request.Method)
+	if _, ok := allowedHTTPMethods[request.Method]; !ok {
+		return Request{}, fmt.Errorf("invalid request: %s %s, method %s is not supported", request.Method, request.Path, request.Method)
+	}
+	if request.Weight == 0 {
+		request.Weight = 1
+	}
+	return newRequest(request, compression)
+}
+
+// newRequest interpolates the placeholders in the path and body of a request, reads the body from a file and compresses it if needed.
+func newRequest(request Request, compression CompressionType) (Request, error) {
+	headers := make(map[string]string)
+	for k, v := range request.Headers {
+		headers[textproto.CanonicalMIMEHeaderKey(k)] = v
+	}
+	request.Headers = headers
+	request.Path = placeholders.InterpolatePlaceholders(request.Path)
+	if request.Body == nil {
+		return request, nil
 	}
 
-	path := placeholders.InterpolatePlaceholders(parts[1])
 	// the body of the request can either be inlined, or come from a file
-	rawBody, err := placeholders.GetBodyFromFileOrInlined(parts[2])
+	rawBody, err := placeholders.GetBodyFromFileOrInlined(*request.Body)
 	if err != nil {
-		return Request{}, fmt.Errorf("unable to parse body for request: %s", parts[2])
+		return Request{}, fmt.Errorf("unable to parse body for request: %s", *request.Body)
+	}
+	body := placeholders.InterpolatePlaceholders(*rawBody)
+	if len(placeholders.Data(body)) > 0 {
+		// the body is compressed each time its data placeholders are set
+		request.Body = &body
+		request.compression = compression
+		return request, nil
 	}
-	var body = placeholders.InterpolatePlaceholders(*rawBody)
+	return compress(request, body, compression)
+}
 
+// DataReferences returns the columns used by the data placeholders of the path, header values and body of the request.
+func (r Request) DataReferences() []placeholders.DataReference {
+	references := placeholders.Data(r.Path)
+	for _, v := range r.Headers {
+		references = append(references, placeholders.Data(v)...)
+	}
+	if r.Body != nil {
+		references = append(references, placeholders.Data(*r.Body)...)
+	}
+	return references
+}
+
+// WithData returns a copy of the request whose path, header values and body have their data placeholders,
+// e.g. {$data|hotels.id}, replaced with the values of the next rows of Data, which must be set. The same rows are used
+// across the whole request. The body is then compressed if needed.
+func (r Request) WithData() (Request, error) {
+	rows, ok := r.Data.Next()
+	if !ok {
+		return Request{}, ErrNoRowsLeft
+	}
+	request := r
+	request.Path = placeholders.InterpolateData(r.Path, rows)
+	request.Headers = make(map[string]string, len(r.Headers))
+	for k, v := range r.Headers {
+		request.Headers[k] = placeholders.InterpolateData(v, rows)
+	}
+	if r.Body == nil || len(placeholders.Data(*r.Body)) == 0 {
+		return request, nil
+	}
+	return compress(request, placeholders.InterpolateData(*r.Body, rows), r.compression)
+}
+
+// WithVariables returns a copy of the request whose path, header values and body have their variable placeholders,
+// e.g. {$var|session}, replaced with the values of the variables. The body is then compressed, so the request must
+// have been created without compression.
+func (r Request) WithVariables(variables map[string]string, compression CompressionType) (Request, error) {
+	request := r
+	request.Path = placeholders.InterpolateVariables(r.Path, variables)
+	request.Headers = make(map[string]string, len(r.Headers))
+	for k, v := range r.Headers {
+		request.Headers[k] = placeholders.InterpolateVariables(v, variables)
+	}
+	if r.Body == nil {
+		return request, nil
+	}
+	return compress(request, placeholders.InterpolateVariables(*r.Body, variables), compression)
+}
+
+// compress sets the body of a request, compressed if needed along with the according Content-Encoding header.
+func compress(request Request, body string, compression CompressionType) (Request, error) {
 	var reader io.Reader
+	var err error
 	switch compression {
 	case COMPRESSION_GZIP:
 		reader, err = compressGzip([]byte(body))
@@ -100,14 +282,13 @@ func ToHTTPRequest(requestString string, compression CompressionType) (Request,
 	}
 
 	if err != nil {
-		return Request{}, fmt.Errorf("unable to compress body for request: %s", parts[2])
+		return Request{}, fmt.Errorf("unable to compress body for request: %s", *request.Body)
 	}
 
 	buf := new(bytes.Buffer)
 	buf.ReadFrom(reader)
 	compressedBody := buf.String()
 
-	headers := make(map[string]string)
 	if compression != COMPRESSION_NONE {
 		encoding := ""
 		switch compression {
@@ -118,15 +299,11 @@ func ToHTTPRequest(requestString string, compression CompressionType) (Request,
 		case COMPRESSION_DEFLATE:
 			encoding = "deflate"
 		}
-		headers["Content-Encoding"] = encoding
+		request.Headers["Content-Encoding"] = encoding
 	}
 
-	return Request{
-		Method:  method,
-		Headers: headers,
-		Path:    path,
-		Body:    &compressedBody,
-	}, nil
+	request.Body = &compressedBody
+	return request, nil
 }
 
 func compressGzip(data []byte) (io.Reader, error) {
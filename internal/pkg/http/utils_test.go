@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"mittens/internal/pkg/internal"
+	"mittens/internal/pkg/response"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -143,3 +144,172 @@ func TestHttp_Interpolation(t *testing.T) {
 	assert.True(t, matchPath)
 	assert.True(t, matchBody)
 }
+
+func TestHttp_JSONToHttpRequest(t *testing.T) {
+	requestFlag := `{"name": "search", "method": "post", "path": "/search", "body": "{\"query\": \"mittens\"}", "headers": {"content-type": "application/json"}, "timeout-milliseconds": 200}`
+	request, err := ToHTTPRequest(requestFlag, COMPRESSION_NONE)
+	require.NoError(t, err)
+
+	assert.Equal(t, "search", request.Name)
+	assert.Equal(t, "search", request.DisplayName())
+	assert.Equal(t, http.MethodPost, request.Method)
+	assert.Equal(t, "/search", request.Path)
+	assert.Equal(t, `{"query": "mittens"}`, *request.Body)
+	assert.Equal(t, map[string]string{"Content-Type": "application/json"}, request.Headers)
+	assert.Equal(t, 200, request.TimeoutMilliseconds)
+}
+
+func TestHttp_JSONWithCompressionToHttpRequest(t *testing.T) {
+	requestFlag := `{"method": "post", "path": "/db", "body": "{}", "headers": {"Content-Type": "application/json"}}`
+	request, err := ToHTTPRequest(requestFlag, COMPRESSION_GZIP)
+	require.NoError(t, err)
+
+	assert.Equal(t, "POST /db", request.DisplayName())
+	assert.Equal(t, map[string]string{"Content-Type": "application/json", "Content-Encoding": "gzip"}, request.Headers)
+}
+
+func TestHttp_InvalidJSONToHttpRequest(t *testing.T) {
+	_, err := ToHTTPRequest(`{"method": "get", "path": "/ping", "timeout": 10}`, COMPRESSION_NONE)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown field "timeout"`)
+
+	_, err = ToHTTPRequest(`{"method": "hmm", "path": "/ping"}`, COMPRESSION_NONE)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "method HMM is not supported")
+
+	_, err = ToHTTPRequest(`{"method": "get"}`, COMPRESSION_NONE)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "path is required")
+}
+
+func TestHttp_JSONWeightAndCount(t *testing.T) {
+	request, err := ToHTTPRequest(`{"method": "get", "path": "/search", "weight": 5}`, COMPRESSION_NONE)
+	require.NoError(t, err)
+	assert.Equal(t, 5, request.Weight)
+	assert.Equal(t, 0, request.Count)
+
+	request, err = ToHTTPRequest(`{"method": "post", "path": "/cache", "once": true}`, COMPRESSION_NONE)
+	require.NoError(t, err)
+	assert.Equal(t, 1, request.Count)
+
+	request, err = ToHTTPRequest(`get:/ping`, COMPRESSION_NONE)
+	require.NoError(t, err)
+	assert.Equal(t, 1, request.Weight)
+
+	_, err = ToHTTPRequest(`{"method": "get", "path": "/ping", "count": 2, "once": true}`, COMPRESSION_NONE)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "once and count cannot be used together")
+}
+
+func TestHttp_JSONBodyObject(t *testing.T) {
+	request, err := ToHTTPRequest(`{"method": "post", "path": "/search", "body": {"query": "mittens"}}`, COMPRESSION_NONE)
+	require.NoError(t, err)
+	assert.Equal(t, `{"query": "mittens"}`, *request.Body)
+}
+
+func TestHttp_JSONExpect(t *testing.T) {
+	request, err := ToHTTPRequest(`{"method": "get", "path": "/health", "expect": {"status": [200, 204], "headers": {"content-type": "json"}, "body-contains": "UP", "json-path": {"$.status": "UP", "$.checks[0].healthy": true}}}`, COMPRESSION_NONE)
+	require.NoError(t, err)
+	require.NotNil(t, request.Expect)
+	assert.True(t, request.Expect.NeedsBody())
+
+	headers := http.Header{"Content-Type": []string{"application/json"}}
+	body := []byte(`{"status": "UP", "checks": [{"healthy": true}]}`)
+	assert.NoError(t, request.Expect.Check(response.Response{StatusCode: 204, Headers: headers, Body: body}))
+
+	assert.EqualError(t, request.Expect.Check(response.Response{StatusCode: 201, Headers: headers, Body: body}), "unexpected status 201")
+	assert.EqualError(t, request.Expect.Check(response.Response{StatusCode: 200, Body: body}), "missing header content-type")
+	assert.EqualError(t, request.Expect.Check(response.Response{StatusCode: 200, Headers: headers, Body: []byte(`{"status": "DOWN"}`)}), `body does not contain "UP"`)
+	assert.EqualError(t, request.Expect.Check(response.Response{StatusCode: 200, Headers: headers, Body: []byte(`{"status": "UP", "checks": [{"healthy": false}]}`)}), "$.checks[0].healthy is false, expected true")
+	assert.EqualError(t, request.Expect.Check(response.Response{StatusCode: 200, Headers: headers, Body: []byte(`UP`)}), "body is not JSON")
+}
+
+func TestHttp_JSONExpectHeadersOrder(t *testing.T) {
+	request, err := ToHTTPRequest(`{"method": "get", "path": "/ping", "expect": {"headers": {"x-request-id": "", "content-type": "json", "etag": ""}}}`, COMPRESSION_NONE)
+	require.NoError(t, err)
+
+	for i := 0; i < 20; i++ {
+		assert.EqualError(t, request.Expect.Check(response.Response{StatusCode: 200}), "missing header content-type")
+		headers := http.Header{"Content-Type": []string{"text/plain"}}
+		assert.EqualError(t, request.Expect.Check(response.Response{StatusCode: 200, Headers: headers}), `header content-type does not contain "json"`)
+		headers = http.Header{"Content-Type": []string{"application/json"}}
+		assert.EqualError(t, request.Expect.Check(response.Response{StatusCode: 200, Headers: headers}), "missing header etag")
+	}
+}
+
+func TestHttp_JSONExpectBodyMatches(t *testing.T) {
+	request, err := ToHTTPRequest(`{"method": "get", "path": "/version", "expect": {"body-matches": "^v\\d+"}}`, COMPRESSION_NONE)
+	require.NoError(t, err)
+
+	assert.NoError(t, request.Expect.Check(response.Response{StatusCode: 200, Body: []byte("v12")}))
+	assert.EqualError(t, request.Expect.Check(response.Response{StatusCode: 200, Body: []byte("dev")}), `body does not match "^v\\d+"`)
+	assert.EqualError(t, request.Expect.Check(response.Response{StatusCode: 500, Body: []byte("v12")}), "unexpected status 500")
+}
+
+func TestHttp_NoExpect(t *testing.T) {
+	request, err := ToHTTPRequest(`get:/ping`, COMPRESSION_NONE)
+	require.NoError(t, err)
+
+	assert.Nil(t, request.Expect)
+	assert.False(t, request.Expect.NeedsBody())
+	assert.NoError(t, request.Expect.Check(response.Response{StatusCode: 204}))
+	assert.EqualError(t, request.Expect.Check(response.Response{StatusCode: 404}), "unexpected status 404")
+}
+
+func TestHttp_InvalidJSONExpect(t *testing.T) {
+	_, err := ToHTTPRequest(`{"method": "get", "path": "/ping", "expect": {"status": [42]}}`, COMPRESSION_NONE)
+	assert.ErrorContains(t, err, "invalid expected status 42")
+
+	_, err = ToHTTPRequest(`{"method": "get", "path": "/ping", "expect": {"body-matches": "("}}`, COMPRESSION_NONE)
+	assert.ErrorContains(t, err, "invalid body-matches")
+
+	_, err = ToHTTPRequest(`{"method": "get", "path": "/ping", "expect": {"json-path": {"status": "UP"}}}`, COMPRESSION_NONE)
+	assert.ErrorContains(t, err, "must start with $")
+
+	_, err = ToHTTPRequest(`{"method": "get", "path": "/ping", "expect": {"code": 200}}`, COMPRESSION_NONE)
+	assert.ErrorContains(t, err, `unknown field "code"`)
+}
+
+func TestRequestURI(t *testing.T) {
+	assert.Equal(t, "/hotels/1?lang=en", RequestURI("/hotels/1?lang=en"))
+	assert.Equal(t, "/hotels/1?lang=en", RequestURI("https://example.com:8443/hotels/1?lang=en#reviews"))
+	assert.Equal(t, "/?lang=en", RequestURI("https://example.com?lang=en"))
+	assert.Equal(t, "/", RequestURI("https://example.com"))
+	assert.Equal(t, "/hotels", RequestURI("hotels"))
+	assert.Equal(t, "/search?n={$range|min=1,max=5}", RequestURI("http://example.com/search?n={$range|min=1,max=5}"))
+}
+
+type testDataSource []map[string]map[string]string
+
+func (s *testDataSource) Next() (map[string]map[string]string, bool) {
+	if len(*s) == 0 {
+		return nil, false
+	}
+	rows := (*s)[0]
+	*s = (*s)[1:]
+	return rows, true
+}
+
+func (s *testDataSource) Done() bool {
+	return len(*s) == 0
+}
+
+func TestHttp_WithData(t *testing.T) {
+	request, err := ToHTTPRequest(`{"method": "post", "path": "/hotels/{$data|hotels.id}", "headers": {"Accept-Language": "{$data|hotels.locale}"}, "body": {"id": "{$data|hotels.id}"}}`, COMPRESSION_GZIP)
+	require.NoError(t, err)
+	assert.Equal(t, `{"id": "{$data|hotels.id}"}`, *request.Body, "bodies with data placeholders are compressed once they are set")
+	assert.NotContains(t, request.Headers, "Content-Encoding")
+	assert.Equal(t, 3, len(request.DataReferences()))
+
+	request.Data = &testDataSource{{"hotels": {"id": "42", "locale": "en-GB"}}}
+	withData, err := request.WithData()
+	require.NoError(t, err)
+	assert.Equal(t, "/hotels/42", withData.Path)
+	assert.Equal(t, "en-GB", withData.Headers["Accept-Language"])
+	assert.Equal(t, "gzip", withData.Headers["Content-Encoding"])
+	assert.NotEqual(t, `{"id": "42"}`, *withData.Body)
+	assert.Equal(t, "/hotels/{$data|hotels.id}", request.Path, "the request is not changed")
+
+	_, err = request.WithData()
+	assert.ErrorIs(t, err, ErrNoRowsLeft)
+}
@@ -18,6 +18,8 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"testing"
 )
 
 func CreateTempFile(content string) string {
@@ -36,3 +38,12 @@ func CreateTempFile(content string) string {
 
 	return temporaryFile.Name()
 }
+
+// WriteTempFile writes the content to a file with the given name, in a directory which is removed once the test finishes.
+func WriteTempFile(t testing.TB, name string, content string) string {
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
@@ -2,6 +2,8 @@ package internal
 
 import (
 	"mittens/internal/pkg/probe"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -21,3 +23,12 @@ func TestCreateTempFile(t *testing.T) {
 	// clean up test file
 	probe.DeleteFile(generatedFile)
 }
+
+func TestWriteTempFile(t *testing.T) {
+	file := WriteTempFile(t, "hotels.csv", "id\n42\n")
+
+	assert.Equal(t, "hotels.csv", filepath.Base(file))
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "id\n42\n", string(content))
+}
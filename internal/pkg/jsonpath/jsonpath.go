@@ -0,0 +1,130 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+// Package jsonpath implements the subset of JSONPath needed to select a single value in a JSON document:
+// the root `$`, child names such as `.name` or `['name']` and array indexes such as `[0]`.
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// step selects either a child by name or an array element by index.
+type step struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+// Path is a compiled JSONPath expression.
+type Path struct {
+	expression string
+	steps      []step
+}
+
+// Compile parses a JSONPath expression such as `$.data.items[0].id`.
+func Compile(expression string) (Path, error) {
+	p := Path{expression: expression}
+	rest := strings.TrimSpace(expression)
+	if !strings.HasPrefix(rest, "$") {
+		return p, fmt.Errorf("invalid JSONPath %q: must start with $", expression)
+	}
+	rest = rest[1:]
+
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return p, fmt.Errorf("invalid JSONPath %q: empty name", expression)
+			}
+			p.steps = append(p.steps, step{name: rest[:end]})
+			rest = rest[end:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return p, fmt.Errorf("invalid JSONPath %q: missing ]", expression)
+			}
+			s, err := parseBracket(rest[1:end])
+			if err != nil {
+				return p, fmt.Errorf("invalid JSONPath %q: %v", expression, err)
+			}
+			p.steps = append(p.steps, s)
+			rest = rest[end+1:]
+		default:
+			return p, fmt.Errorf("invalid JSONPath %q: unexpected %q", expression, rest)
+		}
+	}
+	return p, nil
+}
+
+// parseBracket parses the content of brackets, either a quoted name or an index.
+func parseBracket(content string) (step, error) {
+	content = strings.TrimSpace(content)
+	if len(content) >= 2 && (content[0] == '\'' || content[0] == '"') && content[len(content)-1] == content[0] {
+		return step{name: content[1 : len(content)-1]}, nil
+	}
+	index, err := strconv.Atoi(content)
+	if err != nil || index < 0 {
+		return step{}, fmt.Errorf("%q is neither a quoted name nor an index", content)
+	}
+	return step{index: index, isIndex: true}, nil
+}
+
+// String returns the expression the path was compiled from.
+func (p Path) String() string {
+	return p.expression
+}
+
+// Select returns the value selected by the path in a document decoded with encoding/json.
+// It returns an error if the value does not exist.
+func (p Path) Select(document interface{}) (interface{}, error) {
+	current := document
+	for _, s := range p.steps {
+		if s.isIndex {
+			array, ok := current.([]interface{})
+			if !ok || s.index >= len(array) {
+				return nil, fmt.Errorf("%s: no element at index %d", p.expression, s.index)
+			}
+			current = array[s.index]
+			continue
+		}
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: no field %q", p.expression, s.name)
+		}
+		value, ok := object[s.name]
+		if !ok {
+			return nil, fmt.Errorf("%s: no field %q", p.expression, s.name)
+		}
+		current = value
+	}
+	return current, nil
+}
+
+// Decode decodes a JSON document so that values can be selected from it.
+func Decode(data []byte) (interface{}, error) {
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	return document, nil
+}
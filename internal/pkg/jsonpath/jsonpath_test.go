@@ -0,0 +1,68 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const document = `{"status": "UP", "data": {"items": [{"id": 1}, {"id": 2, "first name": "mittens"}]}}`
+
+func TestSelect(t *testing.T) {
+	doc, err := Decode([]byte(document))
+	require.NoError(t, err)
+
+	tests := map[string]interface{}{
+		"$":                                doc,
+		"$.status":                         "UP",
+		"$.data.items[1].id":               float64(2),
+		"$['data'].items[1]['first name']": "mittens",
+		`$.data["items"][0]`:               map[string]interface{}{"id": float64(1)},
+	}
+	for expression, expected := range tests {
+		path, err := Compile(expression)
+		require.NoError(t, err, expression)
+		value, err := path.Select(doc)
+		require.NoError(t, err, expression)
+		assert.Equal(t, expected, value, expression)
+	}
+}
+
+func TestSelect_Missing(t *testing.T) {
+	doc, err := Decode([]byte(document))
+	require.NoError(t, err)
+
+	_, err = mustCompile(t, "$.data.items[5]").Select(doc)
+	assert.EqualError(t, err, "$.data.items[5]: no element at index 5")
+
+	_, err = mustCompile(t, "$.status.code").Select(doc)
+	assert.EqualError(t, err, `$.status.code: no field "code"`)
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	for _, expression := range []string{"status", "$.", "$[0", "$[-1]", "$[abc]", "$status"} {
+		_, err := Compile(expression)
+		assert.Error(t, err, expression)
+	}
+}
+
+func mustCompile(t *testing.T, expression string) Path {
+	path, err := Compile(expression)
+	require.NoError(t, err)
+	return path
+}
@@ -0,0 +1,145 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package metrics
+
+import (
+	"mittens/internal/pkg/stats"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Warmup phases, in the order in which they happen.
+const (
+	WAITING = "waiting"
+	WARMING = "warming"
+	DONE    = "done"
+)
+
+var phases = []string{WAITING, WARMING, DONE}
+
+// Outcomes of the warmup requests which got a response.
+const (
+	SUCCESS = "success"
+	FAILURE = "failure"
+)
+
+// Metrics exposes the progress of the warmup in the Prometheus format.
+// A nil Metrics ignores every update, which allows callers to use it whether metrics are enabled or not.
+type Metrics struct {
+	registry      *prometheus.Registry
+	requests      *prometheus.CounterVec
+	errors        *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+	readinessWait *prometheus.GaugeVec
+	phase         *prometheus.GaugeVec
+}
+
+// New creates the warmup metrics in their own registry, along with the standard Go and process metrics.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mittens_requests_total",
+			Help: "Number of warmup requests which got a response, by target, protocol, endpoint, status code and outcome: success, or failure if the response did not meet the expectations of the request.",
+		}, []string{"target", "protocol", "endpoint", "status", "outcome"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mittens_request_errors_total",
+			Help: "Number of warmup requests which did not get a response, e.g. because of a connection error or a timeout.",
+		}, []string{"target", "protocol", "endpoint"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mittens_request_duration_seconds",
+			Help:    "Latency of the warmup requests which got a response.",
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 15),
+		}, []string{"target", "protocol", "endpoint"}),
+		readinessWait: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mittens_target_readiness_wait_seconds",
+			Help: "Time taken by the target to become ready, by target.",
+		}, []string{"target"}),
+		phase: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mittens_warmup_phase",
+			Help: "Current phase of the warmup of each target: waiting for the target to become ready, warming it up or done. The current phase is set to 1. With several targets, the empty target holds the phase of the whole warmup.",
+		}, []string{"target", "phase"}),
+	}
+
+	m.registry.MustRegister(
+		m.requests, m.errors, m.duration, m.readinessWait, m.phase,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	m.SetPhase("", WAITING)
+	return m
+}
+
+// Observe updates the request metrics with the result of a warmup request.
+func (m *Metrics) Observe(result stats.Result) {
+	if m == nil {
+		return
+	}
+	if result.Err != nil {
+		m.errors.WithLabelValues(result.Target, result.Protocol, result.Endpoint).Inc()
+		return
+	}
+	m.requests.WithLabelValues(result.Target, result.Protocol, result.Endpoint, status(result), outcome(result)).Inc()
+	m.duration.WithLabelValues(result.Target, result.Protocol, result.Endpoint).Observe(result.Duration.Seconds())
+}
+
+// SetReadinessWait sets the time taken by the target to become ready. The target is empty when a single target is warmed up.
+func (m *Metrics) SetReadinessWait(target string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.readinessWait.WithLabelValues(target).Set(d.Seconds())
+}
+
+// SetPhase sets the current phase of the warmup of the target. The target is empty when a single target is warmed up,
+// or for the phase of the whole warmup when several targets are.
+func (m *Metrics) SetPhase(target string, phase string) {
+	if m == nil {
+		return
+	}
+	for _, p := range phases {
+		value := 0.0
+		if p == phase {
+			value = 1
+		}
+		m.phase.WithLabelValues(target, p).Set(value)
+	}
+}
+
+// Handler returns the handler that serves the metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// outcome returns whether the request succeeded, in the same way as the summary: a response which does not meet the expectations
+// of its request is a failure whatever its status, and an expected status is a success.
+func outcome(result stats.Result) string {
+	if result.Success() {
+		return SUCCESS
+	}
+	return FAILURE
+}
+
+func status(result stats.Result) string {
+	if result.Protocol == stats.GRPC {
+		return result.GrpcCode.String()
+	}
+	return strconv.Itoa(result.StatusCode)
+}
@@ -0,0 +1,77 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package metrics
+
+import (
+	"errors"
+	"mittens/internal/pkg/stats"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	recorder := stats.NewRecorder(0, m)
+	recorder.Record(stats.Result{Endpoint: "GET /ping", Protocol: stats.HTTP, StatusCode: 200, Duration: 3 * time.Millisecond})
+	recorder.Record(stats.Result{Endpoint: "GET /ping", Protocol: stats.HTTP, StatusCode: 200, Duration: 5 * time.Millisecond})
+	recorder.Record(stats.Result{Endpoint: "health/ping", Protocol: stats.GRPC, GrpcCode: codes.Unavailable})
+	recorder.Record(stats.Result{Endpoint: "GET /ping", Protocol: stats.HTTP, Err: errors.New("timeout")})
+	recorder.Record(stats.Result{Target: "cache", Endpoint: "GET /ping", Protocol: stats.HTTP, StatusCode: 200, Duration: time.Millisecond})
+	recorder.Record(stats.Result{Endpoint: "GET /health", Protocol: stats.HTTP, StatusCode: 200, Checked: true, Failure: errors.New(`body does not contain "UP"`)})
+	recorder.Record(stats.Result{Endpoint: "GET /missing", Protocol: stats.HTTP, StatusCode: 404, Checked: true})
+	m.SetReadinessWait("", 1500*time.Millisecond)
+	m.SetReadinessWait("cache", 2*time.Second)
+	m.SetPhase("", WARMING)
+	m.SetPhase("cache", DONE)
+
+	body := scrape(t, m)
+
+	assert.Contains(t, body, `mittens_requests_total{endpoint="GET /ping",outcome="success",protocol="http",status="200",target=""} 2`)
+	assert.Contains(t, body, `mittens_requests_total{endpoint="GET /ping",outcome="success",protocol="http",status="200",target="cache"} 1`)
+	assert.Contains(t, body, `mittens_requests_total{endpoint="health/ping",outcome="failure",protocol="grpc",status="Unavailable",target=""} 1`)
+	assert.Contains(t, body, `mittens_requests_total{endpoint="GET /health",outcome="failure",protocol="http",status="200",target=""} 1`, "a response which does not meet its expectations is a failure")
+	assert.Contains(t, body, `mittens_requests_total{endpoint="GET /missing",outcome="success",protocol="http",status="404",target=""} 1`, "an expected status is a success")
+	assert.Contains(t, body, `mittens_request_errors_total{endpoint="GET /ping",protocol="http",target=""} 1`)
+	assert.Contains(t, body, `mittens_request_duration_seconds_count{endpoint="GET /ping",protocol="http",target=""} 2`)
+	assert.Contains(t, body, `mittens_target_readiness_wait_seconds{target=""} 1.5`)
+	assert.Contains(t, body, `mittens_target_readiness_wait_seconds{target="cache"} 2`)
+	assert.Contains(t, body, `mittens_warmup_phase{phase="waiting",target=""} 0`)
+	assert.Contains(t, body, `mittens_warmup_phase{phase="warming",target=""} 1`)
+	assert.Contains(t, body, `mittens_warmup_phase{phase="done",target="cache"} 1`)
+	assert.Contains(t, body, `mittens_warmup_phase{phase="warming",target="cache"} 0`)
+	assert.Contains(t, body, `go_goroutines`)
+}
+
+func TestMetrics_Nil(t *testing.T) {
+	var m *Metrics
+
+	assert.NotPanics(t, func() {
+		m.Observe(stats.Result{Endpoint: "GET /ping", Protocol: stats.HTTP, StatusCode: 200})
+		m.SetReadinessWait("", time.Second)
+		m.SetPhase("", DONE)
+	})
+}
+
+func scrape(t *testing.T, m *Metrics) string {
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	return recorder.Body.String()
+}
@@ -0,0 +1,349 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package openapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// document holds the parts of an OpenAPI 3 document which describe the requests.
+type document struct {
+	OpenAPI string `yaml:"openapi"`
+	Servers []struct {
+		URL       string `yaml:"url"`
+		Variables map[string]struct {
+			Default string `yaml:"default"`
+		} `yaml:"variables"`
+	} `yaml:"servers"`
+	Paths      map[string]pathItem `yaml:"paths"`
+	Components struct {
+		Schemas       map[string]*schema      `yaml:"schemas"`
+		Parameters    map[string]*parameter   `yaml:"parameters"`
+		RequestBodies map[string]*requestBody `yaml:"requestBodies"`
+		Examples      map[string]*example     `yaml:"examples"`
+	} `yaml:"components"`
+}
+
+type pathItem struct {
+	Parameters []*parameter `yaml:"parameters"`
+	Get        *operation   `yaml:"get"`
+	Head       *operation   `yaml:"head"`
+	Options    *operation   `yaml:"options"`
+	Post       *operation   `yaml:"post"`
+	Put        *operation   `yaml:"put"`
+	Patch      *operation   `yaml:"patch"`
+	Delete     *operation   `yaml:"delete"`
+	Trace      *operation   `yaml:"trace"`
+}
+
+func (p pathItem) operation(method string) *operation {
+	switch method {
+	case "get":
+		return p.Get
+	case "head":
+		return p.Head
+	case "options":
+		return p.Options
+	case "post":
+		return p.Post
+	case "put":
+		return p.Put
+	case "patch":
+		return p.Patch
+	case "delete":
+		return p.Delete
+	case "trace":
+		return p.Trace
+	}
+	return nil
+}
+
+type operation struct {
+	OperationID string       `yaml:"operationId"`
+	Tags        []string     `yaml:"tags"`
+	Parameters  []*parameter `yaml:"parameters"`
+	RequestBody *requestBody `yaml:"requestBody"`
+}
+
+type parameter struct {
+	Ref      string              `yaml:"$ref"`
+	Name     string              `yaml:"name"`
+	In       string              `yaml:"in"`
+	Required bool                `yaml:"required"`
+	Schema   *schema             `yaml:"schema"`
+	Example  interface{}         `yaml:"example"`
+	Examples map[string]*example `yaml:"examples"`
+}
+
+func (p *parameter) hasExample() bool {
+	return p.Example != nil || len(p.Examples) > 0 || (p.Schema != nil && p.Schema.Example != nil)
+}
+
+type requestBody struct {
+	Ref     string               `yaml:"$ref"`
+	Content map[string]mediaType `yaml:"content"`
+}
+
+type mediaType struct {
+	Schema   *schema             `yaml:"schema"`
+	Example  interface{}         `yaml:"example"`
+	Examples map[string]*example `yaml:"examples"`
+}
+
+// example returns the example of the media type, if it has any.
+func (m mediaType) example(d *document) (interface{}, bool, error) {
+	if m.Example != nil {
+		return m.Example, true, nil
+	}
+	return d.firstExample(m.Examples)
+}
+
+type example struct {
+	Ref   string      `yaml:"$ref"`
+	Value interface{} `yaml:"value"`
+}
+
+type schema struct {
+	Ref        string             `yaml:"$ref"`
+	Type       interface{}        `yaml:"type"`
+	Format     string             `yaml:"format"`
+	Example    interface{}        `yaml:"example"`
+	Examples   []interface{}      `yaml:"examples"`
+	Default    interface{}        `yaml:"default"`
+	Enum       []interface{}      `yaml:"enum"`
+	Minimum    *float64           `yaml:"minimum"`
+	Items      *schema            `yaml:"items"`
+	Properties map[string]*schema `yaml:"properties"`
+	ReadOnly   bool               `yaml:"readOnly"`
+	AllOf      []*schema          `yaml:"allOf"`
+	OneOf      []*schema          `yaml:"oneOf"`
+	AnyOf      []*schema          `yaml:"anyOf"`
+}
+
+// typeName returns the type of the schema. OpenAPI 3.1 allows a list of types, e.g. [string, "null"], in which case
+// the first type other than null is used. Schemas without a type are objects if they have properties.
+func (s *schema) typeName() string {
+	switch t := s.Type.(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, item := range t {
+			if name, ok := item.(string); ok && name != "null" {
+				return name
+			}
+		}
+	}
+	if s.Properties != nil {
+		return "object"
+	}
+	if s.Items != nil {
+		return "array"
+	}
+	return ""
+}
+
+// refName returns the name of a component from a local reference, e.g. Pet for #/components/schemas/Pet.
+func refName(ref, kind string) (string, error) {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("unsupported reference %s, only references to %s are supported", ref, prefix+"...")
+	}
+	return strings.TrimPrefix(ref, prefix), nil
+}
+
+func (d *document) resolveParameter(p *parameter) (*parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	name, err := refName(p.Ref, "parameters")
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := d.Components.Parameters[name]
+	if !ok || resolved.Ref != "" {
+		return nil, fmt.Errorf("unresolved reference %s", p.Ref)
+	}
+	return resolved, nil
+}
+
+func (d *document) resolveRequestBody(rb *requestBody) (*requestBody, error) {
+	if rb.Ref == "" {
+		return rb, nil
+	}
+	name, err := refName(rb.Ref, "requestBodies")
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := d.Components.RequestBodies[name]
+	if !ok || resolved.Ref != "" {
+		return nil, fmt.Errorf("unresolved reference %s", rb.Ref)
+	}
+	return resolved, nil
+}
+
+// firstExample returns the value of the first example, sorted by name, if there is any.
+func (d *document) firstExample(examples map[string]*example) (interface{}, bool, error) {
+	if len(examples) == 0 {
+		return nil, false, nil
+	}
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	e := examples[names[0]]
+	if e.Ref != "" {
+		name, err := refName(e.Ref, "examples")
+		if err != nil {
+			return nil, false, err
+		}
+		resolved, ok := d.Components.Examples[name]
+		if !ok {
+			return nil, false, fmt.Errorf("unresolved reference %s", e.Ref)
+		}
+		e = resolved
+	}
+	return e.Value, true, nil
+}
+
+// synthesize returns a value which matches a schema. Examples, defaults and enums are used when the schema has them.
+// refs holds the schemas which are being synthesized, so that recursive schemas, e.g. a tree, stop at the first repetition.
+func (d *document) synthesize(s *schema, refs map[string]bool) (interface{}, error) {
+	if s.Ref != "" {
+		if refs[s.Ref] {
+			return nil, nil
+		}
+		name, err := refName(s.Ref, "schemas")
+		if err != nil {
+			return nil, err
+		}
+		resolved, ok := d.Components.Schemas[name]
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %s", s.Ref)
+		}
+		refs[s.Ref] = true
+		defer delete(refs, s.Ref)
+		return d.synthesize(resolved, refs)
+	}
+
+	switch {
+	case s.Example != nil:
+		return s.Example, nil
+	case len(s.Examples) > 0:
+		return s.Examples[0], nil
+	case s.Default != nil:
+		return s.Default, nil
+	case len(s.Enum) > 0:
+		return s.Enum[0], nil
+	case len(s.AllOf) > 0:
+		return d.synthesizeAllOf(s, refs)
+	case len(s.OneOf) > 0:
+		return d.synthesize(s.OneOf[0], refs)
+	case len(s.AnyOf) > 0:
+		return d.synthesize(s.AnyOf[0], refs)
+	}
+
+	switch s.typeName() {
+	case "object":
+		object := make(map[string]interface{})
+		for name, property := range s.Properties {
+			if property.ReadOnly {
+				continue
+			}
+			value, err := d.synthesize(property, refs)
+			if err != nil {
+				return nil, err
+			}
+			if value != nil {
+				object[name] = value
+			}
+		}
+		return object, nil
+	case "array":
+		if s.Items == nil {
+			return []interface{}{}, nil
+		}
+		item, err := d.synthesize(s.Items, refs)
+		if err != nil || item == nil {
+			return []interface{}{}, err
+		}
+		return []interface{}{item}, nil
+	case "integer":
+		if s.Minimum != nil && *s.Minimum > 1 {
+			return int(*s.Minimum), nil
+		}
+		return 1, nil
+	case "number":
+		if s.Minimum != nil && *s.Minimum > 1 {
+			return *s.Minimum, nil
+		}
+		return 1.5, nil
+	case "boolean":
+		return true, nil
+	default:
+		return synthesizeString(s.Format), nil
+	}
+}
+
+// synthesizeAllOf merges the objects synthesized from each schema of allOf. If they are not objects the last value wins.
+func (d *document) synthesizeAllOf(s *schema, refs map[string]bool) (interface{}, error) {
+	var merged interface{}
+	for _, part := range s.AllOf {
+		value, err := d.synthesize(part, refs)
+		if err != nil {
+			return nil, err
+		}
+		object, isObject := value.(map[string]interface{})
+		mergedObject, mergedIsObject := merged.(map[string]interface{})
+		if isObject && mergedIsObject {
+			for k, v := range object {
+				mergedObject[k] = v
+			}
+			continue
+		}
+		if value != nil {
+			merged = value
+		}
+	}
+	return merged, nil
+}
+
+// synthesizeString returns a string which matches the common formats.
+func synthesizeString(format string) string {
+	switch format {
+	case "date":
+		return "2024-01-01"
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "uuid":
+		return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "email":
+		return "user@example.com"
+	case "uri", "url":
+		return "https://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "127.0.0.1"
+	case "ipv6":
+		return "::1"
+	case "byte":
+		return "c3RyaW5n"
+	}
+	return "string"
+}
@@ -0,0 +1,284 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+// Package openapi generates warmup requests for the operations of an OpenAPI 3 document.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"mittens/internal/pkg/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Options filter the operations for which requests are generated.
+type Options struct {
+	// Tags holds the tags of the operations which are warmed up. If empty, every operation is warmed up.
+	Tags []string
+}
+
+// methods holds the operations of a path item in the order in which their requests are generated.
+var methods = []string{"get", "head", "options", "post", "put", "patch", "delete", "trace"}
+
+// ToHTTPRequests generates a request for each operation of an OpenAPI 3 document, written either in JSON or YAML.
+// Operations are visited in the order of their path, then of their method.
+//
+// The values of the path, query and header parameters, and the JSON body, are taken from their examples if the document
+// has any, or are synthesized from their schema otherwise. Only the required query and header parameters are set, along with
+// the optional ones which have an example. The path of the first server, if any, is prepended to the path of each operation.
+func ToHTTPRequests(path string, options Options, compression http.CompressionType) ([]http.Request, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read OpenAPI file: %v", err)
+	}
+	var d document
+	if err := yaml.Unmarshal(content, &d); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI file %s: %v", path, err)
+	}
+	if !strings.HasPrefix(d.OpenAPI, "3.") {
+		return nil, fmt.Errorf("invalid OpenAPI file %s: unsupported version %q, expected 3.x", path, d.OpenAPI)
+	}
+
+	basePath, err := d.basePath()
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI file %s: %v", path, err)
+	}
+
+	paths := make([]string, 0, len(d.Paths))
+	for p := range d.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var requests []http.Request
+	for _, p := range paths {
+		item := d.Paths[p]
+		for _, method := range methods {
+			op := item.operation(method)
+			if op == nil || !options.allows(op) {
+				continue
+			}
+			request, err := d.toHTTPRequest(basePath, p, method, item.Parameters, op, compression)
+			if err != nil {
+				return nil, fmt.Errorf("invalid OpenAPI file %s: %s %s: %v", path, strings.ToUpper(method), p, err)
+			}
+			requests = append(requests, request)
+		}
+	}
+	return requests, nil
+}
+
+// allows returns true if the operation has one of the tags of the options.
+func (o Options) allows(op *operation) bool {
+	if len(o.Tags) == 0 {
+		return true
+	}
+	for _, tag := range o.Tags {
+		for _, opTag := range op.Tags {
+			if strings.EqualFold(tag, opTag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (d *document) toHTTPRequest(basePath, path, method string, pathParameters []*parameter, op *operation, compression http.CompressionType) (http.Request, error) {
+	parameters, err := d.parameters(pathParameters, op.Parameters)
+	if err != nil {
+		return http.Request{}, err
+	}
+
+	request := http.Request{Name: op.OperationID, Method: method, Headers: make(map[string]string)}
+	query := url.Values{}
+	for _, p := range parameters {
+		if !p.Required && p.In != "path" && !p.hasExample() {
+			continue
+		}
+		value, err := d.parameterValue(p)
+		if err != nil {
+			return http.Request{}, fmt.Errorf("parameter %s: %v", p.Name, err)
+		}
+		values := formatValue(value)
+		switch p.In {
+		case "path":
+			for i := range values {
+				values[i] = url.PathEscape(values[i])
+			}
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", strings.Join(values, ","))
+		case "query":
+			for _, v := range values {
+				query.Add(p.Name, v)
+			}
+		case "header":
+			request.Headers[p.Name] = strings.Join(values, ",")
+		}
+		// cookie parameters are not set, like the cookies of HAR files they belong to a session
+	}
+
+	if start := strings.Index(path, "{"); start >= 0 && strings.Contains(path[start:], "}") {
+		return http.Request{}, fmt.Errorf("path parameter %s is not described", path[start:start+strings.Index(path[start:], "}")+1])
+	}
+
+	request.Path = basePath + path
+	if len(query) > 0 {
+		request.Path += "?" + query.Encode()
+	}
+
+	if op.RequestBody != nil {
+		body, contentType, err := d.body(op.RequestBody)
+		if err != nil {
+			return http.Request{}, fmt.Errorf("request body: %v", err)
+		}
+		if body != nil {
+			request.Body = body
+			request.Headers["Content-Type"] = contentType
+		}
+	}
+	return http.NewRequest(request, compression)
+}
+
+// parameters returns the parameters of an operation, along with the ones of its path item which it does not override.
+func (d *document) parameters(pathParameters, operationParameters []*parameter) ([]*parameter, error) {
+	var parameters []*parameter
+	index := make(map[string]int)
+	for _, p := range append(pathParameters, operationParameters...) {
+		p, err := d.resolveParameter(p)
+		if err != nil {
+			return nil, err
+		}
+		key := p.In + ":" + p.Name
+		if i, ok := index[key]; ok {
+			parameters[i] = p
+			continue
+		}
+		index[key] = len(parameters)
+		parameters = append(parameters, p)
+	}
+	return parameters, nil
+}
+
+// parameterValue returns the example of a parameter, or a value synthesized from its schema.
+func (d *document) parameterValue(p *parameter) (interface{}, error) {
+	if p.Example != nil {
+		return p.Example, nil
+	}
+	if value, ok, err := d.firstExample(p.Examples); err != nil || ok {
+		return value, err
+	}
+	if p.Schema == nil {
+		return "string", nil
+	}
+	return d.synthesize(p.Schema, make(map[string]bool))
+}
+
+// body returns the body of a request and its content type. JSON bodies are generated from their example or schema,
+// other content types are only sent if they have an example. The body is nil if it cannot be generated.
+func (d *document) body(rb *requestBody) (*string, string, error) {
+	rb, err := d.resolveRequestBody(rb)
+	if err != nil {
+		return nil, "", err
+	}
+
+	contentTypes := make([]string, 0, len(rb.Content))
+	for contentType := range rb.Content {
+		contentTypes = append(contentTypes, contentType)
+	}
+	sort.Slice(contentTypes, func(i, j int) bool {
+		// JSON content types come first
+		iJSON, jJSON := isJSON(contentTypes[i]), isJSON(contentTypes[j])
+		if iJSON != jJSON {
+			return iJSON
+		}
+		return contentTypes[i] < contentTypes[j]
+	})
+
+	for _, contentType := range contentTypes {
+		media := rb.Content[contentType]
+		value, ok, err := media.example(d)
+		if err != nil {
+			return nil, "", err
+		}
+		if !ok && isJSON(contentType) && media.Schema != nil {
+			if value, err = d.synthesize(media.Schema, make(map[string]bool)); err != nil {
+				return nil, "", err
+			}
+			ok = true
+		}
+		if !ok {
+			continue
+		}
+		if s, isString := value.(string); isString && !isJSON(contentType) {
+			return &s, contentType, nil
+		}
+		if !isJSON(contentType) {
+			continue
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, "", err
+		}
+		body := string(b)
+		return &body, contentType, nil
+	}
+	return nil, "", nil
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// basePath returns the path of the first server, with its variables set to their default, e.g. /v1 for https://{host}/v1.
+func (d *document) basePath() (string, error) {
+	if len(d.Servers) == 0 {
+		return "", nil
+	}
+	server := d.Servers[0]
+	rawURL := server.URL
+	for name, variable := range server.Variables {
+		rawURL = strings.ReplaceAll(rawURL, "{"+name+"}", variable.Default)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid server %s: %v", server.URL, err)
+	}
+	return strings.TrimSuffix(u.Path, "/"), nil
+}
+
+// formatValue returns the values of a parameter as strings. Arrays have a value for each item, other values are written
+// as JSON unless they are strings.
+func formatValue(value interface{}) []string {
+	if items, ok := value.([]interface{}); ok {
+		var values []string
+		for _, item := range items {
+			values = append(values, formatValue(item)...)
+		}
+		return values
+	}
+	if s, ok := value.(string); ok {
+		return []string{s}
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return []string{fmt.Sprint(value)}
+	}
+	return []string{string(b)}
+}
@@ -0,0 +1,223 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package openapi
+
+import (
+	"mittens/internal/pkg/http"
+	"mittens/internal/pkg/internal"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const specContent = `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: "1"
+servers:
+  - url: https://{region}.example.com/{version}
+    variables:
+      region:
+        default: eu
+      version:
+        default: v1
+paths:
+  /pets/{petId}:
+    parameters:
+      - $ref: "#/components/parameters/PetId"
+    get:
+      operationId: getPet
+      tags: [pets]
+      parameters:
+        - name: fields
+          in: query
+          schema:
+            type: array
+            items:
+              type: string
+          example: [name, age]
+        - name: verbose
+          in: query
+          schema:
+            type: boolean
+        - name: X-Tenant
+          in: header
+          required: true
+          schema:
+            type: string
+            enum: [acme, other]
+    delete:
+      operationId: deletePet
+      tags: [admin]
+  /pets:
+    post:
+      operationId: createPet
+      tags: [pets]
+      requestBody:
+        $ref: "#/components/requestBodies/Pet"
+    get:
+      tags: [pets]
+      parameters:
+        - name: limit
+          in: query
+          required: true
+          schema:
+            type: integer
+            minimum: 10
+        - name: since
+          in: query
+          required: true
+          examples:
+            recent:
+              $ref: "#/components/examples/Since"
+  /uploads:
+    put:
+      tags: [files]
+      requestBody:
+        content:
+          text/plain:
+            example: hello
+          application/octet-stream:
+            schema:
+              type: string
+components:
+  parameters:
+    PetId:
+      name: petId
+      in: path
+      required: true
+      schema:
+        type: string
+        format: uuid
+  requestBodies:
+    Pet:
+      required: true
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Pet"
+  examples:
+    Since:
+      value: "2024-06-01"
+  schemas:
+    Pet:
+      type: object
+      properties:
+        id:
+          type: integer
+          readOnly: true
+        name:
+          type: string
+          example: Rex
+        born:
+          type: string
+          format: date
+        tags:
+          type: array
+          items:
+            type: string
+        owner:
+          $ref: "#/components/schemas/Owner"
+        parent:
+          $ref: "#/components/schemas/Pet"
+    Owner:
+      allOf:
+        - type: object
+          properties:
+            name:
+              type: string
+        - type: object
+          properties:
+            email:
+              type: string
+              format: email
+`
+
+func TestToHTTPRequests(t *testing.T) {
+	requests, err := ToHTTPRequests(internal.WriteTempFile(t, "openapi.yaml", specContent), Options{}, http.COMPRESSION_NONE)
+	require.NoError(t, err)
+	require.Equal(t, 5, len(requests))
+
+	list := requests[0]
+	assert.Equal(t, "GET", list.Method)
+	assert.Equal(t, "GET /v1/pets?limit=10&since=2024-06-01", list.DisplayName(), "operations without an ID are named after their method and path")
+	assert.Nil(t, list.Body)
+
+	create := requests[1]
+	assert.Equal(t, "createPet", create.Name)
+	assert.Equal(t, "POST", create.Method)
+	assert.Equal(t, "/v1/pets", create.Path)
+	assert.Equal(t, "application/json", create.Headers["Content-Type"])
+	require.NotNil(t, create.Body)
+	assert.JSONEq(t, `{"name": "Rex", "born": "2024-01-01", "tags": ["string"], "owner": {"name": "string", "email": "user@example.com"}}`, *create.Body,
+		"read-only and recursive properties are not set")
+
+	get := requests[2]
+	assert.Equal(t, "getPet", get.Name)
+	assert.Equal(t, "/v1/pets/3fa85f64-5717-4562-b3fc-2c963f66afa6?fields=name&fields=age", get.Path, "optional parameters are only set if they have an example")
+	assert.Equal(t, map[string]string{"X-Tenant": "acme"}, get.Headers)
+
+	assert.Equal(t, "DELETE", requests[3].Method)
+	assert.Equal(t, "/v1/pets/3fa85f64-5717-4562-b3fc-2c963f66afa6", requests[3].Path, "parameters of the path item are inherited")
+
+	upload := requests[4]
+	assert.Equal(t, "PUT", upload.Method)
+	require.NotNil(t, upload.Body)
+	assert.Equal(t, "hello", *upload.Body)
+	assert.Equal(t, "text/plain", upload.Headers["Content-Type"])
+}
+
+func TestToHTTPRequests_Tags(t *testing.T) {
+	requests, err := ToHTTPRequests(internal.WriteTempFile(t, "openapi.yaml", specContent), Options{Tags: []string{"admin", "Files"}}, http.COMPRESSION_NONE)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(requests))
+	assert.Equal(t, "deletePet", requests[0].Name)
+	assert.Equal(t, "PUT", requests[1].Method)
+}
+
+func TestToHTTPRequests_JSON(t *testing.T) {
+	spec := `{"openapi": "3.1.0", "paths": {"/items/{id}": {"get": {"parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": ["integer", "null"]}}]}}}}`
+	requests, err := ToHTTPRequests(internal.WriteTempFile(t, "openapi.yaml", spec), Options{}, http.COMPRESSION_GZIP)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(requests))
+	assert.Equal(t, "/items/1", requests[0].Path)
+}
+
+func TestToHTTPRequests_Compression(t *testing.T) {
+	requests, err := ToHTTPRequests(internal.WriteTempFile(t, "openapi.yaml", specContent), Options{Tags: []string{"pets"}}, http.COMPRESSION_GZIP)
+	require.NoError(t, err)
+	assert.Equal(t, "gzip", requests[1].Headers["Content-Encoding"])
+	assert.NotContains(t, requests[0].Headers, "Content-Encoding", "requests without a body are not compressed")
+}
+
+func TestToHTTPRequests_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"unsupported version \"2.0\"":                 `{"swagger": "2.0", "openapi": "2.0"}`,
+		"GET /items/{id}: path parameter {id} is not": `{"openapi": "3.0.0", "paths": {"/items/{id}": {"get": {}}}}`,
+		"unresolved reference #/components/schemas/X": `{"openapi": "3.0.0", "paths": {"/items": {"post": {"requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/X"}}}}}}}}`,
+		"unsupported reference other.yaml#/Item":      `{"openapi": "3.0.0", "paths": {"/items": {"get": {"parameters": [{"$ref": "other.yaml#/Item"}]}}}}`,
+	}
+	for expected, spec := range tests {
+		t.Run(expected, func(t *testing.T) {
+			_, err := ToHTTPRequests(internal.WriteTempFile(t, "openapi.yaml", spec), Options{}, http.COMPRESSION_NONE)
+			assert.ErrorContains(t, err, expected)
+		})
+	}
+
+	_, err := ToHTTPRequests("/does-not-exist.yaml", Options{}, http.COMPRESSION_NONE)
+	assert.ErrorContains(t, err, "unable to read OpenAPI file")
+}
@@ -15,6 +15,7 @@
 package placeholders
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"log"
 	"math/rand"
@@ -30,6 +31,8 @@ const filePrefix = "file:"
 var templatePlaceholderRegex = regexp.MustCompile(`{\$(\w+(?:[\|(?:[\w+-=,]+)]*)}`)
 var templateRangeRegex = regexp.MustCompile(`{\$range\|min=(?P<Min>\d+),max=(?P<Max>\d+)}`)
 var templateElementsRegex = regexp.MustCompile(`{\$random\|(?P<Elements>[,\w-]+)}`)
+var templateVariableRegex = regexp.MustCompile(`{\$var\|([\w-]+)}`)
+var templateDataRegex = regexp.MustCompile(`{\$data\|([\w-]+)\.([\w-]+)}`)
 var templateDatesRegex = regexp.MustCompile(`{\$currentDate(?:\|(?:days(?P<Days>[+-]\d+))*(?:[,]*months(?P<Months>[+-]\d+))*(?:[,]*years(?P<Years>[+-]\d+))*(?:[,]*format=(?P<Format>[yMd|,/-]+))*)*}`)
 
 // dateElements replaces date placeholders with the actual dates. It supports offsets for days, months, and years.
@@ -107,24 +110,86 @@ func rangeElements(source string) string {
 
 // InterpolatePlaceholders scans a string and replaces placeholders with actual values.
 // At the moment this supports; dates, timestamps, random values from a list, and random integers.
+// Other placeholders, such as variables, are left as they are.
 func InterpolatePlaceholders(source string) string {
 
 	return templatePlaceholderRegex.ReplaceAllStringFunc(source, func(templateString string) string {
 
-		if strings.Contains(templateString, "currentDate") {
+		switch placeholderName(templateString) {
+		case "currentDate":
 			return dateElements(templateString)
-		} else if strings.Contains(templateString, "currentTimestamp") {
+		case "currentTimestamp":
 			return timestampElements()
-		} else if strings.Contains(templateString, "random") {
+		case "random":
 			return randomElements(templateString)
-		} else if strings.Contains(templateString, "range") {
+		case "range":
 			return rangeElements(templateString)
-		} else {
-			return source
+		default:
+			return templateString
 		}
 	})
 }
 
+// placeholderName returns the name of a placeholder, e.g. range for {$range|min=1,max=2}.
+func placeholderName(templateString string) string {
+	name := strings.TrimSuffix(strings.TrimPrefix(templateString, "{$"), "}")
+	name, _, _ = strings.Cut(name, "|")
+	return name
+}
+
+// InterpolateVariables replaces variable placeholders, e.g. {$var|session}, with the value of the variable.
+// Placeholders of variables which are not set are left as they are.
+func InterpolateVariables(source string, variables map[string]string) string {
+	if len(variables) == 0 {
+		return source
+	}
+	return templateVariableRegex.ReplaceAllStringFunc(source, func(templateString string) string {
+		if value, ok := variables[templateVariableRegex.FindStringSubmatch(templateString)[1]]; ok {
+			return value
+		}
+		return templateString
+	})
+}
+
+// Variables returns the names of the variables used by the placeholders of a string, in order of appearance.
+func Variables(source string) []string {
+	var names []string
+	for _, match := range templateVariableRegex.FindAllStringSubmatch(source, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// DataReference is a column of a dataset used by a data placeholder, e.g. {$data|hotels.id}.
+type DataReference struct {
+	Dataset string
+	Column  string
+}
+
+// InterpolateData replaces data placeholders, e.g. {$data|hotels.id}, with the value of the column in the row of the dataset.
+// Placeholders of datasets or columns which are not set are left as they are.
+func InterpolateData(source string, rows map[string]map[string]string) string {
+	if len(rows) == 0 {
+		return source
+	}
+	return templateDataRegex.ReplaceAllStringFunc(source, func(templateString string) string {
+		match := templateDataRegex.FindStringSubmatch(templateString)
+		if value, ok := rows[match[1]][match[2]]; ok {
+			return value
+		}
+		return templateString
+	})
+}
+
+// Data returns the columns used by the data placeholders of a string, in order of appearance.
+func Data(source string) []DataReference {
+	var references []DataReference
+	for _, match := range templateDataRegex.FindAllStringSubmatch(source, -1) {
+		references = append(references, DataReference{Dataset: match[1], Column: match[2]})
+	}
+	return references
+}
+
 // GetBodyFromFileOrInlined returns the correct content for the body of a request.
 // the body of the request can either be inlined, or come from a file
 func GetBodyFromFileOrInlined(source string) (*string, error) {
@@ -142,3 +207,18 @@ func GetBodyFromFileOrInlined(source string) (*string, error) {
 		return &source, nil
 	}
 }
+
+// BodyFromJSON returns the body set in a JSON field. The field is either a string, which can use the `file:` notation,
+// or any other JSON value which is then used as is. It returns nil if the field is not set.
+func BodyFromJSON(raw json.RawMessage) (*string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var body string
+	if err := json.Unmarshal(raw, &body); err == nil {
+		return &body, nil
+	}
+	body = string(raw)
+	return &body, nil
+}
@@ -54,6 +54,20 @@ func TestGetBodyFromFileOrInlinedShouldReturnFileContents(t *testing.T) {
 	assert.Equal(t, `{"foo": "bar"}`, *data)
 }
 
+func TestBodyFromJSON(t *testing.T) {
+	body, err := BodyFromJSON([]byte(`"file:/tmp/body.json"`))
+	assert.NoError(t, err)
+	assert.Equal(t, "file:/tmp/body.json", *body)
+
+	body, err = BodyFromJSON([]byte(`{"foo": "bar"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"foo": "bar"}`, *body)
+
+	body, err = BodyFromJSON(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, body)
+}
+
 func TestHttp_DateInterpolation(t *testing.T) {
 	input := `post:/db_{$currentDate}:{"date": "{$currentDate|days+5,months+2,years-1,format=yyyy-MM-dd}"}`
 	output := InterpolatePlaceholders(input)
@@ -107,3 +121,35 @@ func TestHttp_RandomElementInterpolation(t *testing.T) {
 
 	assert.True(t, matchOutput)
 }
+
+func TestHttp_UnknownPlaceholderIsLeftAsIs(t *testing.T) {
+	input := `post:/path_{$range|min=1,max=1}:{"session": "{$var|session}", "other": "{$unknown}"}`
+	output := InterpolatePlaceholders(input)
+
+	assert.Equal(t, `post:/path_1:{"session": "{$var|session}", "other": "{$unknown}"}`, output)
+}
+
+func TestInterpolateVariables(t *testing.T) {
+	input := `/carts/{$var|cart-id}?session={$var|session}&user={$var|user}`
+	output := InterpolateVariables(input, map[string]string{"cart-id": "42", "session": "abc"})
+
+	assert.Equal(t, `/carts/42?session=abc&user={$var|user}`, output)
+}
+
+func TestVariables(t *testing.T) {
+	assert.Equal(t, []string{"session", "cart-id", "session"}, Variables(`{$var|session}/{$var|cart-id}/{$range|min=1,max=2}/{$var|session}`))
+	assert.Empty(t, Variables(`/ping`))
+}
+
+func TestInterpolateData(t *testing.T) {
+	input := `/hotels/{$data|hotels.id}?locale={$data|hotels.locale}&currency={$data|currencies.code}&other={$data|hotels.other}`
+	output := InterpolateData(input, map[string]map[string]string{"hotels": {"id": "42", "locale": "en_GB"}})
+
+	assert.Equal(t, `/hotels/42?locale=en_GB&currency={$data|currencies.code}&other={$data|hotels.other}`, output)
+	assert.Equal(t, input, InterpolatePlaceholders(input), "data placeholders are only interpolated with a row")
+}
+
+func TestData(t *testing.T) {
+	assert.Equal(t, []DataReference{{"hotels", "id"}, {"currency-pairs", "from_code"}}, Data(`{$data|hotels.id}/{$range|min=1,max=2}/{$data|currency-pairs.from_code}`))
+	assert.Empty(t, Data(`/ping`))
+}
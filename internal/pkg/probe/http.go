@@ -0,0 +1,59 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package probe
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// Status holds the liveness and readiness of mittens as exposed by the HTTP probes.
+// It mirrors the probe files: it becomes alive when mittens starts and ready once the warmup finishes.
+type Status struct {
+	alive atomic.Bool
+	ready atomic.Bool
+}
+
+// SetAlive marks mittens as alive.
+func (s *Status) SetAlive() {
+	s.alive.Store(true)
+}
+
+// SetReady marks mittens as ready.
+func (s *Status) SetReady() {
+	s.ready.Store(true)
+}
+
+// LivenessHandler responds with 200 once mittens is alive and 503 before that.
+func (s *Status) LivenessHandler() http.Handler {
+	return probeHandler(&s.alive, "alive")
+}
+
+// ReadinessHandler responds with 200 once mittens is ready and 503 before that.
+func (s *Status) ReadinessHandler() http.Handler {
+	return probeHandler(&s.ready, "ready")
+}
+
+func probeHandler(state *atomic.Bool, name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if !state.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("not " + name))
+			return
+		}
+		_, _ = w.Write([]byte(name))
+	})
+}
@@ -0,0 +1,29 @@
+package probe
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus_Handlers(t *testing.T) {
+	var status Status
+
+	assert.Equal(t, http.StatusServiceUnavailable, serve(status.LivenessHandler()))
+	assert.Equal(t, http.StatusServiceUnavailable, serve(status.ReadinessHandler()))
+
+	status.SetAlive()
+	assert.Equal(t, http.StatusOK, serve(status.LivenessHandler()))
+	assert.Equal(t, http.StatusServiceUnavailable, serve(status.ReadinessHandler()))
+
+	status.SetReady()
+	assert.Equal(t, http.StatusOK, serve(status.ReadinessHandler()))
+}
+
+func serve(handler http.Handler) int {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	return recorder.Code
+}
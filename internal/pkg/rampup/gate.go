@@ -0,0 +1,66 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package rampup
+
+import "sync"
+
+// Gate controls how many of a fixed pool of workers may send requests.
+// Workers are numbered from 0 and worker i may only proceed while more than i workers are open.
+// This allows the number of active workers to go up and down without starting or stopping goroutines.
+type Gate struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	open   int
+	closed bool
+}
+
+// NewGate returns a gate which lets the first open workers through.
+func NewGate(open int) *Gate {
+	g := &Gate{open: open}
+	g.cond = sync.NewCond(&g.mu)
+	return g
+}
+
+// SetOpen changes the number of workers that may proceed.
+func (g *Gate) SetOpen(open int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.open = open
+	g.cond.Broadcast()
+}
+
+// Open returns the number of workers that may proceed.
+func (g *Gate) Open() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.open
+}
+
+// Wait blocks worker i until it may proceed or the gate is closed.
+func (g *Gate) Wait(i int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for !g.closed && i >= g.open {
+		g.cond.Wait()
+	}
+}
+
+// Close lets every worker through. It is used once there are no requests left so that waiting workers can exit.
+func (g *Gate) Close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.closed = true
+	g.cond.Broadcast()
+}
@@ -0,0 +1,166 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package rampup
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	LINEAR      = "linear"
+	STEP        = "step"
+	EXPONENTIAL = "exponential"
+	STAGES      = "stages"
+)
+
+// Stage holds a number of workers for a given duration.
+type Stage struct {
+	Duration    time.Duration
+	Concurrency int
+}
+
+// Profile describes how the number of workers grows from one to its maximum.
+type Profile struct {
+	// Kind is one of linear, step, exponential or stages.
+	Kind string
+	// Concurrency is the number of workers reached at the end of the ramp-up. It is ignored by the stages profile.
+	Concurrency int
+	// Duration is the time taken to reach the concurrency. It is ignored by the stages profile.
+	Duration time.Duration
+	// Steps is the number of increments of the step profile.
+	Steps int
+	// Stages are run in order by the stages profile. The last stage is held until the warmup finishes.
+	Stages []Stage
+}
+
+// Validate checks that the profile can be run.
+func (p Profile) Validate() error {
+	switch p.Kind {
+	case LINEAR, EXPONENTIAL:
+	case STEP:
+		if p.Steps <= 0 {
+			return fmt.Errorf("number of steps must be greater than 0")
+		}
+	case STAGES:
+		if len(p.Stages) == 0 {
+			return fmt.Errorf("stages profile requires at least one stage")
+		}
+		return nil
+	default:
+		return fmt.Errorf("ramp-up profile %q not supported, please use %s, %s, %s or %s", p.Kind, LINEAR, STEP, EXPONENTIAL, STAGES)
+	}
+	if p.Concurrency <= 0 {
+		return fmt.Errorf("concurrency must be greater than 0")
+	}
+	return nil
+}
+
+// MaxWorkers returns the highest number of workers used at any point of the profile.
+func (p Profile) MaxWorkers() int {
+	if p.Kind != STAGES {
+		return p.Concurrency
+	}
+	max := 0
+	for _, stage := range p.Stages {
+		if stage.Concurrency > max {
+			max = stage.Concurrency
+		}
+	}
+	return max
+}
+
+// TotalDuration returns the time after which the number of workers no longer changes.
+func (p Profile) TotalDuration() time.Duration {
+	if p.Kind != STAGES {
+		return p.Duration
+	}
+	// the last stage is held until the end so its duration does not matter
+	var total time.Duration
+	for _, stage := range p.Stages[:len(p.Stages)-1] {
+		total += stage.Duration
+	}
+	return total
+}
+
+// Workers returns the number of workers that should be running once elapsed has passed since the start of the warmup.
+// It is always between 1 and MaxWorkers.
+func (p Profile) Workers(elapsed time.Duration) int {
+	if p.Kind == STAGES {
+		for _, stage := range p.Stages {
+			if elapsed < stage.Duration {
+				return stage.Concurrency
+			}
+			elapsed -= stage.Duration
+		}
+		return p.Stages[len(p.Stages)-1].Concurrency
+	}
+
+	if p.Duration <= 0 || elapsed >= p.Duration {
+		return p.Concurrency
+	}
+	progress := float64(elapsed) / float64(p.Duration)
+
+	var workers int
+	switch p.Kind {
+	case STEP:
+		// the concurrency is split into equal steps, the first one starting straight away
+		step := math.Floor(progress * float64(p.Steps))
+		workers = int(math.Ceil(float64(p.Concurrency) * (step + 1) / float64(p.Steps)))
+	case EXPONENTIAL:
+		// the number of workers doubles at regular intervals until it reaches the concurrency
+		doublings := math.Ceil(math.Log2(float64(p.Concurrency)))
+		workers = int(math.Pow(2, math.Floor(progress*(doublings+1))))
+	default:
+		// one more worker at regular intervals, the same as a step profile with one step per worker
+		workers = 1 + int(progress*float64(p.Concurrency))
+	}
+
+	if workers < 1 {
+		return 1
+	}
+	if workers > p.Concurrency {
+		return p.Concurrency
+	}
+	return workers
+}
+
+// ParseStages parses a comma separated list of stages in the `<duration>:<concurrency>` format, e.g. 10s:2,20s:8,30s:16.
+func ParseStages(value string) ([]Stage, error) {
+	var stages []Stage
+	for _, s := range strings.Split(value, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		duration, concurrency, found := strings.Cut(s, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid stage %q, expected format <duration>:<concurrency>", s)
+		}
+		d, err := time.ParseDuration(duration)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid stage %q, duration must be a positive duration such as 10s", s)
+		}
+		c, err := strconv.Atoi(concurrency)
+		if err != nil || c <= 0 {
+			return nil, fmt.Errorf("invalid stage %q, concurrency must be greater than 0", s)
+		}
+		stages = append(stages, Stage{Duration: d, Concurrency: c})
+	}
+	return stages, nil
+}
@@ -0,0 +1,131 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package rampup
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfile_Linear(t *testing.T) {
+	p := Profile{Kind: LINEAR, Concurrency: 4, Duration: 2 * time.Second}
+
+	assert.Equal(t, 1, p.Workers(0))
+	assert.Equal(t, 1, p.Workers(499*time.Millisecond))
+	assert.Equal(t, 2, p.Workers(500*time.Millisecond))
+	assert.Equal(t, 4, p.Workers(1500*time.Millisecond))
+	assert.Equal(t, 4, p.Workers(time.Minute))
+}
+
+func TestProfile_LinearWithDurationShorterThanConcurrency(t *testing.T) {
+	// 3 seconds for 10 workers used to round down to no ramp-up at all
+	p := Profile{Kind: LINEAR, Concurrency: 10, Duration: 3 * time.Second}
+
+	assert.Equal(t, 1, p.Workers(0))
+	assert.Equal(t, 4, p.Workers(time.Second))
+	assert.Equal(t, 10, p.Workers(3*time.Second))
+}
+
+func TestProfile_NoDuration(t *testing.T) {
+	p := Profile{Kind: EXPONENTIAL, Concurrency: 8}
+
+	assert.Equal(t, 8, p.Workers(0))
+}
+
+func TestProfile_Step(t *testing.T) {
+	p := Profile{Kind: STEP, Concurrency: 10, Duration: 4 * time.Second, Steps: 2}
+
+	assert.Equal(t, 5, p.Workers(0))
+	assert.Equal(t, 5, p.Workers(1999*time.Millisecond))
+	assert.Equal(t, 10, p.Workers(2*time.Second))
+}
+
+func TestProfile_Exponential(t *testing.T) {
+	// 3 doublings to reach 8 workers, so the number of workers changes every second
+	p := Profile{Kind: EXPONENTIAL, Concurrency: 8, Duration: 4 * time.Second}
+
+	assert.Equal(t, 1, p.Workers(0))
+	assert.Equal(t, 2, p.Workers(time.Second))
+	assert.Equal(t, 4, p.Workers(2*time.Second))
+	assert.Equal(t, 8, p.Workers(3*time.Second))
+}
+
+func TestProfile_Stages(t *testing.T) {
+	stages, err := ParseStages("10s:2, 20s:8,30s:4")
+	require.NoError(t, err)
+	p := Profile{Kind: STAGES, Stages: stages}
+
+	assert.Equal(t, 8, p.MaxWorkers())
+	assert.Equal(t, 30*time.Second, p.TotalDuration())
+	assert.Equal(t, 2, p.Workers(0))
+	assert.Equal(t, 8, p.Workers(10*time.Second))
+	assert.Equal(t, 4, p.Workers(30*time.Second))
+	assert.Equal(t, 4, p.Workers(time.Hour))
+}
+
+func TestProfile_Validate(t *testing.T) {
+	assert.NoError(t, Profile{Kind: LINEAR, Concurrency: 2}.Validate())
+	assert.EqualError(t, Profile{Kind: STEP, Concurrency: 2}.Validate(), "number of steps must be greater than 0")
+	assert.EqualError(t, Profile{Kind: STAGES}.Validate(), "stages profile requires at least one stage")
+	assert.EqualError(t, Profile{Kind: "sine", Concurrency: 2}.Validate(), `ramp-up profile "sine" not supported, please use linear, step, exponential or stages`)
+}
+
+func TestParseStages_Invalid(t *testing.T) {
+	_, err := ParseStages("10s")
+	assert.EqualError(t, err, `invalid stage "10s", expected format <duration>:<concurrency>`)
+
+	_, err = ParseStages("10:2")
+	assert.EqualError(t, err, `invalid stage "10:2", duration must be a positive duration such as 10s`)
+
+	_, err = ParseStages("10s:0")
+	assert.EqualError(t, err, `invalid stage "10s:0", concurrency must be greater than 0`)
+}
+
+func TestGate(t *testing.T) {
+	g := NewGate(1)
+	var mu sync.Mutex
+	var passed []int
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			g.Wait(i)
+			mu.Lock()
+			passed = append(passed, i)
+			mu.Unlock()
+		}(i)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, []int{0}, passed)
+	mu.Unlock()
+
+	g.SetOpen(2)
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	assert.ElementsMatch(t, []int{0, 1}, passed)
+	mu.Unlock()
+
+	g.Close()
+	wg.Wait()
+	assert.ElementsMatch(t, []int{0, 1, 2}, passed)
+}
@@ -0,0 +1,132 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket rate limiter which is safe for concurrent use.
+// The bucket holds at most one token so requests are evenly spaced instead of being sent in bursts.
+// A nil Limiter, or one with a rate of zero, does not limit anything.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+	// allowed is the number of requests allowed since the rate was last changed, at since. granted is when the last of them was allowed.
+	allowed int
+	since   time.Time
+	granted time.Time
+}
+
+// NewLimiter returns a limiter which allows up to requestsPerSecond requests per second.
+func NewLimiter(requestsPerSecond float64) *Limiter {
+	now := time.Now()
+	return &Limiter{rate: requestsPerSecond, tokens: 1, last: now, since: now}
+}
+
+// SetRate changes the number of requests allowed per second. Requests already waiting keep their reservation.
+func (l *Limiter) SetRate(requestsPerSecond float64) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.refill(now)
+	if requestsPerSecond != l.rate {
+		l.allowed, l.since = 0, now
+	}
+	l.rate = requestsPerSecond
+}
+
+// minAchievedSpan is how long requests must have been allowed at the current rate for the achieved rate to be measured.
+const minAchievedSpan = time.Second
+
+// Achieved returns the number of requests per second which were allowed since the rate was last changed. It is lower than the rate
+// when the requests are not waited for often enough, e.g. because every worker is waiting for a response. It returns false if the
+// requests were allowed for too short a time to tell.
+func (l *Limiter) Achieved() (float64, bool) {
+	if l == nil {
+		return 0, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	span := l.granted.Sub(l.since)
+	if l.allowed == 0 || span < minAchievedSpan {
+		return 0, false
+	}
+	return float64(l.allowed) / span.Seconds(), true
+}
+
+// Rate returns the number of requests allowed per second.
+func (l *Limiter) Rate() float64 {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// Wait blocks until a request is allowed or the context is done, in which case it returns the context error.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	if l.rate <= 0 {
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+	now := time.Now()
+	l.refill(now)
+	// reserve a token; if there is none the bucket goes negative and we wait until it is refilled
+	l.tokens--
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.allowed++
+	l.granted = now.Add(wait)
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// refill adds the tokens accumulated since the last call. It must be called with the lock held.
+func (l *Limiter) refill(now time.Time) {
+	if l.rate > 0 {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > 1 {
+			l.tokens = 1
+		}
+	}
+	l.last = now
+}
@@ -0,0 +1,99 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_LimitsRate(t *testing.T) {
+	l := NewLimiter(100)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				assert.NoError(t, l.Wait(context.Background()))
+			}
+		}()
+	}
+	wg.Wait()
+
+	// the first request is allowed straight away, the other 19 are spaced by 10ms
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, elapsed, 180*time.Millisecond)
+	assert.Less(t, elapsed, 400*time.Millisecond)
+}
+
+func TestLimiter_WaitReturnsWhenContextIsDone(t *testing.T) {
+	l := NewLimiter(0.1)
+	assert.NoError(t, l.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.Wait(ctx), context.DeadlineExceeded)
+}
+
+func TestLimiter_NoLimit(t *testing.T) {
+	var nilLimiter *Limiter
+	zeroLimiter := NewLimiter(0)
+
+	start := time.Now()
+	for i := 0; i < 1000; i++ {
+		assert.NoError(t, nilLimiter.Wait(context.Background()))
+		assert.NoError(t, zeroLimiter.Wait(context.Background()))
+	}
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestLimiter_SetRate(t *testing.T) {
+	l := NewLimiter(1)
+	l.SetRate(200)
+	assert.Equal(t, float64(200), l.Rate())
+
+	start := time.Now()
+	for i := 0; i < 11; i++ {
+		assert.NoError(t, l.Wait(context.Background()))
+	}
+	assert.Less(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestLimiter_Achieved(t *testing.T) {
+	l := NewLimiter(100)
+	_, ok := l.Achieved()
+	assert.False(t, ok, "nothing was allowed yet")
+
+	// a single caller which takes 20ms per request cannot reach 100 requests per second
+	deadline := time.Now().Add(1200 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		assert.NoError(t, l.Wait(context.Background()))
+		time.Sleep(20 * time.Millisecond)
+	}
+	achieved, ok := l.Achieved()
+	assert.True(t, ok)
+	assert.InDelta(t, 50, achieved, 10)
+
+	l.SetRate(200)
+	_, ok = l.Achieved()
+	assert.False(t, ok, "the achieved rate is measured again once the rate changes")
+}
@@ -0,0 +1,203 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"mittens/internal/pkg/stats"
+	"os"
+	"time"
+)
+
+const (
+	READY     = "ready"
+	NOT_READY = "not-ready"
+)
+
+// Report is the machine-readable outcome of a warmup.
+type Report struct {
+	// Verdict is either ready or not-ready, in which case Reasons explains why.
+	Verdict string   `json:"verdict"`
+	Reasons []string `json:"reasons,omitempty"`
+	// TargetReady is false if the target never became ready and therefore no warmup requests were sent.
+	TargetReady           bool                   `json:"target-ready"`
+	ReadinessWaitSeconds  float64                `json:"readiness-wait-seconds"`
+	WarmupDurationSeconds float64                `json:"warmup-duration-seconds"`
+	Config                map[string]interface{} `json:"config"`
+	Totals                Counts                 `json:"totals"`
+	Latency               Latency                `json:"latency"`
+	Windows               *Windows               `json:"windows,omitempty"`
+	Requests              []Request              `json:"requests"`
+	// Errors holds the number of requests by error across all the requests which did not get a response.
+	Errors map[string]int `json:"errors"`
+	// Rates holds the target rates which were set along with the rates which were achieved.
+	Rates []Rate `json:"rates,omitempty"`
+	// Targets holds the outcome of each target when several targets are warmed up.
+	Targets []Target `json:"targets,omitempty"`
+}
+
+// Target holds the outcome of the warmup of one of several targets.
+type Target struct {
+	Name                  string   `json:"name"`
+	TargetReady           bool     `json:"target-ready"`
+	ReadinessWaitSeconds  float64  `json:"readiness-wait-seconds"`
+	WarmupDurationSeconds float64  `json:"warmup-duration-seconds"`
+	Totals                Counts   `json:"totals"`
+	Latency               Latency  `json:"latency"`
+	Windows               *Windows `json:"windows,omitempty"`
+	Rates                 []Rate   `json:"rates,omitempty"`
+}
+
+// Rate is a target rate of requests per second along with the rate which was achieved once the ramp-up was over.
+type Rate struct {
+	// Name is the flag which sets the rate, e.g. http-requests-per-second.
+	Name     string  `json:"name"`
+	Target   int     `json:"target"`
+	Achieved float64 `json:"achieved"`
+}
+
+// Counts holds the number of requests by outcome.
+type Counts struct {
+	Requests  int `json:"requests"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Errors    int `json:"errors"`
+}
+
+// Latency holds latency percentiles in milliseconds.
+type Latency struct {
+	P50 float64 `json:"p50-ms"`
+	P90 float64 `json:"p90-ms"`
+	P99 float64 `json:"p99-ms"`
+	Max float64 `json:"max-ms"`
+}
+
+// Windows compares the first and last seconds of the warmup.
+type Windows struct {
+	Seconds float64 `json:"seconds"`
+	First   Window  `json:"first"`
+	Last    Window  `json:"last"`
+}
+
+// Window holds the statistics of the requests sent during part of the warmup.
+type Window struct {
+	Counts  Counts  `json:"counts"`
+	Latency Latency `json:"latency"`
+}
+
+// Request holds the statistics of a single request.
+type Request struct {
+	// Target is the name of the target of the request when several targets are warmed up.
+	Target      string         `json:"target,omitempty"`
+	Name        string         `json:"name"`
+	Protocol    string         `json:"protocol"`
+	Counts      Counts         `json:"counts"`
+	Latency     Latency        `json:"latency"`
+	StatusCodes map[string]int `json:"status-codes"`
+	Errors      map[string]int `json:"errors"`
+	// Failures holds the number of responses by reason for the responses which did not meet the expectations of the request.
+	Failures map[string]int `json:"failures,omitempty"`
+	Windows  *Windows       `json:"windows,omitempty"`
+}
+
+// New creates a report from the statistics of the warmup requests.
+func New(summary stats.Summary, config map[string]interface{}, targetReady bool, readinessWait time.Duration, warmupDuration time.Duration) Report {
+	r := Report{
+		Verdict:               READY,
+		TargetReady:           targetReady,
+		ReadinessWaitSeconds:  readinessWait.Seconds(),
+		WarmupDurationSeconds: warmupDuration.Seconds(),
+		Config:                config,
+		Totals:                toCounts(summary.Counts),
+		Latency:               toLatency(summary.Latency),
+		Windows:               toWindows(summary.WindowSize, summary.First, summary.Last),
+		Requests:              make([]Request, 0, len(summary.Endpoints)),
+		Errors:                make(map[string]int),
+	}
+
+	for _, e := range summary.Endpoints {
+		r.Requests = append(r.Requests, Request{
+			Target:      e.Target,
+			Name:        e.Name,
+			Protocol:    e.Protocol,
+			Counts:      toCounts(e.Counts),
+			Latency:     toLatency(e.Latency),
+			StatusCodes: e.StatusCodes,
+			Errors:      e.ErrorMessages,
+			Failures:    e.Failures,
+			Windows:     toWindows(summary.WindowSize, e.First, e.Last),
+		})
+		for message, count := range e.ErrorMessages {
+			r.Errors[message] += count
+		}
+	}
+	return r
+}
+
+// AddTarget adds the outcome of one of several targets to the report.
+func (r *Report) AddTarget(name string, summary stats.Summary, targetReady bool, readinessWait time.Duration, warmupDuration time.Duration, rates []Rate) {
+	r.Targets = append(r.Targets, Target{
+		Name:                  name,
+		TargetReady:           targetReady,
+		ReadinessWaitSeconds:  readinessWait.Seconds(),
+		WarmupDurationSeconds: warmupDuration.Seconds(),
+		Totals:                toCounts(summary.Counts),
+		Latency:               toLatency(summary.Latency),
+		Windows:               toWindows(summary.WindowSize, summary.First, summary.Last),
+		Rates:                 rates,
+	})
+}
+
+// Fail sets the verdict to not-ready for the given reason.
+func (r *Report) Fail(reason string) {
+	r.Verdict = NOT_READY
+	r.Reasons = append(r.Reasons, reason)
+}
+
+// Write writes the report as indented JSON to a file.
+func (r Report) Write(path string) error {
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode report: %v", err)
+	}
+	if err := os.WriteFile(path, append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("unable to write report: %v", err)
+	}
+	return nil
+}
+
+func toCounts(c stats.Counts) Counts {
+	return Counts{Requests: c.Sent + c.Errors, Succeeded: c.Succeeded, Failed: c.Failed, Errors: c.Errors}
+}
+
+func toLatency(l stats.Latency) Latency {
+	return Latency{P50: milliseconds(l.P50), P90: milliseconds(l.P90), P99: milliseconds(l.P99), Max: milliseconds(l.Max)}
+}
+
+func toWindows(size time.Duration, first stats.Window, last stats.Window) *Windows {
+	if size == 0 {
+		return nil
+	}
+	return &Windows{
+		Seconds: size.Seconds(),
+		First:   Window{Counts: toCounts(first.Counts), Latency: toLatency(first.Latency)},
+		Last:    Window{Counts: toCounts(last.Counts), Latency: toLatency(last.Latency)},
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
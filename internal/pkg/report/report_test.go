@@ -0,0 +1,100 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"mittens/internal/pkg/stats"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport_New(t *testing.T) {
+	summary := stats.Summary{
+		Counts:  stats.Counts{Sent: 2, Succeeded: 1, Failed: 1, Errors: 1},
+		Latency: stats.Latency{P50: 1500 * time.Microsecond, Max: 3 * time.Millisecond},
+		Endpoints: []stats.Endpoint{
+			{
+				Name:          "GET /ping",
+				Protocol:      stats.HTTP,
+				Counts:        stats.Counts{Sent: 2, Succeeded: 1, Failed: 1, Errors: 1},
+				StatusCodes:   map[string]int{"200": 1, "503": 1},
+				ErrorMessages: map[string]int{"timeout": 1},
+				Failures:      map[string]int{"unexpected status 503": 1},
+			},
+		},
+	}
+
+	r := New(summary, map[string]interface{}{"concurrency": 2}, true, 2*time.Second, 30*time.Second)
+
+	assert.Equal(t, READY, r.Verdict)
+	assert.Equal(t, 2.0, r.ReadinessWaitSeconds)
+	assert.Equal(t, 30.0, r.WarmupDurationSeconds)
+	assert.Equal(t, Counts{Requests: 3, Succeeded: 1, Failed: 1, Errors: 1}, r.Totals)
+	assert.Equal(t, Latency{P50: 1.5, Max: 3}, r.Latency)
+	assert.Nil(t, r.Windows)
+	require.Equal(t, 1, len(r.Requests))
+	assert.Equal(t, map[string]int{"200": 1, "503": 1}, r.Requests[0].StatusCodes)
+	assert.Equal(t, map[string]int{"unexpected status 503": 1}, r.Requests[0].Failures)
+	assert.Equal(t, map[string]int{"timeout": 1}, r.Errors)
+}
+
+func TestReport_Write(t *testing.T) {
+	r := New(stats.Summary{WindowSize: 10 * time.Second}, map[string]interface{}{}, false, 0, 0)
+	r.Fail("target did not become ready")
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	require.NoError(t, r.Write(path))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(content, &decoded))
+
+	assert.Equal(t, NOT_READY, decoded["verdict"])
+	assert.Equal(t, []interface{}{"target did not become ready"}, decoded["reasons"])
+	assert.Equal(t, false, decoded["target-ready"])
+	assert.Equal(t, []interface{}{}, decoded["requests"])
+	assert.Equal(t, 10.0, decoded["windows"].(map[string]interface{})["seconds"])
+}
+
+func TestReport_AddTarget(t *testing.T) {
+	summary := stats.Summary{
+		Counts:    stats.Counts{Sent: 1, Succeeded: 1},
+		Latency:   stats.Latency{P50: time.Millisecond, Max: time.Millisecond},
+		Endpoints: []stats.Endpoint{{Target: "cache", Name: "GET /ping", Protocol: stats.HTTP, Counts: stats.Counts{Sent: 1, Succeeded: 1}}},
+	}
+
+	r := New(summary, map[string]interface{}{}, true, time.Second, 10*time.Second)
+	r.AddTarget("cache", summary, true, time.Second, 10*time.Second, []Rate{{Name: "requests-per-second", Target: 100, Achieved: 62.5}})
+
+	require.Equal(t, 1, len(r.Requests))
+	assert.Equal(t, "cache", r.Requests[0].Target)
+	require.Equal(t, 1, len(r.Targets))
+	assert.Equal(t, Target{
+		Name:                  "cache",
+		TargetReady:           true,
+		ReadinessWaitSeconds:  1,
+		WarmupDurationSeconds: 10,
+		Totals:                Counts{Requests: 1, Succeeded: 1},
+		Latency:               Latency{P50: 1, Max: 1},
+		Rates:                 []Rate{{Name: "requests-per-second", Target: 100, Achieved: 62.5}},
+	}, r.Targets[0])
+}
@@ -14,7 +14,12 @@
 
 package response
 
-import "time"
+import (
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
 
 // Response represents an HTTP or gRPC response.
 type Response struct {
@@ -22,4 +27,15 @@ type Response struct {
 	Err        error
 	Type       string
 	StatusCode int
+	// GrpcCode and GrpcMessage hold the status of a gRPC call.
+	GrpcCode    codes.Code
+	GrpcMessage string
+	// Headers holds the headers of an HTTP response, or the header metadata of a gRPC response.
+	Headers http.Header
+	// Body holds the body of an HTTP response, or the last message of a gRPC response as JSON.
+	// It is only read when the request asks for it, see http.RequestOptions and grpc.RequestOptions.
+	Body []byte
+	// MessagesSent and MessagesReceived hold the number of messages of a gRPC call, which can be more than one for streaming calls.
+	MessagesSent     int
+	MessagesReceived int
 }
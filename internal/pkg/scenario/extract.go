@@ -0,0 +1,118 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package scenario
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mittens/internal/pkg/jsonpath"
+	"mittens/internal/pkg/response"
+	"regexp"
+)
+
+// Extraction sets a variable from a response. Exactly one of JSONPath, Header and Regex is set.
+type Extraction struct {
+	Variable string `json:"-"`
+	// JSONPath selects the value in the JSON body, e.g. $.session.id. Values which are not strings are set as JSON.
+	JSONPath string `json:"json-path"`
+	// Header is the name of the header whose value is set.
+	Header string `json:"header"`
+	// Regex is matched against the body. The variable is set to the first group of the expression, or the whole match if there is no group.
+	Regex string `json:"regex"`
+
+	path   jsonpath.Path
+	regexp *regexp.Regexp
+}
+
+// compile validates the extraction and prepares its JSONPath or regular expression.
+func (e *Extraction) compile() error {
+	set := 0
+	for _, option := range []string{e.JSONPath, e.Header, e.Regex} {
+		if option != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("exactly one of json-path, header or regex is required")
+	}
+
+	switch {
+	case e.JSONPath != "":
+		path, err := jsonpath.Compile(e.JSONPath)
+		if err != nil {
+			return err
+		}
+		e.path = path
+	case e.Regex != "":
+		re, err := regexp.Compile(e.Regex)
+		if err != nil {
+			return fmt.Errorf("invalid regex: %v", err)
+		}
+		e.regexp = re
+	}
+	return nil
+}
+
+// Extract returns the value of the variable in the response.
+func (e Extraction) Extract(resp response.Response) (string, error) {
+	switch {
+	case e.Header != "":
+		values := resp.Headers.Values(e.Header)
+		if len(values) == 0 {
+			return "", fmt.Errorf("missing header %s", e.Header)
+		}
+		return values[0], nil
+	case e.regexp != nil:
+		match := e.regexp.FindSubmatch(resp.Body)
+		if match == nil {
+			return "", fmt.Errorf("body does not match %q", e.Regex)
+		}
+		if len(match) > 1 {
+			return string(match[1]), nil
+		}
+		return string(match[0]), nil
+	default:
+		document, err := jsonpath.Decode(resp.Body)
+		if err != nil {
+			return "", errors.New("body is not JSON")
+		}
+		value, err := e.path.Select(document)
+		if err != nil {
+			return "", err
+		}
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+		content, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return string(content), nil
+	}
+}
+
+// Extract sets the variables of the step from the response.
+// It returns an error describing the first variable which could not be set.
+func (s Step) Extract(resp response.Response, variables map[string]string) error {
+	for _, extraction := range s.Extractions {
+		value, err := extraction.Extract(resp)
+		if err != nil {
+			return fmt.Errorf("cannot extract %s: %v", extraction.Variable, err)
+		}
+		variables[extraction.Variable] = value
+	}
+	return nil
+}
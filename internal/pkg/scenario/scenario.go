@@ -0,0 +1,253 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+// Package scenario implements warmup scenarios: ordered steps whose responses can set variables used by the later steps,
+// e.g. a session ID returned by a first request and sent along with the next ones.
+package scenario
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mittens/internal/pkg/grpc"
+	"mittens/internal/pkg/http"
+	"mittens/internal/pkg/placeholders"
+	"mittens/internal/pkg/selection"
+	"mittens/internal/pkg/stats"
+	"sort"
+	"strings"
+)
+
+// Scenario is a sequence of HTTP and gRPC requests which are sent one after the other.
+// Each run of the scenario starts without any variables.
+type Scenario struct {
+	Name  string
+	Steps []Step
+	// Weight is the relative frequency with which the scenario is picked.
+	Weight int
+	// Count is the exact number of times the scenario runs. Zero means that the scenario is picked according to its weight.
+	Count int
+}
+
+// Step is a single request of a scenario. Exactly one of HTTP and Grpc is set.
+type Step struct {
+	HTTP *http.Request
+	Grpc *grpc.Request
+	// Extractions set variables from the response, in the order of their names.
+	Extractions []Extraction
+	// compression is applied to the body of HTTP requests once their variables are set.
+	compression http.CompressionType
+}
+
+// jsonScenario is the JSON representation of a scenario.
+type jsonScenario struct {
+	Name   string     `json:"name"`
+	Steps  []jsonStep `json:"steps"`
+	Weight int        `json:"weight"`
+	Count  int        `json:"count"`
+	Once   bool       `json:"once"`
+}
+
+// jsonStep is the JSON representation of a step. The request is either a string in the format of the http-requests
+// and grpc-requests flags, or a JSON object.
+type jsonStep struct {
+	HTTP    json.RawMessage        `json:"http"`
+	Grpc    json.RawMessage        `json:"grpc"`
+	Extract map[string]*Extraction `json:"extract"`
+}
+
+// ToScenario parses a scenario which is a JSON object, e.g.
+// {"name": "cart", "steps": [{"http": "post:/carts", "extract": {"cart": {"json-path": "$.id"}}}, {"http": "get:/carts/{$var|cart}"}]}.
+func ToScenario(value string, compression http.CompressionType) (Scenario, error) {
+	j := jsonScenario{Weight: 1}
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&j); err != nil {
+		return Scenario{}, fmt.Errorf("invalid scenario: %s, %v", value, err)
+	}
+
+	if j.Name == "" {
+		return Scenario{}, fmt.Errorf("invalid scenario: %s, name is required", value)
+	}
+	if len(j.Steps) == 0 {
+		return Scenario{}, fmt.Errorf("invalid scenario %s: steps are required", j.Name)
+	}
+	if err := selection.Validate(j.Weight, j.Count, j.Once); err != nil {
+		return Scenario{}, fmt.Errorf("invalid scenario %s: %v", j.Name, err)
+	}
+
+	s := Scenario{Name: j.Name, Weight: j.Weight, Count: j.Count}
+	if j.Once {
+		s.Count = 1
+	}
+	// variables holds the variables set by the steps so far
+	variables := make(map[string]bool)
+	for i, js := range j.Steps {
+		step, err := js.toStep(compression)
+		if err != nil {
+			return Scenario{}, fmt.Errorf("invalid scenario %s: step %d: %v", j.Name, i+1, err)
+		}
+		for _, name := range step.variables() {
+			if !variables[name] {
+				return Scenario{}, fmt.Errorf("invalid scenario %s: step %d: variable %s is not extracted by an earlier step", j.Name, i+1, name)
+			}
+		}
+		for _, extraction := range step.Extractions {
+			variables[extraction.Variable] = true
+		}
+		s.Steps = append(s.Steps, step)
+	}
+	return s, nil
+}
+
+func (j jsonStep) toStep(compression http.CompressionType) (Step, error) {
+	hasHTTP := len(j.HTTP) > 0 && string(j.HTTP) != "null"
+	hasGrpc := len(j.Grpc) > 0 && string(j.Grpc) != "null"
+	if hasHTTP == hasGrpc {
+		return Step{}, errors.New("either http or grpc is required")
+	}
+
+	step := Step{compression: compression}
+	if hasHTTP {
+		value, err := requestValue(j.HTTP)
+		if err != nil {
+			return Step{}, err
+		}
+		// the body is only compressed once the variables are set, see HTTPRequest
+		request, err := http.ToHTTPRequest(value, http.COMPRESSION_NONE)
+		if err != nil {
+			return Step{}, err
+		}
+		step.HTTP = &request
+	} else {
+		value, err := requestValue(j.Grpc)
+		if err != nil {
+			return Step{}, err
+		}
+		request, err := grpc.ToGrpcRequest(value)
+		if err != nil {
+			return Step{}, err
+		}
+		step.Grpc = &request
+	}
+
+	names := make([]string, 0, len(j.Extract))
+	for name := range j.Extract {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		extraction := j.Extract[name]
+		if extraction == nil {
+			return Step{}, fmt.Errorf("extract %s: json-path, header or regex is required", name)
+		}
+		extraction.Variable = name
+		if err := extraction.compile(); err != nil {
+			return Step{}, fmt.Errorf("extract %s: %v", name, err)
+		}
+		step.Extractions = append(step.Extractions, *extraction)
+	}
+	return step, nil
+}
+
+// requestValue returns a request in the format of the http-requests and grpc-requests flags, which is either a string or a JSON object.
+func requestValue(raw json.RawMessage) (string, error) {
+	var value string
+	if err := json.Unmarshal(raw, &value); err == nil {
+		return value, nil
+	}
+	if !strings.HasPrefix(strings.TrimSpace(string(raw)), "{") {
+		return "", fmt.Errorf("invalid request: %s, expected a string or an object", raw)
+	}
+	return string(raw), nil
+}
+
+// variables returns the names of the variables used by the request of the step.
+func (s Step) variables() []string {
+	var sources []string
+	if s.HTTP != nil {
+		sources = append(sources, s.HTTP.Path)
+		for _, value := range s.HTTP.Headers {
+			sources = append(sources, value)
+		}
+		if s.HTTP.Body != nil {
+			sources = append(sources, *s.HTTP.Body)
+		}
+	} else {
+		sources = s.Grpc.AllMessages()
+	}
+
+	var names []string
+	for _, source := range sources {
+		names = append(names, placeholders.Variables(source)...)
+	}
+	return names
+}
+
+// DisplayName returns the name of the step, which is the name of its request prefixed by the name of the scenario.
+// Variables are not set so that every run of the step is recorded under the same name.
+func (s Step) DisplayName(scenario string) string {
+	if s.HTTP != nil {
+		return scenario + ": " + s.HTTP.DisplayName()
+	}
+	return scenario + ": " + s.Grpc.DisplayName()
+}
+
+// Protocol returns the protocol of the step, either stats.HTTP or stats.GRPC.
+func (s Step) Protocol() string {
+	if s.HTTP != nil {
+		return stats.HTTP
+	}
+	return stats.GRPC
+}
+
+// HTTPRequest returns the HTTP request of the step with the variables set.
+func (s Step) HTTPRequest(variables map[string]string) (http.Request, error) {
+	return s.HTTP.WithVariables(variables, s.compression)
+}
+
+// GrpcRequest returns the gRPC request of the step with the variables set.
+func (s Step) GrpcRequest(variables map[string]string) grpc.Request {
+	return s.Grpc.WithVariables(variables)
+}
+
+// NeedsBody returns true if the body of the response must be read to set the variables of the step.
+func (s Step) NeedsBody() bool {
+	for _, extraction := range s.Extractions {
+		if extraction.Header == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// HasHTTPSteps returns true if any step of the scenario sends an HTTP request.
+func (s Scenario) HasHTTPSteps() bool {
+	for _, step := range s.Steps {
+		if step.HTTP != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// HasGrpcSteps returns true if any step of the scenario sends a gRPC request.
+func (s Scenario) HasGrpcSteps() bool {
+	for _, step := range s.Steps {
+		if step.Grpc != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package scenario
+
+import (
+	"mittens/internal/pkg/http"
+	"mittens/internal/pkg/response"
+	nethttp "net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToScenario(t *testing.T) {
+	s, err := ToScenario(`{
+		"name": "checkout",
+		"weight": 3,
+		"steps": [
+			{"http": "post:/sessions", "extract": {"session": {"json-path": "$.id"}, "token": {"header": "X-Token"}}},
+			{"http": {"method": "get", "path": "/carts/{$var|session}", "headers": {"Authorization": "Bearer {$var|token}"}}, "extract": {"cart": {"regex": "cart-(\\d+)"}}},
+			{"grpc": {"service-method": "cart.Cart/Get", "message": {"id": "{$var|cart}"}}}
+		]
+	}`, http.COMPRESSION_NONE)
+	require.NoError(t, err)
+
+	assert.Equal(t, "checkout", s.Name)
+	assert.Equal(t, 3, s.Weight)
+	require.Equal(t, 3, len(s.Steps))
+	assert.True(t, s.HasHTTPSteps())
+	assert.True(t, s.HasGrpcSteps())
+
+	first := s.Steps[0]
+	assert.Equal(t, "checkout: POST /sessions", first.DisplayName(s.Name))
+	require.Equal(t, 2, len(first.Extractions))
+	assert.Equal(t, "session", first.Extractions[0].Variable)
+	assert.Equal(t, "token", first.Extractions[1].Variable)
+	assert.True(t, first.NeedsBody())
+
+	second := s.Steps[1]
+	assert.Equal(t, "checkout: GET /carts/{$var|session}", second.DisplayName(s.Name), "variables are not set in the name of a step")
+
+	third := s.Steps[2]
+	assert.Nil(t, third.HTTP)
+	assert.Equal(t, "checkout: cart.Cart/Get", third.DisplayName(s.Name))
+	assert.False(t, third.NeedsBody())
+}
+
+func TestToScenario_Once(t *testing.T) {
+	s, err := ToScenario(`{"name": "login", "once": true, "steps": [{"http": "post:/login"}]}`, http.COMPRESSION_NONE)
+	require.NoError(t, err)
+	assert.Equal(t, 1, s.Count)
+}
+
+func TestToScenario_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"invalid scenario":                                   `{"name": "a", "steps": [{"http": "get:/ping"}], "unknown": 1}`,
+		"name is required":                                   `{"steps": [{"http": "get:/ping"}]}`,
+		"steps are required":                                 `{"name": "a"}`,
+		"weight must be greater than 0":                      `{"name": "a", "weight": 0, "steps": [{"http": "get:/ping"}]}`,
+		"either http or grpc is required":                    `{"name": "a", "steps": [{"http": "get:/ping", "grpc": "health/ping"}]}`,
+		"expected a string or an object":                     `{"name": "a", "steps": [{"http": 1}]}`,
+		"exactly one of json-path, header or regex":          `{"name": "a", "steps": [{"http": "get:/ping", "extract": {"id": {"json-path": "$.id", "header": "X-Id"}}}]}`,
+		"invalid regex":                                      `{"name": "a", "steps": [{"http": "get:/ping", "extract": {"id": {"regex": "("}}}]}`,
+		"must start with $":                                  `{"name": "a", "steps": [{"http": "get:/ping", "extract": {"id": {"json-path": "id"}}}]}`,
+		"step 1: variable id is not extracted":               `{"name": "a", "steps": [{"http": "get:/items/{$var|id}", "extract": {"id": {"json-path": "$.id"}}}]}`,
+		"step 2: variable other is not extracted":            `{"name": "a", "steps": [{"http": "get:/ping", "extract": {"id": {"json-path": "$.id"}}}, {"grpc": "items/get:{\"id\": \"{$var|other}\"}"}]}`,
+		"method DELETED is not supported":                    `{"name": "a", "steps": [{"http": "deleted:/ping"}]}`,
+		"expected format <service>/<method>[:body]":          `{"name": "a", "steps": [{"grpc": "ping"}]}`,
+		"extract id: json-path, header or regex is required": `{"name": "a", "steps": [{"http": "get:/ping", "extract": {"id": null}}]}`,
+	}
+	for expected, value := range tests {
+		t.Run(expected, func(t *testing.T) {
+			_, err := ToScenario(value, http.COMPRESSION_NONE)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), expected)
+		})
+	}
+}
+
+func TestStep_HTTPRequest(t *testing.T) {
+	s, err := ToScenario(`{"name": "a", "steps": [
+		{"http": "post:/sessions", "extract": {"session": {"header": "X-Session"}}},
+		{"http": {"method": "post", "path": "/items/{$var|session}", "headers": {"X-Session": "{$var|session}"}, "body": {"session": "{$var|session}"}}}
+	]}`, http.COMPRESSION_GZIP)
+	require.NoError(t, err)
+
+	request, err := s.Steps[1].HTTPRequest(map[string]string{"session": "abc"})
+	require.NoError(t, err)
+	assert.Equal(t, "/items/abc", request.Path)
+	assert.Equal(t, "abc", request.Headers["X-Session"])
+	assert.Equal(t, "gzip", request.Headers["Content-Encoding"], "the body is compressed once the variables are set")
+	assert.Equal(t, "/items/{$var|session}", s.Steps[1].HTTP.Path, "the step is not changed")
+}
+
+func TestStep_GrpcRequest(t *testing.T) {
+	s, err := ToScenario(`{"name": "a", "steps": [
+		{"http": "post:/sessions", "extract": {"session": {"header": "X-Session"}}},
+		{"grpc": {"service-method": "items/stream", "messages": [{"session": "{$var|session}"}, {"page": 2}]}}
+	]}`, http.COMPRESSION_NONE)
+	require.NoError(t, err)
+
+	request := s.Steps[1].GrpcRequest(map[string]string{"session": "abc"})
+	assert.Equal(t, []string{`{"session": "abc"}`, `{"page": 2}`}, request.Messages)
+}
+
+func TestStep_Extract(t *testing.T) {
+	s, err := ToScenario(`{"name": "a", "steps": [{"http": "post:/sessions", "extract": {
+		"id": {"json-path": "$.session.id"},
+		"count": {"json-path": "$.session.count"},
+		"token": {"header": "X-Token"},
+		"cart": {"regex": "cart-(\\d+)"},
+		"user": {"regex": "user-\\d+"}
+	}}]}`, http.COMPRESSION_NONE)
+	require.NoError(t, err)
+
+	resp := response.Response{
+		Headers: nethttp.Header{"X-Token": []string{"secret"}},
+		Body:    []byte(`{"session": {"id": "abc", "count": 2, "cart": "cart-42", "user": "user-7"}}`),
+	}
+	variables := make(map[string]string)
+	require.NoError(t, s.Steps[0].Extract(resp, variables))
+	assert.Equal(t, map[string]string{"id": "abc", "count": "2", "token": "secret", "cart": "42", "user": "user-7"}, variables)
+}
+
+func TestStep_ExtractFailures(t *testing.T) {
+	tests := map[string]struct {
+		extract  string
+		expected string
+	}{
+		"missing header": {`{"token": {"header": "X-Token"}}`, "cannot extract token: missing header X-Token"},
+		"no match":       {`{"cart": {"regex": "cart-(\\d+)"}}`, `cannot extract cart: body does not match "cart-(\\d+)"`},
+		"not JSON":       {`{"id": {"json-path": "$.id"}}`, "cannot extract id: body is not JSON"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := ToScenario(`{"name": "a", "steps": [{"http": "post:/sessions", "extract": `+test.extract+`}]}`, http.COMPRESSION_NONE)
+			require.NoError(t, err)
+
+			err = s.Steps[0].Extract(response.Response{Body: []byte("ok")}, make(map[string]string))
+			require.Error(t, err)
+			assert.Equal(t, test.expected, err.Error())
+		})
+	}
+}
@@ -0,0 +1,143 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package selection
+
+import (
+	"errors"
+	"math/rand"
+)
+
+// Validate checks the options that control how often a request is sent.
+func Validate(weight int, count int, once bool) error {
+	if weight < 1 {
+		return errors.New("weight must be greater than 0")
+	}
+	if count < 0 {
+		return errors.New("count cannot be negative")
+	}
+	if once && count != 0 {
+		return errors.New("once and count cannot be used together")
+	}
+	return nil
+}
+
+// Picker picks items according to their weight and count.
+// Items with a count are picked first, exactly count times each and in the order they were given.
+// The remaining items are then picked in rounds. Each round contains every item as many times as its weight, in random order.
+// This keeps the mix of items proportional to their weights while making sure that every item is picked in each round.
+// Items which are used up, see UntilDone, are no longer picked.
+type Picker[T any] struct {
+	items []T
+	// fixed, weighted and round hold indexes of items
+	fixed    []int
+	weighted []int
+	weights  []int
+	round    []int
+	done     func(T) bool
+	finished func(T)
+}
+
+// NewPicker returns a picker for the items. weightAndCount returns the weight and count of an item.
+func NewPicker[T any](items []T, weightAndCount func(T) (weight int, count int)) *Picker[T] {
+	p := &Picker[T]{items: items}
+	for i, item := range items {
+		weight, count := weightAndCount(item)
+		if count > 0 {
+			for j := 0; j < count; j++ {
+				p.fixed = append(p.fixed, i)
+			}
+			continue
+		}
+		p.weighted = append(p.weighted, i)
+		p.weights = append(p.weights, weight)
+	}
+	return p
+}
+
+// UntilDone makes the picker skip the items for which done returns true, e.g. requests whose datasets have no rows left.
+// A weighted item which is done is dropped from the following rounds.
+func (p *Picker[T]) UntilDone(done func(T) bool) *Picker[T] {
+	p.done = done
+	return p
+}
+
+// OnFinished sets a function called once with each item which will no longer be picked: items with a count once they are
+// picked for the last time, and items which are done once they are dropped.
+func (p *Picker[T]) OnFinished(finished func(T)) *Picker[T] {
+	p.finished = finished
+	return p
+}
+
+// Next returns the next item. It returns false once every item with a count was picked and there are no weighted items left.
+func (p *Picker[T]) Next() (T, bool) {
+	for len(p.fixed) > 0 {
+		i := p.fixed[0]
+		p.fixed = p.fixed[1:]
+		if len(p.fixed) == 0 || p.fixed[0] != i {
+			p.finish(i)
+		}
+		if !p.isDone(i) {
+			return p.items[i], true
+		}
+	}
+
+	for {
+		if len(p.round) == 0 {
+			p.newRound()
+		}
+		if len(p.round) == 0 {
+			var zero T
+			return zero, false
+		}
+		i := p.round[0]
+		p.round = p.round[1:]
+		if !p.isDone(i) {
+			return p.items[i], true
+		}
+	}
+}
+
+func (p *Picker[T]) isDone(i int) bool {
+	return p.done != nil && p.done(p.items[i])
+}
+
+func (p *Picker[T]) finish(i int) {
+	if p.finished != nil {
+		p.finished(p.items[i])
+	}
+}
+
+func (p *Picker[T]) newRound() {
+	var weighted []int
+	var weights []int
+	for j, i := range p.weighted {
+		if p.isDone(i) {
+			p.finish(i)
+			continue
+		}
+		weighted = append(weighted, i)
+		weights = append(weights, p.weights[j])
+	}
+	p.weighted, p.weights = weighted, weights
+
+	for j, i := range p.weighted {
+		for k := 0; k < p.weights[j]; k++ {
+			p.round = append(p.round, i)
+		}
+	}
+	rand.Shuffle(len(p.round), func(i, j int) {
+		p.round[i], p.round[j] = p.round[j], p.round[i]
+	})
+}
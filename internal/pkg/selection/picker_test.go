@@ -0,0 +1,133 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package selection
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	name   string
+	weight int
+	count  int
+}
+
+func weightAndCount(i item) (int, int) {
+	return i.weight, i.count
+}
+
+func TestPicker_FixedCountItemsArePickedFirst(t *testing.T) {
+	p := NewPicker([]item{{"a", 1, 0}, {"b", 1, 2}, {"c", 1, 1}}, weightAndCount)
+
+	var picked []string
+	for i := 0; i < 3; i++ {
+		next, ok := p.Next()
+		require.True(t, ok)
+		picked = append(picked, next.name)
+	}
+
+	assert.Equal(t, []string{"b", "b", "c"}, picked)
+	next, ok := p.Next()
+	require.True(t, ok)
+	assert.Equal(t, "a", next.name)
+}
+
+func TestPicker_StopsWhenOnlyFixedCountItems(t *testing.T) {
+	p := NewPicker([]item{{"a", 1, 1}}, weightAndCount)
+
+	_, ok := p.Next()
+	assert.True(t, ok)
+	_, ok = p.Next()
+	assert.False(t, ok)
+}
+
+func TestPicker_Weights(t *testing.T) {
+	p := NewPicker([]item{{"hot", 3, 0}, {"cold", 1, 0}}, weightAndCount)
+
+	// every round of 4 picks contains each item as many times as its weight
+	for round := 0; round < 100; round++ {
+		picked := make(map[string]int)
+		for i := 0; i < 4; i++ {
+			next, ok := p.Next()
+			require.True(t, ok)
+			picked[next.name]++
+		}
+		assert.Equal(t, map[string]int{"hot": 3, "cold": 1}, picked)
+	}
+}
+
+func TestPicker_UntilDone(t *testing.T) {
+	left := map[string]int{"a": 2, "b": 1}
+	p := NewPicker([]item{{"a", 1, 0}, {"b", 1, 0}, {"c", 1, 0}}, weightAndCount).UntilDone(func(i item) bool {
+		return i.name != "c" && left[i.name] == 0
+	})
+
+	picked := make(map[string]int)
+	for i := 0; i < 30; i++ {
+		next, ok := p.Next()
+		require.True(t, ok)
+		picked[next.name]++
+		left[next.name]--
+	}
+	assert.Equal(t, 2, picked["a"], "items which are done are no longer picked")
+	assert.Equal(t, 1, picked["b"])
+	assert.Equal(t, 27, picked["c"], "the other items are still picked by weight")
+
+	p = NewPicker([]item{{"a", 1, 0}}, weightAndCount).UntilDone(func(item) bool { return true })
+	_, ok := p.Next()
+	assert.False(t, ok, "the picker stops once every item is done")
+}
+
+func TestPicker_OnFinished(t *testing.T) {
+	left := map[string]int{"data": 2}
+	var finished []string
+	p := NewPicker([]item{{"hot", 1, 0}, {"once", 1, 1}, {"twice", 1, 2}, {"data", 1, 0}}, weightAndCount).
+		UntilDone(func(i item) bool { return i.name == "data" && left[i.name] == 0 }).
+		OnFinished(func(i item) { finished = append(finished, i.name) })
+
+	next, _ := p.Next()
+	assert.Equal(t, "once", next.name)
+	assert.Equal(t, []string{"once"}, finished, "items with a count are finished once picked for the last time")
+	p.Next()
+	assert.Equal(t, []string{"once"}, finished)
+	p.Next()
+	assert.Equal(t, []string{"once", "twice"}, finished)
+
+	for i := 0; i < 10; i++ {
+		next, ok := p.Next()
+		require.True(t, ok)
+		left[next.name]--
+	}
+	assert.Equal(t, []string{"once", "twice", "data"}, finished, "items which are done are finished once dropped")
+}
+
+func TestPicker_Empty(t *testing.T) {
+	p := NewPicker([]item{}, weightAndCount)
+
+	_, ok := p.Next()
+	assert.False(t, ok)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(1, 0, false))
+	assert.NoError(t, Validate(1, 0, true))
+	assert.NoError(t, Validate(3, 5, false))
+	assert.EqualError(t, Validate(0, 0, false), "weight must be greater than 0")
+	assert.EqualError(t, Validate(1, -1, false), "count cannot be negative")
+	assert.EqualError(t, Validate(1, 2, true), "once and count cannot be used together")
+}
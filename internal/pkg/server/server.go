@@ -0,0 +1,65 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"log"
+	"mittens/internal/pkg/safe"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Server is the HTTP server that mittens itself exposes, e.g. for probes.
+type Server struct {
+	mux    *http.ServeMux
+	server *http.Server
+}
+
+// New returns a server that listens on the given port once started.
+func New(port int) *Server {
+	mux := http.NewServeMux()
+	return &Server{
+		mux:    mux,
+		server: &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: mux, ReadHeaderTimeout: 5 * time.Second},
+	}
+}
+
+// Handle registers the handler for the given path. It must be called before Start.
+func (s *Server) Handle(path string, handler http.Handler) {
+	s.mux.Handle(path, handler)
+}
+
+// Start listens on the port and serves requests in the background. It returns an error if the port cannot be used.
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return fmt.Errorf("unable to listen on %s: %v", s.server.Addr, err)
+	}
+	log.Printf("Server listening on %s", listener.Addr())
+
+	go safe.Do(func() {
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("Server error: %v", err)
+		}
+	})
+	return nil
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	return s.server.Close()
+}
@@ -0,0 +1,115 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package stats
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+	"time"
+)
+
+// subBins is the number of bins between two powers of two microseconds, which bounds the error of the percentiles to 1/subBins.
+const subBins = 64
+
+// bin holds the number of latencies which fell in a bin, along with the highest of them.
+type bin struct {
+	count int
+	max   time.Duration
+}
+
+// histogram counts latencies in bins whose width is at most 1/64 of their lower bound, so that the memory it uses does not
+// depend on the number of requests. Latencies below 128µs each have their own bin.
+type histogram struct {
+	bins  map[int]*bin
+	count int
+}
+
+func newHistogram() *histogram {
+	return &histogram{bins: make(map[int]*bin)}
+}
+
+// binIndex returns the bin of a latency. The bins of each power of two are in order, after those of the previous power of two.
+func binIndex(d time.Duration) int {
+	v := uint64(d / time.Microsecond)
+	if v < 2*subBins {
+		return int(v)
+	}
+	shift := bits.Len64(v) - bits.Len64(2*subBins-1)
+	return shift*subBins + int(v>>shift)
+}
+
+func (h *histogram) add(d time.Duration) {
+	i := binIndex(d)
+	b, ok := h.bins[i]
+	if !ok {
+		b = &bin{}
+		h.bins[i] = b
+	}
+	b.count++
+	if d > b.max {
+		b.max = d
+	}
+	h.count++
+}
+
+func (h *histogram) merge(other *histogram) {
+	for i, o := range other.bins {
+		b, ok := h.bins[i]
+		if !ok {
+			b = &bin{}
+			h.bins[i] = b
+		}
+		b.count += o.count
+		if o.max > b.max {
+			b.max = o.max
+		}
+	}
+	h.count += other.count
+}
+
+// latency computes the percentiles using the nearest-rank method. Each percentile is the highest latency of the bin it falls in,
+// which is exact as long as the latencies around it fall in different bins.
+func (h *histogram) latency() Latency {
+	if h.count == 0 {
+		return Latency{}
+	}
+	indexes := make([]int, 0, len(h.bins))
+	for i := range h.bins {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	percentile := func(p float64) time.Duration {
+		rank := int(math.Ceil(p / 100 * float64(h.count)))
+		if rank < 1 {
+			rank = 1
+		}
+		seen := 0
+		for _, i := range indexes {
+			seen += h.bins[i].count
+			if seen >= rank {
+				return h.bins[i].max
+			}
+		}
+		return h.bins[indexes[len(indexes)-1]].max
+	}
+	return Latency{
+		P50: percentile(50),
+		P90: percentile(90),
+		P99: percentile(99),
+		Max: h.bins[indexes[len(indexes)-1]].max,
+	}
+}
@@ -0,0 +1,392 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	HTTP = "http"
+	GRPC = "grpc"
+)
+
+// Result is the outcome of a single warmup request.
+type Result struct {
+	// Target is the name of the target the request was sent to. It is empty when a single target is warmed up.
+	Target string
+	// Endpoint identifies the request, e.g. `GET /ping` or `health/ping`.
+	Endpoint string
+	// Protocol is either http or grpc.
+	Protocol string
+	// StatusCode is the HTTP status code of the response. It is not set for gRPC requests.
+	StatusCode int
+	// GrpcCode is the status code of a gRPC response. It is not set for HTTP requests.
+	GrpcCode codes.Code
+	// Err is set when no response was received, e.g. the connection was refused or the request timed out.
+	Err error
+	// Checked is true if the response was checked against the expectations of the request, in which case Failure alone
+	// decides whether the request succeeded, e.g. a 404 is a success when it is the expected status.
+	Checked bool
+	// Failure is set when the response did not meet the expectations of the request.
+	Failure error
+	// Duration is the time taken to receive the response.
+	Duration time.Duration
+}
+
+// Success returns true if a response was received and it met the expectations of the request or,
+// if the request has none, it has a 2xx or OK status.
+func (r Result) Success() bool {
+	if r.Err != nil || r.Failure != nil {
+		return false
+	}
+	if r.Checked {
+		return true
+	}
+	if r.Protocol == GRPC {
+		return r.GrpcCode == codes.OK
+	}
+	return r.StatusCode/100 == 2
+}
+
+// Observer is notified of every result as soon as it is recorded, e.g. to export metrics.
+type Observer interface {
+	Observe(result Result)
+}
+
+// targetObserver passes on the results of a single target.
+type targetObserver struct {
+	target   string
+	observer Observer
+}
+
+func (o targetObserver) Observe(result Result) {
+	if result.Target == o.target {
+		o.observer.Observe(result)
+	}
+}
+
+// ForTarget returns an observer which only passes on the results of the given target to the observer.
+func ForTarget(target string, observer Observer) Observer {
+	return targetObserver{target: target, observer: observer}
+}
+
+// Recorder collects the results of the warmup requests. It is safe for concurrent use.
+// Results are added up by endpoint as they are recorded, so that the memory it uses does not depend on the number of requests.
+type Recorder struct {
+	mu     sync.Mutex
+	start  time.Time
+	window time.Duration
+	// all and spans hold the time of the first and last results of all the targets and of each target.
+	all       *span
+	spans     map[string]*span
+	endpoints []*endpointStats
+	indexes   map[string]int
+	observers []Observer
+}
+
+// span holds the times of the first and last results, relative to the start of the recorder.
+type span struct {
+	first, last time.Duration
+}
+
+// endpointStats adds up the results of an endpoint.
+type endpointStats struct {
+	Endpoint
+	latency *histogram
+	// seconds hold the results by second since the start of the recorder. Only the seconds which may fall in the first or last
+	// window of the target of the endpoint are kept.
+	seconds map[int]*second
+}
+
+// second holds the results of an endpoint recorded during a second.
+type second struct {
+	Counts
+	latency *histogram
+}
+
+// NewRecorder returns an empty recorder. The time of the results is relative to its creation.
+// window is the duration of the first and last windows of the summaries. Zero means that windows are not needed.
+func NewRecorder(window time.Duration, observers ...Observer) *Recorder {
+	return &Recorder{start: time.Now(), window: window, spans: make(map[string]*span), indexes: make(map[string]int), observers: observers}
+}
+
+// Record adds the result of a request and passes it on to the observers.
+func (r *Recorder) Record(result Result) {
+	r.recordAt(result, time.Since(r.start))
+
+	for _, observer := range r.observers {
+		observer.Observe(result)
+	}
+}
+
+// recordAt adds the result of a request recorded at the given time since the start of the recorder.
+func (r *Recorder) recordAt(result Result, offset time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.all = r.all.extend(offset)
+	targetSpan := r.spans[result.Target].extend(offset)
+	r.spans[result.Target] = targetSpan
+
+	e := r.endpoint(result.Target, result.Protocol, result.Endpoint)
+	e.add(result)
+	if result.Err != nil {
+		e.ErrorMessages[result.Err.Error()]++
+	} else {
+		e.StatusCodes[statusCode(result)]++
+		if result.Failure != nil {
+			e.Failures[result.Failure.Error()]++
+		}
+		e.latency.add(result.Duration)
+	}
+
+	if r.window <= 0 {
+		return
+	}
+	n := int(offset / time.Second)
+	sec, ok := e.seconds[n]
+	if !ok {
+		sec = &second{latency: newHistogram()}
+		e.seconds[n] = sec
+		r.prune(e, targetSpan)
+	}
+	sec.add(result)
+	if result.Err == nil {
+		sec.latency.add(result.Duration)
+	}
+}
+
+// AddEndpoint adds an endpoint without any result, so that the summaries include it even if none of its requests are recorded,
+// e.g. because they could not be sent.
+func (r *Recorder) AddEndpoint(target string, protocol string, endpoint string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.endpoint(target, protocol, endpoint)
+}
+
+// endpoint returns the statistics of an endpoint, creating them if needed. The caller must hold the lock.
+func (r *Recorder) endpoint(target string, protocol string, name string) *endpointStats {
+	key := target + " " + protocol + " " + name
+	if i, ok := r.indexes[key]; ok {
+		return r.endpoints[i]
+	}
+	e := &endpointStats{
+		Endpoint: Endpoint{
+			Target:        target,
+			Name:          name,
+			Protocol:      protocol,
+			StatusCodes:   make(map[string]int),
+			ErrorMessages: make(map[string]int),
+			Failures:      make(map[string]int),
+		},
+		latency: newHistogram(),
+		seconds: make(map[int]*second),
+	}
+	r.indexes[key] = len(r.endpoints)
+	r.endpoints = append(r.endpoints, e)
+	return e
+}
+
+// extend updates the span with the time of a result and returns it. A nil span is created.
+func (s *span) extend(offset time.Duration) *span {
+	if s == nil {
+		return &span{first: offset, last: offset}
+	}
+	if offset < s.first {
+		s.first = offset
+	}
+	if offset > s.last {
+		s.last = offset
+	}
+	return s
+}
+
+// prune drops the seconds of an endpoint which are too late to be in the first window and too early to be in the last one.
+// Since the span of all the targets starts no later and ends no earlier than the span of the target, the seconds which are kept
+// cover the windows of both.
+func (r *Recorder) prune(e *endpointStats, targetSpan *span) {
+	windowSeconds := int(math.Ceil(r.window.Seconds()))
+	first, last := int(targetSpan.first/time.Second), int(targetSpan.last/time.Second)
+	for n := range e.seconds {
+		if n >= first+windowSeconds && n <= last-windowSeconds {
+			delete(e.seconds, n)
+		}
+	}
+}
+
+// Counts holds the number of requests by outcome.
+type Counts struct {
+	// Sent is the number of requests which got a response, whatever its status.
+	Sent int
+	// Succeeded is the number of successful responses, see Result.Success.
+	Succeeded int
+	// Failed is the number of other responses.
+	Failed int
+	// Errors is the number of requests which did not get a response.
+	Errors int
+}
+
+func (c *Counts) merge(other Counts) {
+	c.Sent += other.Sent
+	c.Succeeded += other.Succeeded
+	c.Failed += other.Failed
+	c.Errors += other.Errors
+}
+
+func (c *Counts) add(result Result) {
+	switch {
+	case result.Err != nil:
+		c.Errors++
+	case result.Success():
+		c.Sent++
+		c.Succeeded++
+	default:
+		c.Sent++
+		c.Failed++
+	}
+}
+
+// Latency holds the latency percentiles of the requests which got a response.
+type Latency struct {
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// Window holds the statistics of the requests sent during part of the warmup.
+type Window struct {
+	Counts
+	Latency Latency
+}
+
+// Endpoint holds the statistics of the requests sent to a single endpoint.
+type Endpoint struct {
+	// Target is the name of the target of the endpoint. It is empty when a single target is warmed up.
+	Target   string
+	Name     string
+	Protocol string
+	Counts
+	Latency Latency
+	// StatusCodes holds the number of responses by status, e.g. 200 or 503 for HTTP and OK or Unavailable for gRPC.
+	StatusCodes map[string]int
+	// ErrorMessages holds the number of requests by error for the requests which did not get a response.
+	ErrorMessages map[string]int
+	// Failures holds the number of responses by reason for the responses which did not meet the expectations of the request.
+	Failures map[string]int
+	// First and Last hold the statistics of the first and last WindowSize of the warmup.
+	First Window
+	Last  Window
+}
+
+// Summary holds the statistics of all the recorded requests.
+type Summary struct {
+	Counts
+	Latency Latency
+	// Endpoints are in the order in which they were added or first recorded.
+	Endpoints []Endpoint
+	// WindowSize is the duration of the First and Last windows. It is zero if windows were not requested.
+	WindowSize time.Duration
+	First      Window
+	Last       Window
+}
+
+// Summary returns the statistics of the requests recorded so far.
+// The first and last window of the warmup are also summarised separately so that they can be compared.
+// If the warmup lasted less than two windows, each window covers half of it.
+func (r *Recorder) Summary() Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.summarise(r.all, func(*endpointStats) bool { return true })
+}
+
+// TargetSummary is like Summary but only includes the requests sent to the given target.
+func (r *Recorder) TargetSummary(target string) Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.summarise(r.spans[target], func(e *endpointStats) bool { return e.Target == target })
+}
+
+// summarise adds up the endpoints which are included. The windows are taken from the span of their results, which is nil if there are
+// none, in which case the summary has no windows.
+func (r *Recorder) summarise(s *span, include func(*endpointStats) bool) Summary {
+	var summary Summary
+	var first, last int
+	if s != nil && r.window > 0 {
+		summary.WindowSize = r.window
+		if span := s.last - s.first; summary.WindowSize > span/2 {
+			summary.WindowSize = span / 2
+		}
+		first, last = int(s.first/time.Second), int(s.last/time.Second)
+	}
+
+	total, totalFirst, totalLast := newHistogram(), newHistogram(), newHistogram()
+	for _, e := range r.endpoints {
+		if !include(e) {
+			continue
+		}
+		endpoint := e.Endpoint
+		endpoint.StatusCodes, endpoint.ErrorMessages, endpoint.Failures = copyCounts(e.StatusCodes), copyCounts(e.ErrorMessages), copyCounts(e.Failures)
+		endpoint.Latency = e.latency.latency()
+		summary.Counts.merge(e.Counts)
+		total.merge(e.latency)
+
+		if summary.WindowSize > 0 {
+			endpointFirst, endpointLast := newHistogram(), newHistogram()
+			for n, sec := range e.seconds {
+				if time.Duration(n-first)*time.Second < summary.WindowSize {
+					endpoint.First.Counts.merge(sec.Counts)
+					endpointFirst.merge(sec.latency)
+				}
+				if time.Duration(last-n)*time.Second < summary.WindowSize {
+					endpoint.Last.Counts.merge(sec.Counts)
+					endpointLast.merge(sec.latency)
+				}
+			}
+			endpoint.First.Latency, endpoint.Last.Latency = endpointFirst.latency(), endpointLast.latency()
+			summary.First.Counts.merge(endpoint.First.Counts)
+			summary.Last.Counts.merge(endpoint.Last.Counts)
+			totalFirst.merge(endpointFirst)
+			totalLast.merge(endpointLast)
+		}
+		summary.Endpoints = append(summary.Endpoints, endpoint)
+	}
+	summary.Latency, summary.First.Latency, summary.Last.Latency = total.latency(), totalFirst.latency(), totalLast.latency()
+	return summary
+}
+
+func copyCounts(counts map[string]int) map[string]int {
+	copied := make(map[string]int, len(counts))
+	for k, v := range counts {
+		copied[k] = v
+	}
+	return copied
+}
+
+func statusCode(result Result) string {
+	if result.Protocol == GRPC {
+		return result.GrpcCode.String()
+	}
+	return fmt.Sprint(result.StatusCode)
+}
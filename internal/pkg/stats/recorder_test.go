@@ -0,0 +1,257 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package stats
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func TestRecorder_Summary(t *testing.T) {
+	r := NewRecorder(0)
+	r.Record(Result{Endpoint: "GET /ping", Protocol: HTTP, StatusCode: 200, Duration: time.Millisecond})
+	r.Record(Result{Endpoint: "health/ping", Protocol: GRPC, GrpcCode: codes.Unavailable})
+	r.Record(Result{Endpoint: "GET /ping", Protocol: HTTP, StatusCode: 503, Duration: time.Millisecond})
+	r.Record(Result{Endpoint: "GET /ping", Protocol: HTTP, Err: errors.New("connection refused")})
+	r.Record(Result{Endpoint: "health/ping", Protocol: GRPC, GrpcCode: codes.OK})
+
+	summary := r.Summary()
+
+	assert.Equal(t, Counts{Sent: 4, Succeeded: 2, Failed: 2, Errors: 1}, summary.Counts)
+	require.Equal(t, 2, len(summary.Endpoints))
+
+	assert.Equal(t, Endpoint{
+		Name:          "GET /ping",
+		Protocol:      HTTP,
+		Counts:        Counts{Sent: 2, Succeeded: 1, Failed: 1, Errors: 1},
+		Latency:       Latency{P50: time.Millisecond, P90: time.Millisecond, P99: time.Millisecond, Max: time.Millisecond},
+		StatusCodes:   map[string]int{"200": 1, "503": 1},
+		ErrorMessages: map[string]int{"connection refused": 1},
+		Failures:      map[string]int{},
+	}, summary.Endpoints[0])
+	assert.Equal(t, Endpoint{
+		Name:          "health/ping",
+		Protocol:      GRPC,
+		Counts:        Counts{Sent: 2, Succeeded: 1, Failed: 1},
+		StatusCodes:   map[string]int{"OK": 1, "Unavailable": 1},
+		ErrorMessages: map[string]int{},
+		Failures:      map[string]int{},
+	}, summary.Endpoints[1])
+}
+
+func TestRecorder_Expectations(t *testing.T) {
+	r := NewRecorder(0)
+	r.Record(Result{Endpoint: "GET /missing", Protocol: HTTP, StatusCode: 404, Checked: true, Duration: time.Millisecond})
+	r.Record(Result{Endpoint: "GET /missing", Protocol: HTTP, StatusCode: 200, Checked: true, Failure: errors.New("unexpected status 200"), Duration: time.Millisecond})
+	r.Record(Result{Endpoint: "GET /missing", Protocol: HTTP, StatusCode: 200, Checked: true, Failure: errors.New("unexpected status 200"), Duration: time.Millisecond})
+
+	summary := r.Summary()
+
+	assert.Equal(t, Counts{Sent: 3, Succeeded: 1, Failed: 2}, summary.Counts)
+	require.Equal(t, 1, len(summary.Endpoints))
+	assert.Equal(t, map[string]int{"404": 1, "200": 2}, summary.Endpoints[0].StatusCodes)
+	assert.Equal(t, map[string]int{"unexpected status 200": 2}, summary.Endpoints[0].Failures)
+}
+
+func TestRecorder_Targets(t *testing.T) {
+	var observed []Result
+	r := NewRecorder(0, ForTarget("cache", observerFunc(func(result Result) { observed = append(observed, result) })))
+	r.Record(Result{Target: "app", Endpoint: "GET /ping", Protocol: HTTP, StatusCode: 200, Duration: time.Millisecond})
+	r.Record(Result{Target: "cache", Endpoint: "GET /ping", Protocol: HTTP, StatusCode: 503, Duration: time.Millisecond})
+
+	summary := r.Summary()
+	assert.Equal(t, Counts{Sent: 2, Succeeded: 1, Failed: 1}, summary.Counts)
+	require.Equal(t, 2, len(summary.Endpoints), "endpoints with the same name are kept apart by target")
+	assert.Equal(t, "app", summary.Endpoints[0].Target)
+	assert.Equal(t, "cache", summary.Endpoints[1].Target)
+
+	cache := r.TargetSummary("cache")
+	assert.Equal(t, Counts{Sent: 1, Failed: 1}, cache.Counts)
+	require.Equal(t, 1, len(cache.Endpoints))
+	assert.Equal(t, "cache", cache.Endpoints[0].Target)
+
+	require.Equal(t, 1, len(observed))
+	assert.Equal(t, "cache", observed[0].Target)
+}
+
+type observerFunc func(result Result)
+
+func (f observerFunc) Observe(result Result) {
+	f(result)
+}
+
+func TestRecorder_ConcurrentRecords(t *testing.T) {
+	r := NewRecorder(0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				r.Record(Result{Endpoint: "GET /ping", Protocol: HTTP, StatusCode: 200})
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1000, r.Summary().Sent)
+}
+
+func TestRecorder_Percentiles(t *testing.T) {
+	r := NewRecorder(0)
+	for i := 100; i >= 1; i-- {
+		r.Record(Result{Endpoint: "GET /ping", Protocol: HTTP, StatusCode: 200, Duration: time.Duration(i) * time.Millisecond})
+	}
+	// requests without a response do not have a latency
+	r.Record(Result{Endpoint: "GET /ping", Protocol: HTTP, Err: errors.New("timeout"), Duration: time.Second})
+
+	summary := r.Summary()
+
+	expected := Latency{P50: 50 * time.Millisecond, P90: 90 * time.Millisecond, P99: 99 * time.Millisecond, Max: 100 * time.Millisecond}
+	assert.Equal(t, expected, summary.Latency)
+	assert.Equal(t, expected, summary.Endpoints[0].Latency)
+}
+
+// recordFasterTarget records the results of a target which gets faster: 100ms during the first 10 seconds, 20ms during the last 10 seconds.
+func recordFasterTarget(r *Recorder) {
+	for second := 0; second < 30; second++ {
+		d := 100 * time.Millisecond
+		if second >= 10 {
+			d = 50 * time.Millisecond
+		}
+		if second >= 20 {
+			d = 20 * time.Millisecond
+		}
+		r.recordAt(Result{Endpoint: "GET /ping", Protocol: HTTP, StatusCode: 200, Duration: d}, time.Duration(second)*time.Second)
+	}
+}
+
+func TestRecorder_Windows(t *testing.T) {
+	r := NewRecorder(10 * time.Second)
+	recordFasterTarget(r)
+
+	summary := r.Summary()
+
+	assert.Equal(t, 10*time.Second, summary.WindowSize)
+	assert.Equal(t, 10, summary.First.Sent)
+	assert.Equal(t, 100*time.Millisecond, summary.First.Latency.P50)
+	assert.Equal(t, 10, summary.Last.Sent)
+	assert.Equal(t, 20*time.Millisecond, summary.Last.Latency.P50)
+	assert.Equal(t, summary.Last, summary.Endpoints[0].Last)
+
+	// windows cannot be longer than half of the warmup
+	r = NewRecorder(time.Minute)
+	recordFasterTarget(r)
+	assert.Equal(t, 14500*time.Millisecond, r.Summary().WindowSize)
+}
+
+func TestRecorder_BoundedMemory(t *testing.T) {
+	r := NewRecorder(10 * time.Second)
+	// an hour at 100 requests per second, with latencies between 1ms and 1s
+	for i := 0; i < 360000; i++ {
+		d := time.Duration(1+i%1000) * time.Millisecond
+		r.recordAt(Result{Endpoint: "GET /ping", Protocol: HTTP, StatusCode: 200, Duration: d}, time.Duration(i)*10*time.Millisecond)
+	}
+
+	e := r.endpoints[0]
+	assert.LessOrEqual(t, len(e.seconds), 21, "only the seconds of the first and last windows are kept")
+	assert.LessOrEqual(t, len(e.latency.bins), 6*subBins, "latencies are counted in a bounded number of bins")
+
+	summary := r.Summary()
+	assert.Equal(t, 360000, summary.Sent)
+	assert.Equal(t, 1000, summary.First.Sent)
+	assert.Equal(t, 1000, summary.Last.Sent)
+	assert.InEpsilon(t, float64(500*time.Millisecond), float64(summary.Latency.P50), 1.0/subBins)
+	assert.InEpsilon(t, float64(990*time.Millisecond), float64(summary.Latency.P99), 1.0/subBins)
+	assert.Equal(t, time.Second, summary.Latency.Max)
+}
+
+func TestSummary_Table(t *testing.T) {
+	r := NewRecorder(time.Second)
+	r.recordAt(Result{Endpoint: "GET /ping", Protocol: HTTP, StatusCode: 200, Duration: 100 * time.Millisecond}, 0)
+	r.recordAt(Result{Endpoint: "GET /ping", Protocol: HTTP, StatusCode: 503, Duration: 20 * time.Millisecond}, 2*time.Second)
+	r.recordAt(Result{Endpoint: "health/ping", Protocol: GRPC, Err: errors.New("timeout")}, 2*time.Second)
+
+	expected := `PROTOCOL  ENDPOINT     REQUESTS  ERRORS  STATUS CODES    P50    P90     P99     MAX
+http      GET /ping    2         0       200: 1, 503: 1  20 ms  100 ms  100 ms  100 ms
+grpc      health/ping  1         1       -               0 ms   0 ms    0 ms    0 ms
+          TOTAL        3         1                       20 ms  100 ms  100 ms  100 ms
+
+PROTOCOL  ENDPOINT     FIRST 1s P50  LAST 1s P50  CHANGE  FIRST 1s P99  LAST 1s P99  CHANGE
+http      GET /ping    100 ms        20 ms        -80%    100 ms        20 ms        -80%
+grpc      health/ping  0 ms          0 ms         -       0 ms          0 ms         -
+          TOTAL        100 ms        20 ms        -80%    100 ms        20 ms        -80%
+`
+	assert.Equal(t, expected, r.Summary().Table())
+}
+
+func TestThresholds_Check(t *testing.T) {
+	summary := Summary{
+		Counts: Counts{Sent: 9, Succeeded: 8, Failed: 1, Errors: 1},
+		Endpoints: []Endpoint{
+			{Name: "GET /ping", Protocol: HTTP, Counts: Counts{Sent: 7, Succeeded: 7}},
+			{Name: "health/ping", Protocol: GRPC, Counts: Counts{Sent: 2, Succeeded: 1, Failed: 1, Errors: 1}},
+		},
+		Latency:    Latency{P99: 300 * time.Millisecond},
+		WindowSize: 10 * time.Second,
+		Last:       Window{Latency: Latency{P99: 120 * time.Millisecond}},
+	}
+
+	assert.Empty(t, Thresholds{MaxErrorRate: 1}.Check(summary))
+	assert.Empty(t, Thresholds{MaxErrorRate: 0.2, MinSuccessesPerEndpoint: 1, MaxP99: 150 * time.Millisecond}.Check(summary))
+	assert.Equal(t, []string{
+		"error rate 20.0% is above 10.0%",
+		"grpc health/ping had 1 successful requests, below 5",
+		"p99 latency of the last 10s is 120ms, above 100ms",
+	}, Thresholds{MaxErrorRate: 0.1, MinSuccessesPerEndpoint: 5, MaxP99: 100 * time.Millisecond}.Check(summary))
+
+	summary.WindowSize = 0
+	assert.Equal(t, []string{"p99 latency of the warmup is 300ms, above 200ms"}, Thresholds{MaxErrorRate: 1, MaxP99: 200 * time.Millisecond}.Check(summary))
+}
+
+func TestThresholds_EndpointWithoutResults(t *testing.T) {
+	r := NewRecorder(0)
+	r.AddEndpoint("", HTTP, "GET /ping")
+	r.AddEndpoint("", GRPC, "health/ping")
+	r.AddEndpoint("cache", HTTP, "GET /cached")
+	r.Record(Result{Endpoint: "GET /ping", Protocol: HTTP, StatusCode: 200, Duration: time.Millisecond})
+
+	summary := r.Summary()
+	require.Equal(t, 3, len(summary.Endpoints))
+	assert.Equal(t, Counts{}, summary.Endpoints[1].Counts)
+	assert.Equal(t, []string{
+		"grpc health/ping had 0 successful requests, below 1",
+		"http GET /cached had 0 successful requests, below 1",
+	}, Thresholds{MaxErrorRate: 1, MinSuccessesPerEndpoint: 1}.Check(summary))
+
+	// a target without any result is still summarised
+	summary = r.TargetSummary("cache")
+	require.Equal(t, 1, len(summary.Endpoints))
+	assert.Equal(t, []string{"http GET /cached had 0 successful requests, below 1"}, Thresholds{MaxErrorRate: 1, MinSuccessesPerEndpoint: 1}.Check(summary))
+}
+
+func TestThresholds_Validate(t *testing.T) {
+	assert.NoError(t, Thresholds{MaxErrorRate: 0.5, MinSuccessesPerEndpoint: 1, MaxP99: time.Second}.Validate())
+	assert.Error(t, Thresholds{MaxErrorRate: 1.5}.Validate())
+	assert.Error(t, Thresholds{MaxErrorRate: 1, MinSuccessesPerEndpoint: -1}.Validate())
+	assert.Error(t, Thresholds{MaxErrorRate: 1, MaxP99: -time.Second}.Validate())
+}
@@ -0,0 +1,90 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package stats
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Table returns the summary as a human-readable table with one row per endpoint.
+// If windows were requested a second table compares the latencies of the first and last windows.
+func (s Summary) Table() string {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "PROTOCOL\tENDPOINT\tREQUESTS\tERRORS\tSTATUS CODES\tP50\tP90\tP99\tMAX")
+	for _, e := range s.Endpoints {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\n", e.Protocol, e.Name, e.Sent+e.Errors, e.Errors, statusCodes(e.StatusCodes), latencyColumns(e.Latency))
+	}
+	fmt.Fprintf(w, "\tTOTAL\t%d\t%d\t\t%s\n", s.Sent+s.Errors, s.Errors, latencyColumns(s.Latency))
+
+	if s.WindowSize > 0 {
+		fmt.Fprintln(w)
+		window := s.WindowSize.Round(time.Millisecond)
+		fmt.Fprintf(w, "PROTOCOL\tENDPOINT\tFIRST %s P50\tLAST %s P50\tCHANGE\tFIRST %s P99\tLAST %s P99\tCHANGE\n", window, window, window, window)
+		for _, e := range s.Endpoints {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", e.Protocol, e.Name, comparisonColumns(e.First, e.Last))
+		}
+		fmt.Fprintf(w, "\tTOTAL\t%s\n", comparisonColumns(s.First, s.Last))
+	}
+
+	w.Flush()
+	return b.String()
+}
+
+func latencyColumns(l Latency) string {
+	return fmt.Sprintf("%s\t%s\t%s\t%s", milliseconds(l.P50), milliseconds(l.P90), milliseconds(l.P99), milliseconds(l.Max))
+}
+
+func comparisonColumns(first Window, last Window) string {
+	return fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s",
+		milliseconds(first.Latency.P50), milliseconds(last.Latency.P50), change(first.Latency.P50, last.Latency.P50),
+		milliseconds(first.Latency.P99), milliseconds(last.Latency.P99), change(first.Latency.P99, last.Latency.P99))
+}
+
+func milliseconds(d time.Duration) string {
+	return fmt.Sprintf("%d ms", d.Milliseconds())
+}
+
+// change returns the relative change between two latencies, e.g. -40% if the target got faster.
+func change(before time.Duration, after time.Duration) string {
+	if before == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%+.0f%%", (float64(after)-float64(before))/float64(before)*100)
+}
+
+// statusCodes formats the number of responses by status, e.g. `200: 10, 503: 2`.
+func statusCodes(codes map[string]int) string {
+	if len(codes) == 0 {
+		return "-"
+	}
+	keys := make([]string, 0, len(codes))
+	for code := range codes {
+		keys = append(keys, code)
+	}
+	sort.Strings(keys)
+
+	formatted := make([]string, 0, len(keys))
+	for _, code := range keys {
+		formatted = append(formatted, fmt.Sprintf("%s: %d", code, codes[code]))
+	}
+	return strings.Join(formatted, ", ")
+}
@@ -0,0 +1,76 @@
+//Copyright 2024 Expedia, Inc.
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+
+package stats
+
+import (
+	"fmt"
+	"time"
+)
+
+// Thresholds are the limits a warmup must stay within for the target to be considered warm.
+type Thresholds struct {
+	// MaxErrorRate is the maximum share, between 0 and 1, of requests which failed or did not get a response. 1 allows any rate.
+	MaxErrorRate float64
+	// MinSuccessesPerEndpoint is the minimum number of successful requests for each endpoint. 0 disables the threshold.
+	MinSuccessesPerEndpoint int
+	// MaxP99 is the maximum p99 latency of the last window of the warmup, or of the whole warmup if there are no windows.
+	// 0 disables the threshold.
+	MaxP99 time.Duration
+}
+
+// Validate checks that the thresholds are within their allowed ranges.
+func (t Thresholds) Validate() error {
+	if t.MaxErrorRate < 0 || t.MaxErrorRate > 1 {
+		return fmt.Errorf("max error rate must be between 0 and 100%%, got %g%%", t.MaxErrorRate*100)
+	}
+	if t.MinSuccessesPerEndpoint < 0 {
+		return fmt.Errorf("min successes per request cannot be negative, got %d", t.MinSuccessesPerEndpoint)
+	}
+	if t.MaxP99 < 0 {
+		return fmt.Errorf("max p99 cannot be negative, got %v", t.MaxP99)
+	}
+	return nil
+}
+
+// Check returns the reasons why the summary does not meet the thresholds. It returns nothing if the summary meets them all.
+func (t Thresholds) Check(summary Summary) []string {
+	var reasons []string
+
+	if total := summary.Sent + summary.Errors; total > 0 {
+		rate := float64(summary.Failed+summary.Errors) / float64(total)
+		if rate > t.MaxErrorRate {
+			reasons = append(reasons, fmt.Sprintf("error rate %.1f%% is above %.1f%%", rate*100, t.MaxErrorRate*100))
+		}
+	}
+
+	if t.MinSuccessesPerEndpoint > 0 {
+		for _, endpoint := range summary.Endpoints {
+			if endpoint.Succeeded < t.MinSuccessesPerEndpoint {
+				reasons = append(reasons, fmt.Sprintf("%s %s had %d successful requests, below %d", endpoint.Protocol, endpoint.Name, endpoint.Succeeded, t.MinSuccessesPerEndpoint))
+			}
+		}
+	}
+
+	if t.MaxP99 > 0 {
+		p99, scope := summary.Latency.P99, "the warmup"
+		if summary.WindowSize > 0 {
+			p99, scope = summary.Last.Latency.P99, fmt.Sprintf("the last %v", summary.WindowSize)
+		}
+		if p99 > t.MaxP99 {
+			reasons = append(reasons, fmt.Sprintf("p99 latency of %s is %v, above %v", scope, p99, t.MaxP99))
+		}
+	}
+	return reasons
+}
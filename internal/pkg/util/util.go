@@ -33,3 +33,21 @@ func ToHeaders(headersFlag []string) map[string]string {
 	}
 	return headers
 }
+
+// MergeHeaders returns a new map with the headers and the overrides. Header names are compared case-insensitively,
+// so an override replaces any header with the same name regardless of its case.
+func MergeHeaders(headers map[string]string, overrides map[string]string) map[string]string {
+	merged := make(map[string]string, len(headers)+len(overrides))
+	for k, v := range headers {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		for existing := range merged {
+			if strings.EqualFold(existing, k) {
+				delete(merged, existing)
+			}
+		}
+		merged[k] = v
+	}
+	return merged
+}
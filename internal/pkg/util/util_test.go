@@ -59,3 +59,19 @@ func Test_HeadersWithMultipleSeparatorsToHeaders(t *testing.T) {
 	assert.Equal(t, 1, len(headers))
 	assert.Equal(t, "some:strange:cookie", headers["Cookie"])
 }
+
+func Test_MergeHeaders(t *testing.T) {
+
+	headers := map[string]string{
+		"content-type": "application/json",
+		"Host":         "localhost",
+	}
+	overrides := map[string]string{
+		"Content-Type": "application/x-protobuf",
+	}
+
+	merged := MergeHeaders(headers, overrides)
+
+	assert.Equal(t, map[string]string{"Content-Type": "application/x-protobuf", "Host": "localhost"}, merged)
+	assert.Equal(t, "application/json", headers["content-type"], "Assert that the original headers are not modified")
+}
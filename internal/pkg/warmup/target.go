@@ -22,6 +22,8 @@ import (
 	"mittens/internal/pkg/util"
 	"net/http"
 	"time"
+
+	"google.golang.org/grpc/codes"
 )
 
 // TargetOptions represents target configurations set by the user.
@@ -86,7 +88,7 @@ func (t Target) WaitForReadinessProbe(maxReadinessWaitDurationInSeconds int, hea
 						continue
 					}
 					err1 := t.readinessGrpcClient.SendRequest(request.ServiceMethod, "", headers, false)
-					if err1.Err != nil {
+					if err1.Err != nil || err1.GrpcCode != codes.OK {
 						log.Printf("gRPC target not ready yet...")
 						continue
 					}
@@ -15,166 +15,781 @@
 package warmup
 
 import (
+	"context"
+	"errors"
 	"log"
-	"maps"
 	"math/rand"
+	"mittens/internal/pkg/accesslog"
+	"mittens/internal/pkg/convergence"
 	"mittens/internal/pkg/grpc"
 	"mittens/internal/pkg/http"
+	"mittens/internal/pkg/rampup"
+	"mittens/internal/pkg/ratelimit"
+	"mittens/internal/pkg/response"
 	"mittens/internal/pkg/safe"
+	"mittens/internal/pkg/scenario"
+	"mittens/internal/pkg/selection"
+	"mittens/internal/pkg/stats"
 	"mittens/internal/pkg/util"
 
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Warmup holds any information needed for the workers to send requests.
 type Warmup struct {
-	Target                   Target
-	Concurrency              int
-	HttpRequests             []http.Request
-	HttpHeaders              []string
-	GrpcRequests             []grpc.Request
+	// Name identifies the target in the recorded results when several targets are warmed up. It is empty for a single target.
+	Name         string
+	Target       Target
+	HttpRequests []http.Request
+	HttpHeaders  []string
+	GrpcRequests []grpc.Request
+	// Scenarios are sent by their own workers, alongside the HTTP and gRPC requests.
+	Scenarios []scenario.Scenario
+	// Replay holds requests read from access logs which are sent in their original order and at their original pace,
+	// by their own workers, rather than picked at random.
+	Replay []accesslog.Entry
+	// ReplaySpeed scales the pace of the replay, e.g. 2 sends the requests twice as fast as they were logged.
+	ReplaySpeed              float64
 	RequestDelayMilliseconds int
-	ConcurrencyTargetSeconds int
+	// RampUp sets how many workers send requests for each protocol over time. Target rates are scaled in proportion to the number of workers.
+	RampUp rampup.Profile
+	// RequestsPerSecond is the target rate across HTTP and gRPC requests. Zero means no limit.
+	RequestsPerSecond int
+	// HttpRequestsPerSecond is the target rate of HTTP requests. Zero means no limit.
+	HttpRequestsPerSecond int
+	// GrpcRequestsPerSecond is the target rate of gRPC requests. Zero means no limit.
+	GrpcRequestsPerSecond int
+	// MaxInFlight is the maximum number of requests waiting for a response, for each protocol, when requests are sent at a target
+	// rate. The workers are not used then, so that the rate does not depend on the latency of the target.
+	MaxInFlight int
+	// Convergence stops the warmup early once latencies have converged. If nil the warmup runs for its maximum duration.
+	// It must also observe the results of this target in the recorder passed to Run, see stats.ForTarget.
+	Convergence *convergence.Detector
 }
 
-func (w Warmup) GetWarmupHTTPRequests(maxDurationSeconds int) chan http.Request {
-	requestsChan := make(chan http.Request)
+// GetWarmupHTTPRequests returns a channel with the HTTP requests to be sent for a maximum of maxDurationSeconds or until the context is done.
+// Requests are only added to the channel once all the limiters allow it.
+func (w Warmup) GetWarmupHTTPRequests(ctx context.Context, maxDurationSeconds int, limiters ...*ratelimit.Limiter) chan http.Request {
+	finished := func(r http.Request) { w.finish(stats.HTTP, r.DisplayName()) }
+	return dispatchRequests(ctx, w.HttpRequests, func(r http.Request) (int, int) { return r.Weight, r.Count }, dataDone, finished, maxDurationSeconds, limiters)
+}
+
+// GetWarmupGrpcRequests returns a channel with the gRPC requests to be sent for a maximum of maxDurationSeconds or until the context is done.
+// Requests are only added to the channel once all the limiters allow it.
+func (w Warmup) GetWarmupGrpcRequests(ctx context.Context, maxDurationSeconds int, limiters ...*ratelimit.Limiter) chan grpc.Request {
+	finished := func(r grpc.Request) { w.finish(stats.GRPC, r.DisplayName()) }
+	return dispatchRequests(ctx, w.GrpcRequests, func(r grpc.Request) (int, int) { return r.Weight, r.Count }, nil, finished, maxDurationSeconds, limiters)
+}
+
+// dispatchRequests creates a goroutine that continuously adds requests to a channel for a maximum of maxDurationSeconds or until the context is done.
+// Requests are picked according to their weight and count, until done returns true for them if it is set, and finished is called with
+// the requests which will no longer be picked. The channel is closed once the time is up or there are no requests left to send.
+func dispatchRequests[T any](parent context.Context, requests []T, weightAndCount func(T) (int, int), done func(T) bool, finished func(T), maxDurationSeconds int, limiters []*ratelimit.Limiter) chan T {
+	requestsChan := make(chan T)
 
-	// create a goroutine that continuously adds requests to a channel for a maximum of maxDurationSeconds
 	go safe.Do(func() {
-		if len(w.HttpRequests) == 0 {
-			close(requestsChan)
-			return
-		}
-		timeout := time.After(time.Duration(maxDurationSeconds) * time.Second)
+		defer close(requestsChan)
+
+		picker := selection.NewPicker(requests, weightAndCount).UntilDone(done).OnFinished(finished)
+		ctx, cancel := context.WithTimeout(parent, time.Duration(maxDurationSeconds)*time.Second)
+		defer cancel()
 
 		for {
+			for _, limiter := range limiters {
+				if err := limiter.Wait(ctx); err != nil {
+					return
+				}
+			}
+			request, ok := picker.Next()
+			if !ok {
+				return
+			}
 			select {
-			case <-timeout:
-				close(requestsChan)
+			case <-ctx.Done():
 				return
-			default:
-				number := rand.Intn(len(w.HttpRequests))
-				requestsChan <- w.HttpRequests[number]
+			case requestsChan <- request:
 			}
 		}
 	})
 	return requestsChan
 }
 
-// FIXME: make this generic and remove duplication
-func (w Warmup) GetWarmupGrpcRequests(maxDurationSeconds int) chan grpc.Request {
-	requestsChan := make(chan grpc.Request)
+// dispatchReplay creates a goroutine that adds the replayed requests to a channel in the order in which they were logged, for a maximum of
+// maxDurationSeconds or until the context is done. Each request is added once as much time has passed since the first one as when it was logged,
+// scaled by the speed, and once all the limiters allow it. The log starts over once every request has been added. Requests whose datasets
+// have no rows left are skipped and passed to finished, and the channel is closed once every request is skipped.
+func dispatchReplay(parent context.Context, entries []accesslog.Entry, speed float64, finished func(http.Request), maxDurationSeconds int, limiters []*ratelimit.Limiter) chan http.Request {
+	requestsChan := make(chan http.Request)
 
-	// create a goroutine that continuously adds requests to a channel for a maximum of maxDurationSeconds
 	go safe.Do(func() {
-		if len(w.GrpcRequests) == 0 {
-			close(requestsChan)
-			return
-		}
-		timeout := time.After(time.Duration(maxDurationSeconds) * time.Second)
+		defer close(requestsChan)
 
+		ctx, cancel := context.WithTimeout(parent, time.Duration(maxDurationSeconds)*time.Second)
+		defer cancel()
+
+		if speed <= 0 {
+			speed = 1
+		}
+		first := entries[0].Time
 		for {
-			select {
-			case <-timeout:
-				close(requestsChan)
+			start := time.Now()
+			sent := 0
+			for _, entry := range entries {
+				if dataDone(entry.Request) {
+					finished(entry.Request)
+					continue
+				}
+				// entries without a time, or logged before the first one, are sent straight away
+				if offset := entry.Time.Sub(first); offset > 0 {
+					if wait := time.Duration(float64(offset)/speed) - time.Since(start); wait > 0 {
+						select {
+						case <-ctx.Done():
+							return
+						case <-time.After(wait):
+						}
+					}
+				}
+				for _, limiter := range limiters {
+					if err := limiter.Wait(ctx); err != nil {
+						return
+					}
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case requestsChan <- entry.Request:
+					sent++
+				}
+			}
+			if sent == 0 {
 				return
-			default:
-				number := rand.Intn(len(w.GrpcRequests))
-				requestsChan <- w.GrpcRequests[number]
 			}
 		}
 	})
 	return requestsChan
 }
 
-// Run sends requests to the target using goroutines.
-func (w Warmup) Run(hasHttpRequests bool, hasGrpcRequests bool, maxDurationSeconds int, requestsSentCounter *int) {
+// dataDone returns whether the datasets of an HTTP request have no rows left, in which case it is no longer sent.
+func dataDone(r http.Request) bool {
+	return r.Data != nil && r.Data.Done()
+}
+
+// finish tells the convergence detector, if any, that an endpoint is no longer sent so that it does not wait for its latencies.
+func (w Warmup) finish(protocol string, endpoint string) {
+	if w.Convergence != nil {
+		w.Convergence.Finish(protocol, endpoint)
+	}
+}
+
+// finishScenario finishes the steps of a scenario which is no longer sent.
+func (w Warmup) finishScenario(s scenario.Scenario) {
+	for _, step := range s.Steps {
+		w.finish(step.Protocol(), step.DisplayName(s.Name))
+	}
+}
+
+// newLimiter returns a limiter for the given rate, or nil if the rate is not limited.
+func newLimiter(requestsPerSecond int) *ratelimit.Limiter {
+	if requestsPerSecond <= 0 {
+		return nil
+	}
+	return ratelimit.NewLimiter(float64(requestsPerSecond))
+}
+
+// requestDelay returns the delay between the steps of scenarios. The delay is ignored if the rate is limited since the limiter
+// already spaces the requests.
+func (w Warmup) requestDelay(limiters ...*ratelimit.Limiter) int {
+	if limited(limiters...) {
+		return 0
+	}
+	return w.RequestDelayMilliseconds
+}
+
+// limited returns true if any of the limiters is set, in which case requests are sent at a target rate.
+func limited(limiters ...*ratelimit.Limiter) bool {
+	for _, limiter := range limiters {
+		if limiter != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// scenariosLimited returns true if every step of the scenarios is sent at a target rate.
+func (w Warmup) scenariosLimited(httpLimiter *ratelimit.Limiter, grpcLimiter *ratelimit.Limiter, globalLimiter *ratelimit.Limiter) bool {
+	for _, s := range w.Scenarios {
+		if s.HasHTTPSteps() && !limited(httpLimiter, globalLimiter) {
+			return false
+		}
+		if s.HasGrpcSteps() && !limited(grpcLimiter, globalLimiter) {
+			return false
+		}
+	}
+	return true
+}
+
+// inFlight bounds the number of requests sent at a target rate which are waiting for a response.
+type inFlight struct {
+	slots chan struct{}
+	// saturated is set once a request had to wait for a slot.
+	saturated *atomic.Bool
+}
+
+func newInFlight(size int, saturated *atomic.Bool) *inFlight {
+	return &inFlight{slots: make(chan struct{}, size), saturated: saturated}
+}
+
+func (f *inFlight) acquire() {
+	select {
+	case f.slots <- struct{}{}:
+	default:
+		f.saturated.Store(true)
+		f.slots <- struct{}{}
+	}
+}
+
+func (f *inFlight) release() {
+	<-f.slots
+}
+
+// sendAtRate sends each request of the channel in its own goroutine as soon as it is received, so that requests are sent at the rate
+// at which they are dispatched whatever the latency of the target. A request is only received once there is a free slot.
+// It returns once the channel is closed and every request got its response.
+func sendAtRate[T any](requests <-chan T, slots *inFlight, send func(T)) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		slots.acquire()
+		request, ok := <-requests
+		if !ok {
+			slots.release()
+			return
+		}
+		wg.Add(1)
+		go safe.Do(func() {
+			defer wg.Done()
+			defer slots.release()
+			send(request)
+		})
+	}
+}
+
+// rampUpInterval is how often the number of workers and the target rates are adjusted during the ramp-up.
+const rampUpInterval = 100 * time.Millisecond
+
+// Rate is a target rate of requests per second along with the rate which was achieved once the ramp-up was over.
+type Rate struct {
+	// Name is the flag which sets the rate, e.g. http-requests-per-second.
+	Name     string
+	Target   int
+	Achieved float64
+}
+
+// minAchievedShare is the share of a target rate below which the rate is considered as not reached.
+const minAchievedShare = 0.9
+
+// Run sends requests to the target using goroutines and adds their results to the recorder.
+// It returns the target rates which were set along with the rates which were achieved.
+func (w Warmup) Run(hasHttpRequests bool, hasGrpcRequests bool, maxDurationSeconds int, recorder *stats.Recorder) []Rate {
 	rand.Seed(time.Now().UnixNano()) // initialize seed only once to prevent deterministic/repeated calls every time we run
 
 	var wg sync.WaitGroup
-	var rampUpInterval = w.ConcurrencyTargetSeconds / w.Concurrency
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// every worker is started straight away, the gates decide how many of them can send requests at any point in time
+	workers := w.RampUp.MaxWorkers()
+	var gates []*rampup.Gate
+	// limiters holds the target rate of each limiter, which is scaled during the ramp-up
+	limiters := make(map[*ratelimit.Limiter]int)
+
+	// the global limiter is shared by HTTP and gRPC requests
+	globalLimiter := w.addLimiter(limiters, w.RequestsPerSecond)
+	// the protocol limiters are shared by the requests and the steps of the scenarios
+	httpLimiter := w.addLimiter(limiters, w.HttpRequestsPerSecond)
+	grpcLimiter := w.addLimiter(limiters, w.GrpcRequestsPerSecond)
+
+	w.addEndpoints(recorder)
+
+	// connect to gRPC server once and only if there are gRPC requests
+	// this is done before starting any worker as the workers share the target and its clients
+	var grpcConnected bool
+	if hasGrpcRequests || w.hasGrpcSteps() {
+		log.Print("gRPC client connecting...")
+		if connErr := w.Target.grpcClient.Connect(w.HttpHeaders); connErr != nil {
+			log.Printf("gRPC client connect error: %v", connErr)
+		} else {
+			grpcConnected = true
+		}
+	}
+
+	// requests sent at a target rate are each sent in their own goroutine, up to MaxInFlight at a time for each protocol
+	var saturated atomic.Bool
 
 	if hasHttpRequests {
-		for i := 1; i <= w.Concurrency; i++ {
-			waitForRampUp(rampUpInterval, i)
-			log.Printf("Spawning new go routine for HTTP requests")
+		// all the workers share the same channel so that requests with a count are sent exactly that many times
+		requests := w.GetWarmupHTTPRequests(ctx, maxDurationSeconds, httpLimiter, globalLimiter)
+		if limited(httpLimiter, globalLimiter) {
+			log.Printf("Sending HTTP requests at a target rate with up to %d request(s) in flight", w.MaxInFlight)
+			slots := newInFlight(w.MaxInFlight, &saturated)
 			wg.Add(1)
 			go safe.Do(func() {
-				w.HTTPWarmupWorker(&wg, w.GetWarmupHTTPRequests(maxDurationSeconds), w.HttpHeaders, w.RequestDelayMilliseconds, requestsSentCounter)
+				defer wg.Done()
+				sendAtRate(requests, slots, func(request http.Request) {
+					w.sendWarmupHTTPRequest(request, w.HttpHeaders, recorder)
+				})
 			})
+		} else {
+			gate := rampup.NewGate(w.RampUp.Workers(0))
+			gates = append(gates, gate)
+			for i := 0; i < workers; i++ {
+				log.Printf("Spawning new go routine for HTTP requests")
+				wg.Add(1)
+				worker := i
+				go safe.Do(func() {
+					w.HTTPWarmupWorker(&wg, gate, worker, requests, w.HttpHeaders, w.RequestDelayMilliseconds, recorder)
+				})
+			}
 		}
 	}
 
-	if hasGrpcRequests {
-		// connect to gRPC server once and only if there are gRPC requests
-		log.Print("gRPC client connecting...")
-		connErr := w.Target.grpcClient.Connect(w.HttpHeaders)
-
-		if connErr != nil {
-			log.Printf("gRPC client connect error: %v", connErr)
+	if hasGrpcRequests && grpcConnected {
+		requests := w.GetWarmupGrpcRequests(ctx, maxDurationSeconds, grpcLimiter, globalLimiter)
+		if limited(grpcLimiter, globalLimiter) {
+			log.Printf("Sending gRPC requests at a target rate with up to %d request(s) in flight", w.MaxInFlight)
+			slots := newInFlight(w.MaxInFlight, &saturated)
+			wg.Add(1)
+			go safe.Do(func() {
+				defer wg.Done()
+				sendAtRate(requests, slots, func(request grpc.Request) {
+					w.sendGrpcRequest(request, request.DisplayName(), w.HttpHeaders, false, nil, recorder)
+				})
+			})
 		} else {
-			for i := 1; i <= w.Concurrency; i++ {
-				waitForRampUp(rampUpInterval, i)
+			gate := rampup.NewGate(w.RampUp.Workers(0))
+			gates = append(gates, gate)
+			for i := 0; i < workers; i++ {
 				log.Printf("Spawning new go routine for gRPC requests")
 				wg.Add(1)
+				worker := i
+				go safe.Do(func() {
+					w.GrpcWarmupWorker(&wg, gate, worker, requests, w.HttpHeaders, w.RequestDelayMilliseconds, recorder)
+				})
+			}
+		}
+	}
+
+	if len(w.Replay) > 0 {
+		// the replay is paced by the times of the log, so the request delay does not apply
+		finished := func(r http.Request) { w.finish(stats.HTTP, r.DisplayName()) }
+		requests := dispatchReplay(ctx, w.Replay, w.ReplaySpeed, finished, maxDurationSeconds, []*ratelimit.Limiter{httpLimiter, globalLimiter})
+		if limited(httpLimiter, globalLimiter) {
+			slots := newInFlight(w.MaxInFlight, &saturated)
+			wg.Add(1)
+			go safe.Do(func() {
+				defer wg.Done()
+				sendAtRate(requests, slots, func(request http.Request) {
+					w.sendWarmupHTTPRequest(request, w.HttpHeaders, recorder)
+				})
+			})
+		} else {
+			gate := rampup.NewGate(w.RampUp.Workers(0))
+			gates = append(gates, gate)
+			for i := 0; i < workers; i++ {
+				log.Printf("Spawning new go routine for replayed HTTP requests")
+				wg.Add(1)
+				worker := i
+				go safe.Do(func() {
+					w.HTTPWarmupWorker(&wg, gate, worker, requests, w.HttpHeaders, 0, recorder)
+				})
+			}
+		}
+	}
+
+	if len(w.Scenarios) > 0 {
+		pacing := stepPacing{
+			httpLimiters:          []*ratelimit.Limiter{httpLimiter, globalLimiter},
+			grpcLimiters:          []*ratelimit.Limiter{grpcLimiter, globalLimiter},
+			httpDelayMilliseconds: w.requestDelay(httpLimiter, globalLimiter),
+			grpcDelayMilliseconds: w.requestDelay(grpcLimiter, globalLimiter),
+		}
+
+		// the steps are paced rather than the scenarios, which stop once the time is up even if they have steps left
+		scenarioCtx, scenarioCancel := context.WithTimeout(ctx, time.Duration(maxDurationSeconds)*time.Second)
+		defer scenarioCancel()
+		scenarios := dispatchRequests(scenarioCtx, w.Scenarios, func(s scenario.Scenario) (int, int) { return s.Weight, s.Count }, nil, w.finishScenario, maxDurationSeconds, nil)
+		// scenarios are only sent at a target rate if every one of their steps is, since the others must not be sent by more than the workers
+		if w.scenariosLimited(httpLimiter, grpcLimiter, globalLimiter) {
+			log.Printf("Sending scenarios at a target rate with up to %d scenario(s) in flight", w.MaxInFlight)
+			slots := newInFlight(w.MaxInFlight, &saturated)
+			wg.Add(1)
+			go safe.Do(func() {
+				defer wg.Done()
+				sendAtRate(scenarios, slots, func(s scenario.Scenario) {
+					w.runScenario(scenarioCtx, s, w.HttpHeaders, pacing, recorder)
+				})
+			})
+		} else {
+			gate := rampup.NewGate(w.RampUp.Workers(0))
+			gates = append(gates, gate)
+			for i := 0; i < workers; i++ {
+				log.Printf("Spawning new go routine for scenarios")
+				wg.Add(1)
+				worker := i
 				go safe.Do(func() {
-					w.GrpcWarmupWorker(&wg, w.GetWarmupGrpcRequests(maxDurationSeconds), w.HttpHeaders, w.RequestDelayMilliseconds, requestsSentCounter)
+					w.scenarioWarmupWorker(scenarioCtx, &wg, gate, worker, scenarios, w.HttpHeaders, pacing, recorder)
 				})
 			}
 		}
 	}
 
+	go safe.Do(func() {
+		w.rampUp(ctx, gates, limiters)
+	})
+	go safe.Do(func() {
+		w.stopWhenConverged(ctx, cancel)
+	})
+
 	wg.Wait()
+	return w.achievedRates(saturated.Load(), map[string]*ratelimit.Limiter{
+		"requests-per-second":      globalLimiter,
+		"http-requests-per-second": httpLimiter,
+		"grpc-requests-per-second": grpcLimiter,
+	}, limiters)
 }
 
-// HTTPWarmupWorker sends HTTP requests to the target using goroutines.
-func (w Warmup) HTTPWarmupWorker(wg *sync.WaitGroup, requests <-chan http.Request, headers []string, requestDelayMilliseconds int, requestsSentCounter *int) {
-	for request := range requests {
-		time.Sleep(time.Duration(requestDelayMilliseconds) * time.Millisecond)
+// addEndpoints adds every request and scenario step to the recorder, so that the summaries include the ones which get no results,
+// e.g. because the gRPC client could not connect or the warmup stopped before they were sent.
+func (w Warmup) addEndpoints(recorder *stats.Recorder) {
+	for _, r := range w.HttpRequests {
+		recorder.AddEndpoint(w.Name, stats.HTTP, r.DisplayName())
+	}
+	for _, r := range w.GrpcRequests {
+		recorder.AddEndpoint(w.Name, stats.GRPC, r.DisplayName())
+	}
+	for _, entry := range w.Replay {
+		recorder.AddEndpoint(w.Name, stats.HTTP, entry.Request.DisplayName())
+	}
+	for _, s := range w.Scenarios {
+		for _, step := range s.Steps {
+			recorder.AddEndpoint(w.Name, step.Protocol(), step.DisplayName(s.Name))
+		}
+	}
+}
 
-		headersMap := util.ToHeaders(headers)
-		// Overwrite Content-Encoding header if required
-		maps.Copy(headersMap, request.Headers)
+// achievedRates returns the rates achieved by the limiters, by the flag which set them, and warns about the target rates which
+// were not reached. saturated is true if requests had to wait for others to get a response, in which case the target is too slow
+// to reach the rate with MaxInFlight requests in flight.
+func (w Warmup) achievedRates(saturated bool, named map[string]*ratelimit.Limiter, targets map[*ratelimit.Limiter]int) []Rate {
+	var rates []Rate
+	for _, name := range []string{"requests-per-second", "http-requests-per-second", "grpc-requests-per-second"} {
+		limiter := named[name]
+		achieved, ok := limiter.Achieved()
+		if !ok {
+			continue
+		}
+		rate := Rate{Name: name, Target: targets[limiter], Achieved: achieved}
+		rates = append(rates, rate)
+		// the global rate is also held back by the protocol rates, which are checked on their own
+		capped := name == "requests-per-second" && (named["http-requests-per-second"] != nil || named["grpc-requests-per-second"] != nil)
+		if capped || achieved >= minAchievedShare*float64(rate.Target) {
+			continue
+		}
+		if saturated {
+			log.Printf("⚠️ Only %.1f of the %d requests per second set by %s were sent as %d request(s) were waiting for responses, raise max-in-flight-requests to reach the target rate", achieved, rate.Target, name, w.MaxInFlight)
+		} else {
+			log.Printf("⚠️ Only %.1f of the %d requests per second set by %s were sent", achieved, rate.Target, name)
+		}
+	}
+	return rates
+}
 
-		resp := w.Target.httpClient.SendRequest(request.Method, request.Path, headersMap, request.Body)
+// convergenceInterval is how often latencies are checked for convergence.
+const convergenceInterval = time.Second
 
-		if resp.Err != nil {
-			log.Printf("🔴 Error in request for %s: %v", request.Path, resp.Err)
-		} else {
-			*requestsSentCounter++
+// stopWhenConverged cancels the warmup once latencies have converged.
+// Latencies are only checked once the ramp-up is complete and a full window has passed since then, as well as the minimum duration.
+func (w Warmup) stopWhenConverged(ctx context.Context, cancel context.CancelFunc) {
+	if w.Convergence == nil {
+		return
+	}
+	start := time.Now()
+	earliest := w.RampUp.TotalDuration() + w.Convergence.Options.Window
+	if earliest < w.Convergence.Options.MinDuration {
+		earliest = w.Convergence.Options.MinDuration
+	}
 
-			if resp.StatusCode/100 == 2 {
-				log.Printf("🟢 %s response\t%d ms\t%v\t%s\t%s", resp.Type, resp.Duration/time.Millisecond, resp.StatusCode, request.Method, request.Path)
-			} else {
-				log.Printf("🔴 %s response\t%d ms\t%v\t%s\t%s", resp.Type, resp.Duration/time.Millisecond, resp.StatusCode, request.Method, request.Path)
+	ticker := time.NewTicker(convergenceInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if time.Since(start) < earliest {
+			continue
+		}
+		if w.Convergence.Converged(time.Now()) {
+			log.Printf("🏁 Latencies converged after %d second(s), stopping the warmup", int(time.Since(start).Seconds()))
+			cancel()
+			return
+		}
+	}
+}
+
+// addLimiter creates a limiter for the given rate and keeps track of its target rate. It returns nil if the rate is not limited.
+func (w Warmup) addLimiter(limiters map[*ratelimit.Limiter]int, requestsPerSecond int) *ratelimit.Limiter {
+	limiter := newLimiter(requestsPerSecond)
+	if limiter != nil {
+		limiters[limiter] = requestsPerSecond
+		// start at the rate of the first workers rather than the full rate
+		limiter.SetRate(float64(requestsPerSecond) * w.rampUpFraction(0))
+	}
+	return limiter
+}
+
+// rampUp adjusts the number of workers and the target rates until the ramp-up is complete or the context is done.
+func (w Warmup) rampUp(ctx context.Context, gates []*rampup.Gate, limiters map[*ratelimit.Limiter]int) {
+	start := time.Now()
+	ticker := time.NewTicker(rampUpInterval)
+	defer ticker.Stop()
+
+	current := 0
+	for {
+		elapsed := time.Since(start)
+		if workers := w.RampUp.Workers(elapsed); workers != current {
+			log.Printf("Ramp-up: %d worker(s) per protocol", workers)
+			current = workers
+			for _, gate := range gates {
+				gate.SetOpen(workers)
+			}
+			for limiter, requestsPerSecond := range limiters {
+				limiter.SetRate(float64(requestsPerSecond) * w.rampUpFraction(elapsed))
 			}
 		}
+		if elapsed >= w.RampUp.TotalDuration() {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// rampUpFraction returns the share of the workers that are active once elapsed has passed.
+func (w Warmup) rampUpFraction(elapsed time.Duration) float64 {
+	return float64(w.RampUp.Workers(elapsed)) / float64(w.RampUp.MaxWorkers())
+}
+
+// HTTPWarmupWorker sends HTTP requests to the target using goroutines.
+// The worker only picks a new request when the gate lets it through.
+func (w Warmup) HTTPWarmupWorker(wg *sync.WaitGroup, gate *rampup.Gate, worker int, requests <-chan http.Request, headers []string, requestDelayMilliseconds int, recorder *stats.Recorder) {
+	// there are no requests left once the channel is closed so the workers still waiting at the gate can exit
+	defer gate.Close()
+
+	for {
+		gate.Wait(worker)
+		request, ok := <-requests
+		if !ok {
+			break
+		}
+		time.Sleep(time.Duration(requestDelayMilliseconds) * time.Millisecond)
+
+		w.sendWarmupHTTPRequest(request, headers, recorder)
 	}
 	wg.Done()
 }
 
+// sendWarmupHTTPRequest sets the data placeholders of a warmup request, if any, and sends it.
+func (w Warmup) sendWarmupHTTPRequest(request http.Request, headers []string, recorder *stats.Recorder) {
+	// requests are recorded under their name before their data placeholders are set, so that all the rows are counted together
+	endpoint := request.DisplayName()
+	if request.Data != nil {
+		var err error
+		if request, err = request.WithData(); err != nil {
+			// the last rows may have been taken by other requests after the request was picked
+			if !errors.Is(err, http.ErrNoRowsLeft) {
+				log.Printf("🔴 Error in request for %s: %v", endpoint, err)
+			}
+			return
+		}
+	}
+	w.sendHTTPRequest(request, endpoint, headers, false, nil, recorder)
+}
+
 // GrpcWarmupWorker sends gRPC requests to the target using goroutines.
-func (w Warmup) GrpcWarmupWorker(wg *sync.WaitGroup, requests <-chan grpc.Request, headers []string, requestDelayMilliseconds int, requestsSentCounter *int) {
-	for request := range requests {
+// The worker only picks a new request when the gate lets it through.
+func (w Warmup) GrpcWarmupWorker(wg *sync.WaitGroup, gate *rampup.Gate, worker int, requests <-chan grpc.Request, headers []string, requestDelayMilliseconds int, recorder *stats.Recorder) {
+	// there are no requests left once the channel is closed so the workers still waiting at the gate can exit
+	defer gate.Close()
+
+	for {
+		gate.Wait(worker)
+		request, ok := <-requests
+		if !ok {
+			break
+		}
 		time.Sleep(time.Duration(requestDelayMilliseconds) * time.Millisecond)
 
-		resp := w.Target.grpcClient.SendRequest(request.ServiceMethod, request.Message, headers, false)
+		w.sendGrpcRequest(request, request.DisplayName(), headers, false, nil, recorder)
+	}
+	wg.Done()
+}
+
+// hasGrpcSteps returns true if any scenario sends gRPC requests.
+func (w Warmup) hasGrpcSteps() bool {
+	for _, s := range w.Scenarios {
+		if s.HasGrpcSteps() {
+			return true
+		}
+	}
+	return false
+}
 
-		if resp.Err != nil {
-			log.Printf("🔴 Error in request for %s: %v", request.ServiceMethod, resp.Err)
-		} else {
-			*requestsSentCounter++
-			log.Printf("🟢 %s response\t%d ms %s", resp.Type, resp.Duration/time.Millisecond, request.ServiceMethod)
+// stepPacing holds the limiters and delays applied before each step of a scenario, according to its protocol.
+type stepPacing struct {
+	httpLimiters          []*ratelimit.Limiter
+	grpcLimiters          []*ratelimit.Limiter
+	httpDelayMilliseconds int
+	grpcDelayMilliseconds int
+}
+
+// pace blocks until the limiters allow a request and the delay has passed. It returns false if the context is done first.
+func pace(ctx context.Context, limiters []*ratelimit.Limiter, delayMilliseconds int) bool {
+	for _, limiter := range limiters {
+		if err := limiter.Wait(ctx); err != nil {
+			return false
 		}
+	}
+	time.Sleep(time.Duration(delayMilliseconds) * time.Millisecond)
+	return ctx.Err() == nil
+}
+
+// scenarioWarmupWorker runs scenarios against the target using goroutines.
+// The worker only picks a new scenario when the gate lets it through.
+func (w Warmup) scenarioWarmupWorker(ctx context.Context, wg *sync.WaitGroup, gate *rampup.Gate, worker int, scenarios <-chan scenario.Scenario, headers []string, pacing stepPacing, recorder *stats.Recorder) {
+	// there are no scenarios left once the channel is closed so the workers still waiting at the gate can exit
+	defer gate.Close()
 
+	for {
+		gate.Wait(worker)
+		s, ok := <-scenarios
+		if !ok {
+			break
+		}
+		w.runScenario(ctx, s, headers, pacing, recorder)
 	}
 	wg.Done()
 }
 
-func waitForRampUp(rampUpInterval int, currentConcurrency int) {
-	if currentConcurrency > 1 && rampUpInterval > 0 {
-		time.Sleep(time.Duration(rampUpInterval) * time.Second)
+// runScenario sends the steps of a scenario one after the other. Each step sets its variables from its response.
+// The scenario stops at the first step which does not succeed since the later steps may need its variables.
+func (w Warmup) runScenario(ctx context.Context, s scenario.Scenario, headers []string, pacing stepPacing, recorder *stats.Recorder) {
+	variables := make(map[string]string)
+	extract := func(step scenario.Step) func(response.Response) error {
+		if len(step.Extractions) == 0 {
+			return nil
+		}
+		return func(resp response.Response) error {
+			return step.Extract(resp, variables)
+		}
+	}
+
+	for _, step := range s.Steps {
+		endpoint := step.DisplayName(s.Name)
+		var result stats.Result
+		if step.HTTP != nil {
+			if !pace(ctx, pacing.httpLimiters, pacing.httpDelayMilliseconds) {
+				return
+			}
+			request, err := step.HTTPRequest(variables)
+			if err != nil {
+				log.Printf("🔴 Error in request for %s: %v", endpoint, err)
+				return
+			}
+			result = w.sendHTTPRequest(request, endpoint, headers, step.NeedsBody(), extract(step), recorder)
+		} else {
+			if !pace(ctx, pacing.grpcLimiters, pacing.grpcDelayMilliseconds) {
+				return
+			}
+			result = w.sendGrpcRequest(step.GrpcRequest(variables), endpoint, headers, len(step.Extractions) > 0, extract(step), recorder)
+		}
+		if !result.Success() {
+			log.Printf("Scenario %s stopped after %s", s.Name, endpoint)
+			return
+		}
+	}
+}
+
+// sendHTTPRequest sends an HTTP request to the target, records its result under the given endpoint and logs it.
+// The body of the response is read if the expectation of the request needs it or if captureBody is set.
+// If set, extract is called with successful responses and an error it returns makes the request a failure.
+func (w Warmup) sendHTTPRequest(request http.Request, endpoint string, headers []string, captureBody bool, extract func(response.Response) error, recorder *stats.Recorder) stats.Result {
+	// request headers (including Content-Encoding if required) take precedence over the global ones
+	headersMap := util.MergeHeaders(util.ToHeaders(headers), request.Headers)
+	options := http.RequestOptions{
+		Timeout:     time.Duration(request.TimeoutMilliseconds) * time.Millisecond,
+		CaptureBody: captureBody || request.Expect.NeedsBody(),
+	}
+
+	resp := w.Target.httpClient.SendRequestWithOptions(request.Method, request.Path, headersMap, request.Body, options)
+	result := stats.Result{Target: w.Name, Endpoint: endpoint, Protocol: stats.HTTP, StatusCode: resp.StatusCode, Err: resp.Err, Duration: resp.Duration}
+	if resp.Err == nil && request.Expect != nil {
+		result.Checked = true
+		result.Failure = request.Expect.Check(resp)
+	}
+	if extract != nil && result.Success() {
+		result.Checked = true
+		result.Failure = extract(resp)
+	}
+	recorder.Record(result)
+
+	switch {
+	case resp.Err != nil:
+		log.Printf("🔴 Error in request for %s: %v", endpoint, resp.Err)
+	case result.Failure != nil:
+		log.Printf("🔴 %s response\t%d ms\t%v\t%s\t%v", resp.Type, resp.Duration/time.Millisecond, resp.StatusCode, endpoint, result.Failure)
+	case result.Success():
+		log.Printf("🟢 %s response\t%d ms\t%v\t%s", resp.Type, resp.Duration/time.Millisecond, resp.StatusCode, endpoint)
+	default:
+		log.Printf("🔴 %s response\t%d ms\t%v\t%s", resp.Type, resp.Duration/time.Millisecond, resp.StatusCode, endpoint)
+	}
+	return result
+}
+
+// sendGrpcRequest sends a gRPC request to the target, records its result under the given endpoint and logs it.
+// The headers and last message of the response are kept if captureResponse is set.
+// If set, extract is called with successful responses and an error it returns makes the request a failure.
+func (w Warmup) sendGrpcRequest(request grpc.Request, endpoint string, headers []string, captureResponse bool, extract func(response.Response) error, recorder *stats.Recorder) stats.Result {
+	options := grpc.RequestOptions{MaxResponses: request.MaxResponses, CaptureResponse: captureResponse}
+	resp := w.Target.grpcClient.SendRequestWithOptions(request.ServiceMethod, request.AllMessages(), headers, options)
+	result := stats.Result{Target: w.Name, Endpoint: endpoint, Protocol: stats.GRPC, GrpcCode: resp.GrpcCode, Err: resp.Err, Duration: resp.Duration}
+	if resp.Err == nil && request.Expect != nil {
+		result.Checked = true
+		result.Failure = request.Expect.Check(resp)
+	}
+	if extract != nil && result.Success() {
+		result.Checked = true
+		result.Failure = extract(resp)
+	}
+	recorder.Record(result)
+
+	switch {
+	case resp.Err != nil:
+		log.Printf("🔴 Error in request for %s: %v", endpoint, resp.Err)
+	case result.Failure != nil:
+		log.Printf("🔴 %s response\t%d ms\t%v\t%s\t%v", resp.Type, resp.Duration/time.Millisecond, resp.GrpcCode, endpoint, result.Failure)
+	case result.Success():
+		log.Printf("🟢 %s response\t%d ms\t%v\t%s\t%d message(s) sent, %d received", resp.Type, resp.Duration/time.Millisecond, resp.GrpcCode, endpoint, resp.MessagesSent, resp.MessagesReceived)
+	default:
+		log.Printf("🔴 %s response\t%d ms\t%v\t%s\t%s", resp.Type, resp.Duration/time.Millisecond, resp.GrpcCode, endpoint, resp.GrpcMessage)
 	}
+	return result
 }
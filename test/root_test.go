@@ -17,13 +17,19 @@ package test
 import (
 	"compress/gzip"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"mittens/cmd"
 	"mittens/fixture"
 	"mittens/internal/pkg/probe"
+	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -130,6 +136,88 @@ func TestWarmupFailReadinessIfNoRequestsAreSentToTarget(t *testing.T) {
 	assert.False(t, readyFileExists)
 }
 
+func TestWarmupFailReadinessIfErrorRateIsTooHigh(t *testing.T) {
+	t.Cleanup(func() {
+		cleanup()
+	})
+
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+	os.Args = []string{
+		"mittens",
+		"-file-probe-enabled=true",
+		"-http-requests=get:/hello-world",
+		"-http-requests=get:/missing",
+		fmt.Sprintf("-target-http-port=%d", mockHttpServerPort),
+		fmt.Sprintf("-target-readiness-port=%d", mockHttpServerPort),
+		"-target-readiness-http-path=/health",
+		"-max-duration-seconds=2",
+		"-exit-after-warmup=true",
+		"-fail-readiness=true",
+		"-fail-readiness-max-error-rate=10",
+		"-report-file=" + reportFile,
+	}
+
+	cmd.CreateConfig()
+	cmd.RunCmdRoot()
+
+	readyFileExists, err := probe.FileExists("ready")
+	require.NoError(t, err)
+	assert.False(t, readyFileExists)
+
+	content, err := os.ReadFile(reportFile)
+	require.NoError(t, err)
+	var report struct {
+		Verdict string   `json:"verdict"`
+		Reasons []string `json:"reasons"`
+	}
+	require.NoError(t, json.Unmarshal(content, &report))
+	assert.Equal(t, "not-ready", report.Verdict)
+	require.Equal(t, 1, len(report.Reasons))
+	assert.Contains(t, report.Reasons[0], "is above 10.0%")
+}
+
+func TestWarmupFailReadinessIfARequestGetsNoResults(t *testing.T) {
+	t.Cleanup(func() {
+		cleanup()
+	})
+
+	// the gRPC client cannot connect to a port that doesnt exist (9999), so the gRPC request is never sent
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+	os.Args = []string{
+		"mittens",
+		"-file-probe-enabled=true",
+		"-http-requests=get:/hello-world",
+		"-grpc-requests=grpc.testing.TestService/EmptyCall",
+		fmt.Sprintf("-target-http-port=%d", mockHttpServerPort),
+		"-target-grpc-port=9999",
+		fmt.Sprintf("-target-readiness-port=%d", mockHttpServerPort),
+		"-target-readiness-http-path=/health",
+		"-max-duration-seconds=2",
+		"-exit-after-warmup=true",
+		"-fail-readiness=true",
+		"-fail-readiness-min-successes-per-request=1",
+		"-report-file=" + reportFile,
+	}
+
+	cmd.CreateConfig()
+	cmd.RunCmdRoot()
+
+	assert.Greater(t, httpInvocations, 0, "Assert that the HTTP request was sent")
+	readyFileExists, err := probe.FileExists("ready")
+	require.NoError(t, err)
+	assert.False(t, readyFileExists)
+
+	content, err := os.ReadFile(reportFile)
+	require.NoError(t, err)
+	var report struct {
+		Verdict string   `json:"verdict"`
+		Reasons []string `json:"reasons"`
+	}
+	require.NoError(t, json.Unmarshal(content, &report))
+	assert.Equal(t, "not-ready", report.Verdict)
+	assert.Equal(t, []string{"grpc grpc.testing.TestService/EmptyCall had 0 successful requests, below 1"}, report.Reasons)
+}
+
 func TestHttp(t *testing.T) {
 	t.Cleanup(func() {
 		cleanup()
@@ -161,6 +249,146 @@ func TestHttp(t *testing.T) {
 	assert.True(t, readyFileExists)
 }
 
+func TestHttpAndGrpcOverUnixSockets(t *testing.T) {
+	t.Cleanup(func() {
+		cleanup()
+	})
+
+	dir := t.TempDir()
+	httpSocket := filepath.Join(dir, "http.sock")
+	grpcSocket := filepath.Join(dir, "grpc.sock")
+	var socketInvocations int32
+	httpServer := fixture.StartHttpTargetTestServerOnSocket([]fixture.PathResponseHandler{{Path: "/hello-world", PathHandlerFunc: func(rw http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&socketInvocations, 1)
+	}}}, httpSocket)
+	defer httpServer.Close()
+	callStats := fixture.NewCallStats()
+	grpcServer := fixture.StartGrpcTargetTestServerOnSocket(callStats, grpcSocket)
+	defer grpcServer.GracefulStop()
+
+	os.Args = []string{
+		"mittens",
+		"-file-probe-enabled=true",
+		"-target-http-host=unix://" + httpSocket,
+		"-target-readiness-http-host=unix://" + httpSocket,
+		"-target-http-protocol=h2c",
+		"-target-grpc-host=unix://" + grpcSocket,
+		"-target-insecure=true",
+		"-http-requests=get:/hello-world",
+		"-grpc-requests=grpc.testing.TestService/EmptyCall",
+		"-exit-after-warmup=true",
+		"-target-readiness-http-path=/health",
+		"-max-duration-seconds=2",
+	}
+
+	cmd.CreateConfig()
+	cmd.RunCmdRoot()
+
+	assert.Greater(t, atomic.LoadInt32(&socketInvocations), int32(0), "Assert that some calls were made over the HTTP socket")
+	assert.GreaterOrEqual(t, len(callStats.StatusesByMethod["/grpc.testing.TestService/EmptyCall"]), 1, "Assert that some calls were made over the gRPC socket")
+
+	readyFileExists, err := probe.FileExists("ready")
+	require.NoError(t, err)
+	assert.True(t, readyFileExists)
+}
+
+func TestMultipleTargets(t *testing.T) {
+	t.Cleanup(func() {
+		cleanup()
+	})
+
+	var cacheInvocations int32
+	cacheServer, cachePort := fixture.StartHttpTargetTestServer([]fixture.PathResponseHandler{{Path: "/cached", PathHandlerFunc: func(rw http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&cacheInvocations, 1)
+	}}})
+	defer cacheServer.Close()
+
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+	os.Args = []string{
+		"mittens",
+		"-file-probe-enabled=true",
+		fmt.Sprintf("-target-http-port=%d", mockHttpServerPort),
+		fmt.Sprintf("-target-readiness-port=%d", mockHttpServerPort),
+		"-target-readiness-http-path=/health",
+		`-targets={"name": "app", "http-requests": ["get:/hello-world"]}`,
+		fmt.Sprintf(`-targets={"name": "cache", "http-port": %d, "http-requests": ["get:/cached"]}`, cachePort),
+		"-exit-after-warmup=true",
+		"-max-duration-seconds=2",
+		"-fail-readiness=true",
+		"-report-file=" + reportFile,
+	}
+
+	cmd.CreateConfig()
+	cmd.RunCmdRoot()
+
+	assert.Greater(t, httpInvocations, 0, "Assert that some calls were made to the app")
+	assert.Greater(t, atomic.LoadInt32(&cacheInvocations), int32(0), "Assert that some calls were made to the cache")
+
+	content, err := os.ReadFile(reportFile)
+	require.NoError(t, err)
+	var report struct {
+		Verdict  string `json:"verdict"`
+		Requests []struct {
+			Target string `json:"target"`
+			Name   string `json:"name"`
+		} `json:"requests"`
+		Targets []struct {
+			Name        string `json:"name"`
+			TargetReady bool   `json:"target-ready"`
+		} `json:"targets"`
+	}
+	require.NoError(t, json.Unmarshal(content, &report))
+	assert.Equal(t, "ready", report.Verdict)
+	require.Equal(t, 2, len(report.Targets))
+	assert.Equal(t, "app", report.Targets[0].Name)
+	assert.True(t, report.Targets[0].TargetReady)
+	assert.Equal(t, "cache", report.Targets[1].Name)
+	assert.True(t, report.Targets[1].TargetReady)
+	assert.Equal(t, 2, len(report.Requests))
+
+	readyFileExists, err := probe.FileExists("ready")
+	require.NoError(t, err)
+	assert.True(t, readyFileExists)
+}
+
+func TestMultipleTargetsFailReadinessIfOneIsNeverReady(t *testing.T) {
+	t.Cleanup(func() {
+		cleanup()
+	})
+
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+	os.Args = []string{
+		"mittens",
+		"-file-probe-enabled=true",
+		fmt.Sprintf("-target-http-port=%d", mockHttpServerPort),
+		fmt.Sprintf("-target-readiness-port=%d", mockHttpServerPort),
+		"-target-readiness-http-path=/health",
+		`-targets={"name": "app", "http-requests": ["get:/hello-world"]}`,
+		`-targets={"name": "cache", "http-port": 9999, "http-requests": ["get:/cached"]}`,
+		"-exit-after-warmup=true",
+		"-max-duration-seconds=2",
+		"-fail-readiness=true",
+		"-report-file=" + reportFile,
+	}
+
+	cmd.CreateConfig()
+	cmd.RunCmdRoot()
+
+	content, err := os.ReadFile(reportFile)
+	require.NoError(t, err)
+	var report struct {
+		Verdict string   `json:"verdict"`
+		Reasons []string `json:"reasons"`
+	}
+	require.NoError(t, json.Unmarshal(content, &report))
+	assert.Equal(t, "not-ready", report.Verdict)
+	assert.Equal(t, []string{"cache: no requests were sent"}, report.Reasons)
+
+	readyFileExists, err := probe.FileExists("ready")
+	require.NoError(t, err)
+	assert.False(t, readyFileExists)
+}
+
 func TestGrpcAndHttpWithVariousReflectionAPICombinations(t *testing.T) {
 	testConfigs := []struct {
 		name      string
@@ -204,6 +432,85 @@ func TestGrpcAndHttpWithVariousReflectionAPICombinations(t *testing.T) {
 	}
 }
 
+func TestGrpcWithProtoFiles(t *testing.T) {
+	t.Cleanup(func() {
+		cleanup()
+	})
+
+	callStats := fixture.NewCallStats()
+	server, port := fixture.StartGrpcTargetTestServerWithoutReflection(callStats)
+	defer server.GracefulStop()
+
+	protoDir := t.TempDir()
+	proto := `syntax = "proto3";
+package grpc.testing;
+message Empty {}
+service TestService {
+  rpc EmptyCall(Empty) returns (Empty);
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(protoDir, "test.proto"), []byte(proto), 0644))
+
+	os.Args = []string{
+		"mittens",
+		"-file-probe-enabled=true",
+		fmt.Sprintf("-target-grpc-port=%d", port),
+		fmt.Sprintf("-target-http-port=%d", mockHttpServerPort),
+		fmt.Sprintf("-target-readiness-port=%d", mockHttpServerPort),
+		"-grpc-requests=grpc.testing.TestService/EmptyCall",
+		"-grpc-proto-files=test.proto",
+		"-grpc-import-paths=" + protoDir,
+		"-target-insecure=true",
+		"-exit-after-warmup=true",
+		"-target-readiness-http-path=/health",
+		"-max-duration-seconds=2",
+	}
+
+	cmd.CreateConfig()
+	cmd.RunCmdRoot()
+
+	assert.GreaterOrEqual(t, len(callStats.StatusesByMethod["/grpc.testing.TestService/EmptyCall"]), 1, "Assert that some calls were made to the gRPC server")
+}
+
+func TestGrpcExpectedCodes(t *testing.T) {
+	t.Cleanup(func() {
+		cleanup()
+	})
+
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+	os.Args = []string{
+		"mittens",
+		"-file-probe-enabled=true",
+		fmt.Sprintf("-target-grpc-port=%d", mockGrpcServerPort),
+		fmt.Sprintf("-target-http-port=%d", mockHttpServerPort),
+		fmt.Sprintf("-target-readiness-port=%d", mockHttpServerPort),
+		`-grpc-requests={"service-method": "grpc.testing.TestService/EmptyCall", "expect": {"codes": ["UNIMPLEMENTED"]}}`,
+		"-target-insecure=true",
+		"-exit-after-warmup=true",
+		"-target-readiness-http-path=/health",
+		"-max-duration-seconds=2",
+		"-fail-readiness=true",
+		"-fail-readiness-max-error-rate=0",
+		"-report-file=" + reportFile,
+	}
+
+	cmd.CreateConfig()
+	cmd.RunCmdRoot()
+
+	content, err := os.ReadFile(reportFile)
+	require.NoError(t, err)
+	var report struct {
+		Verdict  string `json:"verdict"`
+		Requests []struct {
+			StatusCodes map[string]int `json:"status-codes"`
+		} `json:"requests"`
+	}
+	require.NoError(t, json.Unmarshal(content, &report))
+	assert.Equal(t, "ready", report.Verdict)
+	require.Equal(t, 1, len(report.Requests))
+	assert.Greater(t, report.Requests[0].StatusCodes["Unimplemented"], 0)
+}
+
 func testGrpcAndHttp(t *testing.T, grpcPort int, grpcCallStats *fixture.CallStats) {
 	t.Cleanup(func() {
 		cleanup()
@@ -243,6 +550,570 @@ func testGrpcAndHttp(t *testing.T, grpcPort int, grpcCallStats *fixture.CallStat
 	assert.True(t, readyFileExists)
 }
 
+func TestHttpRequestsPerSecond(t *testing.T) {
+	t.Cleanup(func() {
+		cleanup()
+	})
+
+	os.Args = []string{
+		"mittens",
+		"-file-probe-enabled=true",
+		fmt.Sprintf("-target-http-port=%d", mockHttpServerPort),
+		fmt.Sprintf("-target-readiness-port=%d", mockHttpServerPort),
+		"-http-requests=get:/hello-world",
+		"-concurrency=2",
+		"-request-delay-milliseconds=500",
+		"-http-requests-per-second=20",
+		"-exit-after-warmup=true",
+		"-target-readiness-http-path=/health",
+		"-max-duration-seconds=3",
+	}
+
+	cmd.CreateConfig()
+	cmd.RunCmdRoot()
+
+	// with a delay of 500ms two workers would send less than 10 requests, the target rate ignores the delay but caps the number of requests
+	assert.Greater(t, httpInvocations, 10, "Assert that the request delay was ignored")
+	assert.LessOrEqual(t, httpInvocations, 60, "Assert that the requests were rate limited")
+}
+
+func TestHttpRequestsPerSecondSlowTarget(t *testing.T) {
+	t.Cleanup(func() {
+		cleanup()
+	})
+
+	// a single worker waiting 100ms for each response would send at most 10 requests per second
+	rates := runSlowTargetAtRate(t, "-concurrency=1")
+
+	require.Equal(t, 1, len(rates))
+	assert.Equal(t, "http-requests-per-second", rates[0].Name)
+	assert.Equal(t, 50, rates[0].Target)
+	assert.Greater(t, rates[0].Achieved, 40.0, "Assert that the rate does not depend on the latency of the target")
+	assert.Less(t, rates[0].Achieved, 60.0)
+}
+
+func TestHttpRequestsPerSecondNotReached(t *testing.T) {
+	t.Cleanup(func() {
+		cleanup()
+	})
+
+	// a single request in flight waiting 100ms for each response sends at most 10 requests per second
+	rates := runSlowTargetAtRate(t, "-max-in-flight-requests=1")
+
+	require.Equal(t, 1, len(rates))
+	assert.Equal(t, 50, rates[0].Target)
+	assert.Greater(t, rates[0].Achieved, 5.0)
+	assert.Less(t, rates[0].Achieved, 15.0, "Assert that the achieved rate is reported")
+}
+
+type rate struct {
+	Name     string  `json:"name"`
+	Target   int     `json:"target"`
+	Achieved float64 `json:"achieved"`
+}
+
+// runSlowTargetAtRate sends 50 requests per second to a target which takes 100ms to respond and returns the rates of the report.
+func runSlowTargetAtRate(t *testing.T, args ...string) []rate {
+	server, port := fixture.StartHttpTargetTestServer([]fixture.PathResponseHandler{
+		{Path: "/slow", PathHandlerFunc: func(rw http.ResponseWriter, r *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+		}},
+	})
+	defer server.Close()
+
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+	os.Args = append([]string{
+		"mittens",
+		fmt.Sprintf("-target-http-port=%d", port),
+		fmt.Sprintf("-target-readiness-port=%d", port),
+		"-target-readiness-http-path=/health",
+		"-http-requests=get:/slow",
+		"-http-requests-per-second=50",
+		"-exit-after-warmup=true",
+		"-max-duration-seconds=3",
+		"-report-file=" + reportFile,
+	}, args...)
+
+	cmd.CreateConfig()
+	cmd.RunCmdRoot()
+
+	content, err := os.ReadFile(reportFile)
+	require.NoError(t, err)
+	var report struct {
+		Rates []rate `json:"rates"`
+	}
+	require.NoError(t, json.Unmarshal(content, &report))
+	return report.Rates
+}
+
+func TestHttpRampUpStages(t *testing.T) {
+	t.Cleanup(func() {
+		cleanup()
+	})
+
+	os.Args = []string{
+		"mittens",
+		"-file-probe-enabled=true",
+		fmt.Sprintf("-target-http-port=%d", mockHttpServerPort),
+		fmt.Sprintf("-target-readiness-port=%d", mockHttpServerPort),
+		"-http-requests=get:/hello-world",
+		"-request-delay-milliseconds=0",
+		"-http-requests-per-second=40",
+		"-ramp-up-profile=stages",
+		"-ramp-up-stages=2s:1,10s:4",
+		"-exit-after-warmup=true",
+		"-target-readiness-http-path=/health",
+		"-max-duration-seconds=5",
+	}
+
+	cmd.CreateConfig()
+	cmd.RunCmdRoot()
+
+	// the rate is scaled with the number of workers: 10 requests per second during the first stage and 40 afterwards
+	assert.Greater(t, httpInvocations, 30, "Assert that the second stage was reached")
+	assert.LessOrEqual(t, httpInvocations, 130, "Assert that the rate was scaled during the first stage")
+}
+
+func TestHttpConvergence(t *testing.T) {
+	t.Cleanup(func() {
+		cleanup()
+	})
+
+	os.Args = []string{
+		"mittens",
+		"-file-probe-enabled=true",
+		fmt.Sprintf("-target-http-port=%d", mockHttpServerPort),
+		fmt.Sprintf("-target-readiness-port=%d", mockHttpServerPort),
+		"-http-requests=get:/hello-world",
+		"-exit-after-warmup=true",
+		"-target-readiness-http-path=/health",
+		"-request-delay-milliseconds=50",
+		"-max-duration-seconds=30",
+		"-max-warmup-seconds=20",
+		"-convergence-enabled=true",
+		"-convergence-tolerance-percent=50",
+		"-convergence-window-seconds=2",
+		"-convergence-min-seconds=3",
+		"-convergence-min-requests=5",
+	}
+
+	start := time.Now()
+	cmd.CreateConfig()
+	cmd.RunCmdRoot()
+
+	assert.Less(t, time.Since(start), 15*time.Second, "Assert that the warmup stopped before max-warmup-seconds")
+	assert.Greater(t, httpInvocations, 5)
+
+	readyFileExists, err := probe.FileExists("ready")
+	require.NoError(t, err)
+	assert.True(t, readyFileExists)
+}
+
+func TestHttpConvergence_OnceRequest(t *testing.T) {
+	t.Cleanup(func() {
+		cleanup()
+	})
+
+	os.Args = []string{
+		"mittens",
+		"-file-probe-enabled=true",
+		fmt.Sprintf("-target-http-port=%d", mockHttpServerPort),
+		fmt.Sprintf("-target-readiness-port=%d", mockHttpServerPort),
+		"-http-requests=get:/hello-world",
+		`-http-requests={"method": "get", "path": "/status", "once": true}`,
+		"-exit-after-warmup=true",
+		"-target-readiness-http-path=/health",
+		"-request-delay-milliseconds=50",
+		"-max-duration-seconds=30",
+		"-max-warmup-seconds=20",
+		"-convergence-enabled=true",
+		"-convergence-tolerance-percent=50",
+		"-convergence-window-seconds=2",
+		"-convergence-min-seconds=3",
+		"-convergence-min-requests=5",
+	}
+
+	start := time.Now()
+	cmd.CreateConfig()
+	cmd.RunCmdRoot()
+
+	assert.Less(t, time.Since(start), 15*time.Second, "Assert that the once request did not keep the warmup going")
+}
+
+func TestHttpReportFile(t *testing.T) {
+	t.Cleanup(func() {
+		cleanup()
+	})
+
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+	os.Args = []string{
+		"mittens",
+		"-file-probe-enabled=true",
+		fmt.Sprintf("-target-http-port=%d", mockHttpServerPort),
+		fmt.Sprintf("-target-readiness-port=%d", mockHttpServerPort),
+		"-http-requests=get:/hello-world",
+		"-http-headers=Authorization: secret",
+		"-exit-after-warmup=true",
+		"-target-readiness-http-path=/health",
+		"-max-duration-seconds=2",
+		"-report-file=" + reportFile,
+	}
+
+	cmd.CreateConfig()
+	cmd.RunCmdRoot()
+
+	content, err := os.ReadFile(reportFile)
+	require.NoError(t, err)
+	var report struct {
+		Verdict     string                 `json:"verdict"`
+		TargetReady bool                   `json:"target-ready"`
+		Config      map[string]interface{} `json:"config"`
+		Requests    []struct {
+			Name   string `json:"name"`
+			Counts struct {
+				Requests int `json:"requests"`
+			} `json:"counts"`
+			StatusCodes map[string]int `json:"status-codes"`
+		} `json:"requests"`
+	}
+	require.NoError(t, json.Unmarshal(content, &report))
+
+	assert.Equal(t, "ready", report.Verdict)
+	assert.True(t, report.TargetReady)
+	assert.Equal(t, []interface{}{"Authorization: <redacted>"}, report.Config["http-headers"])
+	require.Equal(t, 1, len(report.Requests))
+	assert.Equal(t, "GET /hello-world", report.Requests[0].Name)
+	assert.Equal(t, httpInvocations, report.Requests[0].Counts.Requests)
+	assert.Equal(t, httpInvocations, report.Requests[0].StatusCodes["200"])
+}
+
+func TestHttpExpectations(t *testing.T) {
+	t.Cleanup(func() {
+		cleanup()
+	})
+
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+	os.Args = []string{
+		"mittens",
+		"-file-probe-enabled=true",
+		fmt.Sprintf("-target-http-port=%d", mockHttpServerPort),
+		fmt.Sprintf("-target-readiness-port=%d", mockHttpServerPort),
+		`-http-requests={"name": "up", "method": "get", "path": "/status", "expect": {"headers": {"Content-Type": "json"}, "json-path": {"$.status": "UP"}}}`,
+		`-http-requests={"name": "down", "method": "get", "path": "/status", "expect": {"json-path": {"$.status": "DOWN"}}}`,
+		`-http-requests={"name": "missing", "method": "get", "path": "/missing", "expect": {"status": [404]}}`,
+		"-exit-after-warmup=true",
+		"-target-readiness-http-path=/health",
+		"-max-duration-seconds=2",
+		"-report-file=" + reportFile,
+	}
+
+	cmd.CreateConfig()
+	cmd.RunCmdRoot()
+
+	content, err := os.ReadFile(reportFile)
+	require.NoError(t, err)
+	var report struct {
+		Requests []struct {
+			Name   string `json:"name"`
+			Counts struct {
+				Requests  int `json:"requests"`
+				Succeeded int `json:"succeeded"`
+				Failed    int `json:"failed"`
+			} `json:"counts"`
+			Failures map[string]int `json:"failures"`
+		} `json:"requests"`
+	}
+	require.NoError(t, json.Unmarshal(content, &report))
+
+	require.Equal(t, 3, len(report.Requests))
+	for _, request := range report.Requests {
+		assert.Greater(t, request.Counts.Requests, 0, request.Name)
+		switch request.Name {
+		case "up", "missing":
+			assert.Equal(t, request.Counts.Requests, request.Counts.Succeeded, request.Name)
+		case "down":
+			assert.Equal(t, request.Counts.Requests, request.Counts.Failed)
+			assert.Equal(t, map[string]int{`$.status is "UP", expected "DOWN"`: request.Counts.Requests}, request.Failures)
+		}
+	}
+}
+
+func TestScenarios(t *testing.T) {
+	t.Cleanup(func() {
+		cleanup()
+	})
+
+	// each session gets its own token, which must be sent along with the later requests of the session
+	var sessions int32
+	var tokens sync.Map
+	var checked int32
+	server, port := fixture.StartHttpTargetTestServer([]fixture.PathResponseHandler{
+		{Path: "/sessions", PathHandlerFunc: func(rw http.ResponseWriter, r *http.Request) {
+			id := atomic.AddInt32(&sessions, 1)
+			token := fmt.Sprintf("token-%d", id)
+			tokens.Store(fmt.Sprintf("session-%d", id), token)
+			rw.Header().Set("X-Token", token)
+			rw.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(rw, `{"session": {"id": "session-%d", "size": 2}}`, id)
+		}},
+		{Path: "/sessions/", PathHandlerFunc: func(rw http.ResponseWriter, r *http.Request) {
+			token, ok := tokens.Load(strings.TrimPrefix(r.URL.Path, "/sessions/"))
+			if !ok || r.Header.Get("X-Token") != token {
+				rw.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			atomic.AddInt32(&checked, 1)
+		}},
+	})
+	defer server.Close()
+
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+	os.Args = []string{
+		"mittens",
+		fmt.Sprintf("-target-http-port=%d", port),
+		fmt.Sprintf("-target-grpc-port=%d", mockGrpcServerPort),
+		fmt.Sprintf("-target-readiness-port=%d", port),
+		"-target-readiness-http-path=/health",
+		"-target-insecure=true",
+		`-scenarios={"name": "session", "steps": [
+			{"http": "post:/sessions", "extract": {"session": {"json-path": "$.session.id"}, "size": {"json-path": "$.session.size"}, "token": {"header": "X-Token"}}},
+			{"grpc": {"name": "stream", "service-method": "grpc.testing.TestService/StreamingOutputCall", "message": "{\"response_parameters\": [{\"size\": {$var|size}}]}"}, "extract": {"payload": {"json-path": "$.payload.body"}}},
+			{"http": {"name": "check", "method": "get", "path": "/sessions/{$var|session}?payload={$var|payload}", "headers": {"X-Token": "{$var|token}"}}}
+		]}`,
+		"-exit-after-warmup=true",
+		"-max-duration-seconds=2",
+		"-request-delay-milliseconds=50",
+		"-report-file=" + reportFile,
+	}
+
+	cmd.CreateConfig()
+	cmd.RunCmdRoot()
+
+	assert.Greater(t, atomic.LoadInt32(&checked), int32(0), "Assert that the last step got the variables of the first one")
+
+	content, err := os.ReadFile(reportFile)
+	require.NoError(t, err)
+	var report struct {
+		Requests []struct {
+			Name   string `json:"name"`
+			Counts struct {
+				Requests  int `json:"requests"`
+				Succeeded int `json:"succeeded"`
+				Errors    int `json:"errors"`
+			} `json:"counts"`
+		} `json:"requests"`
+	}
+	require.NoError(t, json.Unmarshal(content, &report))
+
+	names := make([]string, 0, len(report.Requests))
+	for _, request := range report.Requests {
+		names = append(names, request.Name)
+		assert.Greater(t, request.Counts.Requests, 0, request.Name)
+		assert.Equal(t, request.Counts.Requests, request.Counts.Succeeded, request.Name)
+		assert.Equal(t, 0, request.Counts.Errors, request.Name)
+	}
+	assert.ElementsMatch(t, []string{"session: POST /sessions", "session: stream", "session: check"}, names)
+}
+
+func TestAccessLogReplay(t *testing.T) {
+	t.Cleanup(func() {
+		cleanup()
+	})
+
+	var mu sync.Mutex
+	var paths []string
+	record := func(rw http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.URL.RequestURI())
+	}
+	server, port := fixture.StartHttpTargetTestServer([]fixture.PathResponseHandler{
+		{Path: "/hotels/", PathHandlerFunc: record},
+		{Path: "/search", PathHandlerFunc: record},
+	})
+	defer server.Close()
+
+	// the requests are a second apart in the log, which is replayed ten times faster
+	accessLog := filepath.Join(t.TempDir(), "access.log")
+	require.NoError(t, os.WriteFile(accessLog, []byte(`127.0.0.1 - - [10/Oct/2024:13:55:36 +0000] "GET /hotels/42?email=jane%40example.com HTTP/1.1" 200 10 "-" "curl/8.0"
+127.0.0.1 - - [10/Oct/2024:13:55:37 +0000] "GET /search?q=paris HTTP/1.1" 200 10 "-" "curl/8.0"
+127.0.0.1 - - [10/Oct/2024:13:55:38 +0000] "GET /hotels/7 HTTP/1.1" 200 10 "-" "curl/8.0"
+`), 0644))
+
+	os.Args = []string{
+		"mittens",
+		fmt.Sprintf("-target-http-port=%d", port),
+		fmt.Sprintf("-target-readiness-port=%d", port),
+		"-target-readiness-http-path=/health",
+		"-target-insecure=true",
+		"-access-log-files=" + accessLog,
+		"-access-log-mode=replay",
+		"-access-log-replay-speed=10",
+		"-access-log-drop-query-params=email",
+		"-exit-after-warmup=true",
+		"-max-duration-seconds=2",
+	}
+
+	cmd.CreateConfig()
+	cmd.RunCmdRoot()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Greater(t, len(paths), 3, "Assert that the log is replayed again once it is over")
+	// the first request of the next replay may arrive along with the last one of the log, so only the first requests are compared
+	assert.Equal(t, []string{"/hotels/42", "/search?q=paris"}, paths[:2], "Assert that the requests are replayed in order")
+	assert.Contains(t, paths[2:4], "/hotels/7")
+}
+
+func TestAccessLogReplay_OnceDataset(t *testing.T) {
+	t.Cleanup(func() {
+		cleanup()
+	})
+
+	var mu sync.Mutex
+	var hotels []string
+	searches := 0
+	server, port := fixture.StartHttpTargetTestServer([]fixture.PathResponseHandler{
+		{Path: "/hotels/", PathHandlerFunc: func(rw http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			hotels = append(hotels, r.URL.RequestURI())
+		}},
+		{Path: "/search", PathHandlerFunc: func(rw http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			searches++
+		}},
+	})
+	defer server.Close()
+
+	dir := t.TempDir()
+	accessLog := filepath.Join(dir, "access.log")
+	require.NoError(t, os.WriteFile(accessLog, []byte(`127.0.0.1 - - [10/Oct/2024:13:55:36 +0000] "GET /hotels/42 HTTP/1.1" 200 10
+127.0.0.1 - - [10/Oct/2024:13:55:37 +0000] "GET /search?q=paris HTTP/1.1" 200 10
+`), 0644))
+	ids := filepath.Join(dir, "ids.csv")
+	require.NoError(t, os.WriteFile(ids, []byte("id\n1\n2\n"), 0644))
+
+	os.Args = []string{
+		"mittens",
+		fmt.Sprintf("-target-http-port=%d", port),
+		fmt.Sprintf("-target-readiness-port=%d", port),
+		"-target-readiness-http-path=/health",
+		"-target-insecure=true",
+		"-access-log-files=" + accessLog,
+		"-access-log-mode=replay",
+		"-access-log-replay-speed=10",
+		`-access-log-replace=/hotels/\d+=/hotels/{$data|ids.id}`,
+		`-datasets={"name": "ids", "file": "` + ids + `", "mode": "once"}`,
+		"-exit-after-warmup=true",
+		"-max-duration-seconds=2",
+	}
+
+	cmd.CreateConfig()
+	cmd.RunCmdRoot()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"/hotels/1", "/hotels/2"}, hotels, "Assert that replayed requests are skipped once their once dataset has no rows left")
+	assert.Greater(t, searches, 2, "Assert that the other requests are still replayed")
+}
+
+func TestDatasets(t *testing.T) {
+	t.Cleanup(func() {
+		cleanup()
+	})
+
+	var mu sync.Mutex
+	var received []string
+	server, port := fixture.StartHttpTargetTestServer([]fixture.PathResponseHandler{
+		{Path: "/hotels/", PathHandlerFunc: func(rw http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, r.URL.RequestURI()+" "+r.Header.Get("Accept-Language"))
+		}},
+	})
+	defer server.Close()
+
+	hotels := filepath.Join(t.TempDir(), "hotels.csv")
+	require.NoError(t, os.WriteFile(hotels, []byte("id,locale\n42,en-GB\n7,fr-FR\n"), 0644))
+
+	os.Args = []string{
+		"mittens",
+		fmt.Sprintf("-target-http-port=%d", port),
+		fmt.Sprintf("-target-readiness-port=%d", port),
+		"-target-readiness-http-path=/health",
+		"-target-insecure=true",
+		`-datasets={"name": "hotels", "file": "` + hotels + `", "mode": "once"}`,
+		`-http-requests={"method": "get", "path": "/hotels/{$data|hotels.id}", "headers": {"Accept-Language": "{$data|hotels.locale}"}}`,
+		"-exit-after-warmup=true",
+		"-max-duration-seconds=5",
+	}
+
+	cmd.CreateConfig()
+	cmd.RunCmdRoot()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.ElementsMatch(t, []string{"/hotels/42 en-GB", "/hotels/7 fr-FR"}, received, "Assert that each row is sent once, with its columns used together")
+}
+
+func TestServerProbes(t *testing.T) {
+	t.Cleanup(func() {
+		cleanup()
+	})
+
+	serverPort := freePort(t)
+	os.Args = []string{
+		"mittens",
+		"-file-probe-enabled=false",
+		"-server-probe-enabled=true",
+		"-server-metrics-enabled=true",
+		fmt.Sprintf("-server-port=%d", serverPort),
+		fmt.Sprintf("-target-http-port=%d", mockHttpServerPort),
+		fmt.Sprintf("-target-readiness-port=%d", mockHttpServerPort),
+		"-http-requests=get:/hello-world",
+		"-exit-after-warmup=true",
+		"-target-readiness-http-path=/health",
+		"-max-duration-seconds=3",
+	}
+
+	cmd.CreateConfig()
+	done := make(chan bool)
+	go func() {
+		cmd.RunCmdRoot()
+		close(done)
+	}()
+
+	// mittens is alive straight away but only ready once the warmup finishes
+	time.Sleep(2500 * time.Millisecond)
+	assert.Equal(t, http.StatusOK, probeStatusCode(t, serverPort, "/alive"))
+	assert.Equal(t, http.StatusServiceUnavailable, probeStatusCode(t, serverPort, "/ready"))
+
+	resp, err := http.Get(fmt.Sprintf("http://localhost:%d/metrics", serverPort))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `mittens_warmup_phase{phase="warming",target=""} 1`)
+	assert.Contains(t, string(body), `mittens_requests_total{endpoint="GET /hello-world",outcome="success",protocol="http",status="200",target=""}`)
+	<-done
+}
+
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func probeStatusCode(t *testing.T, port int, path string) int {
+	resp, err := http.Get(fmt.Sprintf("http://localhost:%d%s", port, path))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	return resp.StatusCode
+}
+
 func TestCompressWithGZip(t *testing.T) {
 	t.Cleanup(func() {
 		cleanup()
@@ -286,6 +1157,13 @@ func setup() {
 				w.WriteHeader(http.StatusOK)
 			},
 		},
+		{
+			Path: "/status",
+			PathHandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"status": "UP"}`))
+			},
+		},
 		{
 			Path: "/compressed",
 			PathHandlerFunc: func(w http.ResponseWriter, r *http.Request) {